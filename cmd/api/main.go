@@ -3,149 +3,253 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
-	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
-	"go.uber.org/zap"
-	gormpg "gorm.io/driver/postgres"
-	gormsqlite "gorm.io/driver/sqlite"
-
-	"gorm.io/gorm"
-	_ "modernc.org/sqlite" // penting: register driver "sqlite" (pure Go, no CGO)
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/Quineeryn/go-backend-101/internal/auth"
 	"github.com/Quineeryn/go-backend-101/internal/cache"
 	"github.com/Quineeryn/go-backend-101/internal/config"
+	appdb "github.com/Quineeryn/go-backend-101/internal/db"
 	"github.com/Quineeryn/go-backend-101/internal/docs"
+	"github.com/Quineeryn/go-backend-101/internal/health"
 	"github.com/Quineeryn/go-backend-101/internal/httpx"
+	"github.com/Quineeryn/go-backend-101/internal/idempotency"
+	"github.com/Quineeryn/go-backend-101/internal/jobs"
 	"github.com/Quineeryn/go-backend-101/internal/logger"
 	"github.com/Quineeryn/go-backend-101/internal/middleware"
 	"github.com/Quineeryn/go-backend-101/internal/ratelimit"
+	"github.com/Quineeryn/go-backend-101/internal/settings"
+	"github.com/Quineeryn/go-backend-101/internal/storage"
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
 	"github.com/Quineeryn/go-backend-101/internal/users"
+	"github.com/Quineeryn/go-backend-101/internal/webhooks"
 )
 
 func main() {
 	_ = godotenv.Load()
 
 	// === config & logger ===
-	cfg := config.FromEnv()
+	// prioritas: default < file (--config / CONFIG_FILE) < env < flag
+	cfg, cfgOpt, err := config.Load(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if cfgOpt.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	_ = logger.Init(logger.Config{
 		Env:        cfg.Env,
-		FilePath:   cfg.LogFilePath,
-		MaxSizeMB:  cfg.LogMaxSizeMB,
-		MaxBackups: cfg.LogMaxBackups,
-		MaxAgeDays: cfg.LogMaxAgeDays,
+		FilePath:   cfg.Log.FilePath,
+		MaxSizeMB:  cfg.Log.MaxSizeMB,
+		MaxBackups: cfg.Log.MaxBackups,
+		MaxAgeDays: cfg.Log.MaxAgeDays,
+		Level:      cfg.Log.Level,
 	})
 	defer logger.L.Sync()
 
+	// runtime settings: rate limit, cache TTL & log level bisa di-reload
+	// (file --config di-poll, atau SIGHUP) tanpa restart
+	rt := settings.New(cfg, func() (config.Config, error) {
+		c, _, err := config.Load(os.Args[1:])
+		return c, err
+	})
+	rt.OnChange(func(c config.Config) {
+		if err := logger.SetLevel(c.Log.Level); err != nil {
+			slog.Warn("settings.log_level", "err", err)
+		}
+	})
+
 	appLogger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	slog.SetDefault(appLogger)
 
-	// === DB (GORM: Postgres atau SQLite) ===
-	dsn := cfg.DBDSN
-	if dsn == "" {
-		dsn = "var/app.db" // fallback dev
+	// === production preflight: laporkan semua pelanggaran sekaligus ===
+	pre := preflight(cfg)
+	for _, w := range pre.Warnings {
+		slog.Warn("preflight.warning", "msg", w)
 	}
-	dialect := "sqlite"
-	if strings.HasPrefix(dsn, "postgres://") || strings.Contains(dsn, "host=") {
-		dialect = "postgres"
+	if !pre.OK() {
+		for _, v := range pre.Violations {
+			slog.Error("preflight.violation", "msg", v)
+		}
+		fmt.Fprintln(os.Stderr, pre)
+		os.Exit(1)
 	}
 
-	var db *gorm.DB
-	var err error
+	// === DB (GORM: Postgres atau SQLite, lihat internal/db) ===
+	openCtx, cancelOpen := context.WithTimeout(context.Background(), 2*time.Minute)
+	db, dsn, err := appdb.Open(openCtx, cfg.DB.Options())
+	cancelOpen()
+	if err != nil {
+		slog.Error("db.open.failed", "err", err)
+		os.Exit(1)
+	}
+	dialect := dsn.Dialect
+	if sqlDB, err := db.DB(); err == nil {
+		if err := appdb.RegisterStats(sqlDB, "primary"); err != nil {
+			slog.Warn("db.metrics", "err", err)
+		}
+	}
+	slog.Info("db.connected", "dialect", dialect)
 
-	if dialect == "postgres" {
-		db, err = gorm.Open(gormpg.Open(dsn), &gorm.Config{})
+	// read replica (opsional): baca users ke replica, tulis ke primary
+	var replicas []appdb.Replica
+	for i, rdsn := range cfg.DB.ReplicaDSNs {
+		name := fmt.Sprintf("replica-%d", i+1)
+		rdb, _, err := appdb.Open(context.Background(), cfg.DB.ReplicaOptions(rdsn))
 		if err != nil {
-			slog.Error("db.open.failed", "dialect", "postgres", "err", err)
+			slog.Error("db.replica.open.failed", "replica", name, "err", err)
 			os.Exit(1)
 		}
-		sqlDB, _ := db.DB()
-		sqlDB.SetMaxOpenConns(30)
-		sqlDB.SetMaxIdleConns(10)
-		sqlDB.SetConnMaxLifetime(30 * time.Minute)
-	} else {
-		ensureDirFor(dsn) // hanya untuk SQLite (file)
-		dial := gormsqlite.Dialector{DriverName: "sqlite", DSN: stripSQLiteURI(dsn)}
-		db, err = gorm.Open(dial, &gorm.Config{})
-		if err != nil {
-			slog.Error("db.open.failed", "dialect", "sqlite", "err", err)
-			os.Exit(1)
+		if sqlDB, err := rdb.DB(); err == nil {
+			_ = appdb.RegisterStats(sqlDB, name)
 		}
-		_ = db.Exec("PRAGMA foreign_keys = ON;").Error
-		_ = db.Exec("PRAGMA journal_mode = WAL;").Error
-		_ = db.Exec("PRAGMA busy_timeout = 5000;").Error
+		var lag appdb.LagFunc
+		if dialect == appdb.Postgres {
+			lag = appdb.PostgresLag
+		}
+		replicas = append(replicas, appdb.Replica{Name: name, DB: rdb, Lag: lag})
 	}
-
-	// === migrate (DEV only) ===
-	if getEnv("AUTO_MIGRATE", "false") == "true" {
-		if dialect == "sqlite" {
-			if err := db.AutoMigrate(&users.User{}, &auth.RefreshToken{}); err != nil {
-				slog.Error("migrate.failed", "err", err)
-				os.Exit(1)
-			}
-		} else {
-			slog.Warn("AUTO_MIGRATE ignored on Postgres; run `make migrate-pg` instead")
+	dbs := appdb.NewCluster(db, cfg.DB.ReplicaMaxLag, replicas...)
+	dbs.CheckReplicas(context.Background(), cfg.Health.DBTimeout)
+	bgCtx, stopBg := context.WithCancel(context.Background())
+	defer stopBg()
+	go dbs.WatchReplicas(bgCtx, cfg.DB.ReplicaCheckInterval, cfg.Health.DBTimeout)
+
+	// === migrate (opsional) + schema guard ===
+	if cfg.DB.AutoMigrate {
+		if err := runMigrations(db, dialect); err != nil {
+			slog.Error("migrate.failed", "err", err)
+			os.Exit(1)
 		}
 	}
+	schema := newSchemaGate(db, dialect, cfg.DB.SchemaGuard)
+	if err := schema.Guard(context.Background()); err != nil {
+		slog.Error("schema.guard.failed", "err", err)
+		os.Exit(1)
+	}
 
 	// === deps ===
-	userStore := users.NewStore(db)
+	userStore := users.NewReplicatedStore(dbs)
 	tokenStore := auth.NewStore(db)
 	jwtMgr := &auth.Manager{
-		Secret:     []byte(getEnv("JWT_SECRET", "dev-secret-change-me")),
-		AccessTTL:  mustParseDur(getEnv("JWT_ACCESS_TTL", "15m")),
-		RefreshTTL: mustParseDur(getEnv("JWT_REFRESH_TTL", "168h")),
+		Secret:     []byte(cfg.JWT.Secret),
+		AccessTTL:  cfg.JWT.AccessTTL,
+		RefreshTTL: cfg.JWT.RefreshTTL,
 	}
 
 	// === CP12: Redis client (global) ===
-	redisCli := cache.NewRedis(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
+	// Semua konsumen selalu dipasang; saat Redis mati circuit breaker membuat
+	// perintah gagal cepat (tanpa timeout) dan tiap konsumen memakai fail
+	// mode-nya. Breaker menutup sendiri begitu Redis kembali.
+	redisCli := cache.NewRedisWith(cache.RedisOptions{
+		Mode:             cfg.Redis.Mode,
+		Addr:             cfg.Redis.Addr,
+		Addrs:            cfg.Redis.Addrs,
+		MasterName:       cfg.Redis.MasterName,
+		Password:         cfg.Redis.Password,
+		SentinelPassword: cfg.Redis.SentinelPassword,
+		DB:               cfg.Redis.DB,
+		DialTimeout:      cfg.Redis.DialTimeout,
+		ReadTimeout:      cfg.Redis.ReadTimeout,
+		WriteTimeout:     cfg.Redis.WriteTimeout,
+		Breaker:          cache.BreakerOptions{Threshold: cfg.Redis.BreakerThreshold, Cooldown: cfg.Redis.BreakerCooldown},
+	})
 	if err := redisCli.Ping(context.Background()); err != nil {
-		slog.Warn("redis.ping.failed", "err", err, "addr", cfg.RedisAddr)
+		slog.Warn("redis.ping.failed", "err", err, "mode", cfg.Redis.Mode, "addr", cfg.Redis.Addr)
 	} else {
-		slog.Info("redis.connected", "addr", cfg.RedisAddr, "db", cfg.RedisDB)
+		slog.Info("redis.connected", "mode", cfg.Redis.Mode, "addr", cfg.Redis.Addr, "db", cfg.Redis.DB)
 	}
 
-	// === CP12: users repo dibungkus cache-aside (fallback ke DB-only jika Redis bermasalah) ===
-	var usersRepo users.Repo = userStore
-	if err := redisCli.Ping(context.Background()); err == nil {
-		usersRepo = users.NewCachedStore(userStore, redisCli.C, mustParseDur(getEnv("USERS_CACHE_TTL", "5m")))
-	}
+	// === CP12: users repo dibungkus cache-aside (Redis error = miss → DB) ===
+	cachedUsers := users.NewCachedStore(userStore, redisCli.C, cfg.Cache.UsersTTL)
+	cachedUsers.SetOptions(usersCacheOptions(cfg))
+	rt.OnChange(func(c config.Config) {
+		cachedUsers.SetTTL(c.Cache.UsersTTL)
+		cachedUsers.SetOptions(usersCacheOptions(c))
+	})
+	var usersRepo users.Repo = cachedUsers
+
+	// === webhooks: delivery jalan di background, users handler cuma enqueue ===
+	webhookStore := webhooks.NewStore(db)
+	webhookDisp := webhooks.NewDispatcher(webhookStore, webhooks.Options{
+		Workers:     cfg.Webhooks.Workers,
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		BaseBackoff: cfg.Webhooks.BaseBackoff,
+		MaxBackoff:  cfg.Webhooks.MaxBackoff,
+	})
 
-	logger.L, err = zap.NewProduction() // atau zap.NewExample() untuk dev
-	if err != nil {
-		log.Fatalf("failed to init logger: %v", err)
+	// response cache (/v1/users/me): L1 memory + L2 Redis, update/delete user
+	// membuang entry-nya di semua replica (pub/sub). Saat Redis mati hanya L1
+	// (paling lama l1_ttl) yang melayani.
+	memCache := func(name string) *cache.Memory {
+		return cache.NewMemoryWith(cache.MemoryOptions{
+			Name:       name,
+			MaxEntries: cfg.Cache.MemoryMaxEntries,
+			MaxBytes:   int64(cfg.Cache.MemoryMaxMB) << 20,
+			GCInterval: cfg.Cache.MemoryGC,
+		})
 	}
-	defer logger.L.Sync()
+	respCache := cache.NewTiered(memCache("response_l1"), cache.NewRedisStore(redisCli.C), cfg.Cache.L1TTL, redisCli.C)
+	usersRepo = users.NewEventedStore(usersRepo, users.Publishers{webhookDisp, users.CacheEvictor{Store: respCache}})
+
+	// === background jobs (maintenance) ===
+	// Lock per tick di Redis → hanya satu replica yang menjalankan tiap job.
+	// Redis mati: fail mode "local" memakai lock lokal (aman hanya untuk satu
+	// replica), "closed" melewati tick.
+	instance, _ := os.Hostname()
+	var jobLocker jobs.Locker = jobs.NewRedisLocker(redisCli.C, instance)
+	if cfg.Redis.FailMode.Jobs == string(cache.FailLocal) {
+		jobLocker = jobs.FallbackLocker{Primary: jobLocker, Fallback: jobs.NewLocalLocker()}
+	}
+	jobHistory := jobs.NewHistory(db)
+	sched := jobs.NewScheduler(jobLocker, jobHistory, instance)
+	retention := cfg.Jobs.Retention
+	mustAddJob(sched.Add("purge-refresh-tokens", cfg.Jobs.PurgeTokensSchedule, 0,
+		jobs.PurgeBefore(tokenStore, 0)))
+	// belum ada tabel soft-delete (users & refresh_tokens dihapus permanen),
+	// jadi retensi hanya untuk log webhook & riwayat job
+	mustAddJob(sched.Add("purge-retention", cfg.Jobs.PurgeRetentionSchedule, 0,
+		jobs.Chain(
+			jobs.PurgeBefore(webhookStore, retention),
+			jobs.PurgeBefore(jobHistory, retention),
+		)))
+	mustAddJob(sched.Add("rotate-cache", cfg.Jobs.RotateCacheSchedule, 0,
+		jobs.RotateCache(redisCli.C, "app:users:*", cfg.Cache.UsersTTL+cfg.Cache.UsersStaleTTL)))
 
 	// === HTTP server ===
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
+	// handler yang mengoper *gin.Context sebagai ctx ikut membaca
+	// value/deadline dari request context (session DB, timeout)
+	r.ContextWithFallback = true
 	r.Use(gin.Recovery())
 	r.Use(httpx.ErrorMiddleware())
 
 	// CP11 middlewares
 	r.Use(httpx.RequestID())
+	r.Use(appdb.Session()) // read-after-write: setelah menulis, baca ke primary
 	r.Use(httpx.AccessLog())
 	r.Use(httpx.Metrics())
 
 	// timeout context duluan
-	r.Use(timeoutMiddleware(60 * time.Second))
+	r.Use(timeoutMiddleware(cfg.Server.RequestTimeout))
 
 	// middleware CP-06 (urutannya penting)
 	r.Use(
@@ -155,19 +259,61 @@ func main() {
 		middleware.RecoveryJSON(),
 	)
 
-	// In-memory cache kecil untuk /v1/users/me
-	cstore := cache.NewMemory(5 * time.Minute)
+	// tenant: header > subdomain > default; token (claim tid) dicek di RequireAuth.
+	// Sebelum rate limit & idempotency karena key keduanya per tenant.
+	tenantStore := tenant.NewStore(db)
+	tenantSchemas := tenant.NewSchemas(tenantStore, cfg.Tenant.CacheTTL)
+	r.Use(tenant.Middleware(tenantStore, tenant.Options{
+		Header:     cfg.Tenant.Header,
+		BaseDomain: cfg.Tenant.BaseDomain,
+		Default:    cfg.Tenant.Default,
+		CacheTTL:   cfg.Tenant.CacheTTL,
+	}))
+
+	// In-memory cache kecil (fallback idempotency)
+	cstore := memCache("idempotency")
 
 	// === CP13: Distributed Rate Limiting (Redis) ===
-	// default per-IP-per-route
+	// policy per route/user/plan dari rate_limit.policy_file, atau bawaan
+	// (login per IP+email, route lain per IP per route) dari rate_limit.*.
+	// Fail mode "local" memakai varian memory dari algoritma yang sama.
 	{
-		rlDefault := ratelimit.NewRedisLimiter(
-			redisCli.C,
-			mustParseFloat(getEnv("RATE_LIMIT_DEFAULT_RPS", "2")),
-			mustParseInt(getEnv("RATE_LIMIT_DEFAULT_BURST", "10")),
-			60*time.Second,
-		)
-		r.Use(ratelimit.MiddlewareRedis(rlDefault, ratelimit.KeyPerIPRoute))
+		rlPolicies := ratelimit.NewPolicies(redisCli.C, ratelimit.PolicyOptions{
+			FailMode: cache.FailMode(cfg.Redis.FailMode.RateLimit),
+			Identify: rateLimitIdentity(jwtMgr),
+		})
+		if err := applyRateLimitPolicies(rlPolicies, cfg.RateLimit); err != nil {
+			slog.Error("ratelimit.policy.invalid", "err", err)
+			os.Exit(1)
+		}
+		rt.OnChange(func(c config.Config) {
+			if err := applyRateLimitPolicies(rlPolicies, c.RateLimit); err != nil {
+				slog.Warn("ratelimit.policy.reload_failed", "err", err)
+			}
+		})
+		// isi file policy juga di-poll (perubahan file tidak mengubah config)
+		if cfg.ReloadInterval > 0 {
+			go rlPolicies.WatchFile(bgCtx, func() string { return rt.Config().RateLimit.PolicyFile }, cfg.ReloadInterval)
+		}
+		r.Use(rlPolicies.Middleware())
+	}
+
+	// === Idempotency-Key untuk POST yang sering di-retry mobile client ===
+	// Redis (shared antar replica); saat Redis mati sesuai fail mode: local =
+	// in-memory per replica, closed = 503, open = tanpa jaminan.
+	{
+		idemFail := cache.FailMode(cfg.Redis.FailMode.Idempotency)
+		var idemBackend idempotency.Backend = idempotency.NewRedisBackend(redisCli)
+		if idemFail == cache.FailLocal {
+			idemBackend = idempotency.Failover{Primary: idemBackend, Fallback: idempotency.NewMemoryBackend(cstore)}
+		}
+		r.Use(idempotency.Middleware(idemBackend, idempotency.Options{
+			TTL:          cfg.Idem.TTL,
+			MaxBodyBytes: int64(cfg.Idem.MaxBodyBytes),
+			Paths:        []string{"/v1/users", "/v1/auth/register"},
+			FailClosed:   idemFail == cache.FailClosed,
+			UserID:       idempotencyUser(jwtMgr),
+		}))
 	}
 
 	// health
@@ -175,89 +321,132 @@ func main() {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// liveness/readiness (load balancer & k8s probes)
+	hc := health.NewRegistry(cfg.Health.Timeout)
+	if sqlDB, err := db.DB(); err == nil {
+		hc.Readiness(health.Check{Name: "db", Checker: health.DB(sqlDB), Timeout: cfg.Health.DBTimeout})
+	}
+	// Redis opsional: cache & limiter punya fallback, jadi hanya "warn"
+	hc.Readiness(health.Check{Name: "redis", Checker: health.Ping(redisCli), Optional: true})
+	hc.Readiness(health.Check{Name: "migrations", Checker: schema.Checker()})
+	if len(replicas) > 0 {
+		// baca jatuh ke primary saat semua replica down → cukup "warn"
+		hc.Readiness(health.Check{Name: "db_replicas", Checker: health.Ping(dbs), Optional: true})
+	}
+	if dialect == appdb.SQLite && !dsn.Memory() {
+		minFree := uint64(cfg.Health.DiskMinFreeMB) << 20
+		hc.Readiness(health.Check{Name: "disk", Checker: health.DiskSpace(dsn.Path, minFree)})
+	}
+	r.GET("/livez", hc.LivezHandler)
+	r.GET("/readyz", hc.ReadyzHandler)
+
 	// docs
 	r.GET("/openapi.yaml", gin.WrapF(docs.OpenAPISpec))
 	r.GET("/docs", gin.WrapF(docs.Redoc))
 
-	// users routes (handler menerima Repo: store atau cached store)
-	users.RegisterRoutes(r, users.NewHandler(usersRepo))
+	// blob storage (avatar); local disajikan sendiri di /media
+	var blobs storage.BlobStore
+	switch cfg.Storage.Driver {
+	case "s3":
+		blobs, err = storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.Storage.S3.Endpoint,
+			Region:    cfg.Storage.S3.Region,
+			Bucket:    cfg.Storage.S3.Bucket,
+			AccessKey: cfg.Storage.S3.AccessKey,
+			SecretKey: cfg.Storage.S3.SecretKey,
+			PublicURL: cfg.Storage.PublicURL,
+		})
+	default:
+		base := cfg.Storage.PublicURL
+		if base == "" {
+			base = "/media"
+			r.StaticFS("/media", gin.Dir(cfg.Storage.LocalDir, false))
+		}
+		blobs, err = storage.NewLocal(cfg.Storage.LocalDir, base)
+	}
+	if err != nil {
+		slog.Error("storage.init.failed", "driver", cfg.Storage.Driver, "err", err)
+		os.Exit(1)
+	}
+
+	// users routes (handler menerima Repo: store atau cached store); wajib
+	// token, tenant diikat ke claim tid
+	users.RegisterRoutes(r, users.NewHandler(usersRepo).
+		WithAttributeValidator(tenantSchemas).
+		WithAvatars(blobs, users.AvatarOptions{MaxBytes: int64(cfg.Avatar.MaxBytes), MaxPixels: cfg.Avatar.MaxPixels}),
+		auth.RequireAuth(jwtMgr))
+
+	// webhooks admin (subscription CRUD, delivery log, redeliver)
+	webhooks.RegisterRoutes(r,
+		webhooks.NewHandler(webhookStore, webhookDisp, users.Events),
+		auth.RequireAuth(jwtMgr), auth.RequireRole("admin"), tenant.RequirePlatform(),
+	)
 
 	// auth routes (rate limit login lebih ketat)
-	authH := auth.Handler{Users: userStore, Tokens: tokenStore, JWT: jwtMgr}
+	// register menulis langsung ke Store → cache list users dibatalkan via event
+	authEvents := users.Publishers{webhookDisp, users.ListInvalidator{Store: cachedUsers}}
+	authH := auth.Handler{Users: userStore, Tokens: tokenStore, JWT: jwtMgr, Events: authEvents}
 	v1 := r.Group("/v1")
 	{
 		v1.POST("/auth/register", authH.Register)
 
-		rlLogin := ratelimit.NewRedisLimiter(
-			redisCli.C,
-			mustParseFloat(getEnv("RATE_LIMIT_AUTH_RPS", "0.2")), // ~12/min
-			mustParseInt(getEnv("RATE_LIMIT_AUTH_BURST", "5")),
-			60*time.Second,
-		)
-		v1.POST("/auth/login",
-			ratelimit.MiddlewareRedis(rlLogin, ratelimit.KeyLogin),
-			authH.Login,
-		)
+		// rate limit login: policy "login" (lihat CP13)
+		v1.POST("/auth/login", authH.Login)
 
 		v1.POST("/auth/refresh", authH.Refresh)
 		v1.POST("/auth/logout", authH.Logout)
 
-		// contoh protected
-		v1.GET("/users/me", auth.RequireAuth(jwtMgr), func(c *gin.Context) {
-			uid := c.GetString("user_id")
-			key := "me:" + uid
-
-			if b, ok := cstore.Get(key); ok {
-				etag := cache.WeakETag(b)
-				if inm := c.GetHeader("If-None-Match"); inm != "" && inm == etag {
-					c.Header("ETag", etag)
-					c.Header("X-Cache", "HIT")
-					c.Status(http.StatusNotModified)
+		// contoh protected; response di-cache per tenant+user (cache.Middleware)
+		v1.GET("/users/me",
+			auth.RequireAuth(jwtMgr),
+			cache.Middleware(respCache, cache.HTTPOptions{
+				TTL:  func() time.Duration { return rt.Config().Cache.MeTTL },
+				Vary: []string{"Authorization", cfg.Tenant.Header},
+			}),
+			func(c *gin.Context) {
+				u, err := userStore.FindByID(c, c.GetString("user_id"))
+				if err != nil {
+					c.Status(http.StatusNotFound)
+					c.Error(err)
 					return
 				}
-				c.Header("ETag", etag)
-				c.Header("X-Cache", "HIT")
-				c.Data(http.StatusOK, "application/json; charset=utf-8", b)
-				return
-			}
+				type mePayload struct {
+					ID    string `json:"id"`
+					Name  string `json:"name"`
+					Email string `json:"email"`
+				}
+				c.JSON(http.StatusOK, mePayload{ID: u.ID, Name: u.Name, Email: u.Email})
+			},
+		)
 
-			u, err := userStore.FindByID(c, uid)
-			if err != nil {
-				c.Status(http.StatusNotFound)
-				c.Error(err)
-				return
-			}
-			type mePayload struct {
-				ID    string `json:"id"`
-				Name  string `json:"name"`
-				Email string `json:"email"`
-			}
-			payload := mePayload{ID: u.ID, Name: u.Name, Email: u.Email}
-			body, err := json.Marshal(payload)
-			if err != nil {
-				c.Status(http.StatusInternalServerError)
-				c.Error(err)
-				return
-			}
+		// tenant (admin platform saja)
+		tenantH := tenant.NewHandler(tenantStore, tenantSchemas)
+		platform := []gin.HandlerFunc{auth.RequireAuth(jwtMgr), auth.RequireRole("admin"), tenant.RequirePlatform()}
+		v1.GET("/admin/tenants", append(platform, tenantH.List)...)
+		v1.POST("/admin/tenants", append(platform, tenantH.Create)...)
+		v1.PUT("/admin/tenants/:id/attributes-schema", append(platform, tenantH.SetAttributesSchema)...)
 
-			cstore.Set(key, body, 30*time.Second)
+		// riwayat background job
+		v1.GET("/admin/jobs/runs",
+			auth.RequireAuth(jwtMgr),
+			auth.RequireRole("admin"),
+			tenant.RequirePlatform(),
+			jobs.RunsHandler(jobHistory),
+		)
 
-			etag := cache.WeakETag(body)
-			if inm := c.GetHeader("If-None-Match"); inm != "" && inm == etag {
-				c.Header("ETag", etag)
-				c.Header("X-Cache", "MISS-ETAG-NOTMOD")
-				c.Status(http.StatusNotModified)
-				return
-			}
-			c.Header("ETag", etag)
-			c.Header("X-Cache", "MISS")
-			c.Data(http.StatusOK, "application/json; charset=utf-8", body)
-		})
+		// runtime settings efektif + kapan terakhir berubah
+		v1.GET("/admin/settings",
+			auth.RequireAuth(jwtMgr),
+			auth.RequireRole("admin"),
+			tenant.RequirePlatform(),
+			rt.Handler,
+		)
 
 		// Admin-only sample
 		v1.GET("/admin/ping",
 			auth.RequireAuth(jwtMgr),
 			auth.RequireRole("admin"),
+			tenant.RequirePlatform(),
 			func(c *gin.Context) {
 				c.JSON(http.StatusOK, gin.H{
 					"ok":   true,
@@ -270,14 +459,19 @@ func main() {
 	// metrics endpoint
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
-	addr := ":" + cfg.Port
+	addr := ":" + cfg.Server.Port
 	srv := &http.Server{
 		Addr:              addr,
 		Handler:           r,
-		ReadHeaderTimeout: 5 * time.Second,
-		ReadTimeout:       10 * time.Second,
-		WriteTimeout:      10 * time.Second,
-		IdleTimeout:       60 * time.Second,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+
+	webhookDisp.Start()
+	if cfg.Jobs.Enabled {
+		sched.Start()
 	}
 
 	// start async
@@ -288,98 +482,138 @@ func main() {
 		}
 	}()
 
+	// hot reload: poll file config + SIGHUP
+	if cfgOpt.File != "" && cfg.ReloadInterval > 0 {
+		go rt.WatchFile(bgCtx, cfgOpt.File, cfg.ReloadInterval)
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			_ = rt.Reload("sighup")
+		}
+	}()
+
 	// graceful shutdown
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	<-stop
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// readiness 503 dulu, beri waktu load balancer berhenti kirim traffic
+	hc.Drain()
+	if d := cfg.Server.DrainDelay; d > 0 {
+		appLogger.Info("server.draining", "delay", d.String())
+		time.Sleep(d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 	_ = srv.Shutdown(ctx)
+	if err := webhookDisp.Stop(ctx); err != nil {
+		appLogger.Warn("webhooks.stop", "err", err)
+	}
+	if err := sched.Stop(ctx); err != nil {
+		appLogger.Warn("jobs.stop", "err", err)
+	}
 	cstore.Close()
+	respCache.Close()
+	_ = redisCli.Close()
 	appLogger.Info("server.stopped")
 }
 
-// timeoutMiddleware: tambah context timeout ke setiap request
-func timeoutMiddleware(d time.Duration) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
-		defer cancel()
-		c.Request = c.Request.WithContext(ctx)
-		c.Next()
+// applyRateLimitPolicies: file policy bila diisi, selain itu policy bawaan
+// dari nilai default_*/auth_*.
+func applyRateLimitPolicies(p *ratelimit.Policies, c config.RateLimit) error {
+	set := ratelimit.DefaultPolicies(
+		ratelimit.Limit{Rate: c.DefaultRPS, Burst: c.DefaultBurst}, ratelimit.Algorithm(c.DefaultAlgorithm),
+		ratelimit.Limit{Rate: c.AuthRPS, Burst: c.AuthBurst}, ratelimit.Algorithm(c.AuthAlgorithm),
+	)
+	if c.PolicyFile != "" {
+		var err error
+		if set, err = ratelimit.LoadPolicyFile(c.PolicyFile); err != nil {
+			return err
+		}
 	}
+	return p.Apply(set)
 }
 
-// ==== helpers ====
-func getEnv(k, def string) string {
-	if v := os.Getenv(k); v != "" {
-		return v
+// bearerClaims: middleware global (rate limit, idempotency) berjalan
+// sebelum RequireAuth, jadi membaca bearer token sendiri. Token invalid atau
+// milik tenant lain = anonim (RequireAuth tetap menolaknya di route protected).
+func bearerClaims(mgr *auth.Manager, c *gin.Context) (*auth.Claims, bool) {
+	raw, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		return nil, false
 	}
-	return def
-}
-
-func mustParseDur(s string) time.Duration {
-	d, err := time.ParseDuration(s)
+	claims, err := mgr.Parse(raw)
 	if err != nil {
-		return 15 * time.Minute
+		return nil, false
 	}
-	return d
+	tid := claims.TenantID
+	if tid == "" {
+		tid = tenant.DefaultID
+	}
+	if tid != tenant.ID(c) {
+		return nil, false
+	}
+	return claims, true
 }
 
-func stripSQLiteURI(dsn string) string {
-	if strings.HasPrefix(dsn, "file:") {
-		return strings.TrimPrefix(dsn, "file:")
+// rateLimitIdentity: user & role (plan) dari bearer token.
+func rateLimitIdentity(mgr *auth.Manager) func(*gin.Context) (ratelimit.Identity, bool) {
+	return func(c *gin.Context) (ratelimit.Identity, bool) {
+		claims, ok := bearerClaims(mgr, c)
+		if !ok {
+			return ratelimit.Identity{}, false
+		}
+		return ratelimit.Identity{UserID: claims.UserID, Role: claims.Role}, true
 	}
-	return dsn
 }
 
-func ensureDirFor(dsn string) {
-	p := stripSQLiteURI(dsn)
-	if i := strings.IndexByte(p, '?'); i >= 0 {
-		p = p[:i]
-	}
-	if p == "" || p == ":memory:" {
-		return
-	}
-	dir := filepath.Dir(p)
-	if dir != "." && dir != "" {
-		_ = os.MkdirAll(dir, 0o755)
+// idempotencyUser: pemilik Idempotency-Key dari bearer token; "anonymous"
+// untuk endpoint publik (register).
+func idempotencyUser(mgr *auth.Manager) func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		if claims, ok := bearerClaims(mgr, c); ok {
+			return claims.UserID
+		}
+		return "anonymous"
 	}
 }
 
-func schemaGuard(db *gorm.DB) error {
-	m := db.Migrator()
-	type pair struct {
-		model any
-		col   string
-	}
-	checks := []pair{
-		{&users.User{}, "password_hash"},
-		{&users.User{}, "role"},
-	}
-	if !m.HasTable(&auth.RefreshToken{}) {
-		return fmt.Errorf("missing table refresh_tokens")
-	}
-	for _, c := range checks {
-		if !m.HasColumn(c.model, c.col) {
-			return fmt.Errorf("missing column %T.%s", c.model, c.col)
-		}
+// timeoutMiddleware: tambah context timeout ke setiap request
+func timeoutMiddleware(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
 	}
-	return nil
 }
 
-func mustParseFloat(s string) float64 {
-	v, err := strconv.ParseFloat(s, 64)
+// ==== helpers ====
+func mustAddJob(err error) {
 	if err != nil {
-		return 1.0
+		slog.Error("jobs.register.failed", "err", err)
+		os.Exit(1)
 	}
-	return v
 }
 
-func mustParseInt(s string) int {
-	v, err := strconv.Atoi(s)
-	if err != nil {
-		return 1
+// usersCacheOptions: config → stampede protection cache user.
+func usersCacheOptions(c config.Config) users.CacheOptions {
+	o := users.DefaultCacheOptions()
+	o.StaleTTL = c.Cache.UsersStaleTTL
+	o.NegativeTTL = c.Cache.UsersNegativeTTL
+	o.EarlyRefreshBeta = c.Cache.UsersEarlyBeta
+	o.ListTTL = c.Cache.UsersListTTL
+	// sudah divalidasi config
+	o.Serializer = cache.MustSerializer(cache.SerializerOptions{
+		Codec:       c.Cache.Codec,
+		Compression: c.Cache.Compression,
+		CompressMin: c.Cache.CompressMinBytes,
+	})
+	if !c.Cache.UsersLock {
+		o.LockTTL = 0
 	}
-	return v
+	return o
 }
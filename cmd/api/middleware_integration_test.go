@@ -4,8 +4,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/Quineeryn/go-backend-101/internal/auth"
+	"github.com/Quineeryn/go-backend-101/internal/cache"
+	"github.com/Quineeryn/go-backend-101/internal/idempotency"
 	"github.com/Quineeryn/go-backend-101/internal/middleware"
 	"github.com/gin-gonic/gin"
 )
@@ -103,3 +109,45 @@ func TestRecoveryJSON_Panic(t *testing.T) {
 		t.Fatalf("message should not be empty")
 	}
 }
+
+// idempotency global (sebelum RequireAuth): user tetap dari bearer token
+func TestIdempotency_UserFromBearerToken(t *testing.T) {
+	mgr := &auth.Manager{Secret: []byte("test-secret"), AccessTTL: time.Minute, RefreshTTL: time.Hour}
+	mem := cache.NewMemory(time.Minute)
+	t.Cleanup(mem.Close)
+	var calls atomic.Int32
+
+	r := newTestRouter()
+	r.Use(idempotency.Middleware(idempotency.NewMemoryBackend(mem), idempotency.Options{
+		Paths:  []string{"/v1/users"},
+		UserID: idempotencyUser(mgr),
+	}))
+	r.POST("/v1/users", func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"call": calls.Add(1)})
+	})
+	post := func(tok string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/users", strings.NewReader(`{}`))
+		req.Header.Set(idempotency.HeaderKey, "same-key")
+		if tok != "" {
+			req.Header.Set("Authorization", "Bearer "+tok)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	alice, _ := mgr.SignAccess("", "alice", "user", "j1")
+	bob, _ := mgr.SignAccess("", "bob", "user", "j2")
+	post(alice)
+	if w := post(bob); w.Header().Get(idempotency.HeaderReplayed) != "" || calls.Load() != 2 {
+		t.Fatalf("bob replayed alice's response (calls=%d)", calls.Load())
+	}
+	if w := post(alice); w.Header().Get(idempotency.HeaderReplayed) != "true" {
+		t.Fatal("alice retry should replay")
+	}
+	// token palsu = anonim, bukan user yang diklaim
+	post("not-a-token")
+	if calls.Load() != 3 {
+		t.Fatalf("invalid token shared a user's key (calls=%d)", calls.Load())
+	}
+}
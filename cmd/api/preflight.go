@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Quineeryn/go-backend-101/internal/config"
+	appdb "github.com/Quineeryn/go-backend-101/internal/db"
+)
+
+// HS256: key minimal 256 bit (RFC 7518 §3.2)
+const minJWTSecretLen = 32
+
+// secret bawaan/contoh yang tidak boleh dipakai di production
+var knownWeakSecrets = []string{
+	"dev-secret-change-me", "change-me", "changeme", "secret", "jwt-secret", "password",
+}
+
+type preflightReport struct {
+	Violations []string // menghentikan startup
+	Warnings   []string // cukup di-log
+}
+
+func (r preflightReport) OK() bool { return len(r.Violations) == 0 }
+
+func (r preflightReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "production preflight failed (%d violations):", len(r.Violations))
+	for _, v := range r.Violations {
+		b.WriteString("\n  - " + v)
+	}
+	return b.String()
+}
+
+func isProduction(env string) bool {
+	switch strings.ToLower(env) {
+	case "production", "prod":
+		return true
+	}
+	return false
+}
+
+// preflight memeriksa konfigurasi yang aman di dev tapi berbahaya di
+// production. Di luar production tidak ada yang dicek.
+func preflight(cfg config.Config) preflightReport {
+	var r preflightReport
+	if !isProduction(cfg.Env) {
+		return r
+	}
+	violate := func(format string, args ...any) { r.Violations = append(r.Violations, fmt.Sprintf(format, args...)) }
+	warn := func(format string, args ...any) { r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...)) }
+
+	// JWT
+	secret := cfg.JWT.Secret
+	if isWeakSecret(secret) {
+		violate("JWT_SECRET is a default/sample value; generate one with `openssl rand -base64 48`")
+	}
+	if len(secret) < minJWTSecretLen {
+		violate("JWT_SECRET is %d bytes; HS256 needs at least %d", len(secret), minJWTSecretLen)
+	}
+
+	// DB
+	if cfg.DB.IsSQLite() {
+		warn("DB_DSN points to SQLite (%s); use Postgres in production", cfg.DB.DSN)
+	} else {
+		checkSSL := func(name, dsn string) {
+			switch mode := sslMode(dsn); mode {
+			case "require", "verify-ca", "verify-full":
+			case "":
+				violate("%s has no sslmode (libpq defaults to prefer, which may fall back to plaintext); set sslmode=require or verify-full", name)
+			default:
+				violate("%s uses sslmode=%s; production requires require, verify-ca or verify-full", name, mode)
+			}
+		}
+		checkSSL("DB_DSN", cfg.DB.DSN)
+		// replica membawa data yang sama, jadi aturannya sama
+		for i, dsn := range cfg.DB.ReplicaDSNs {
+			checkSSL(fmt.Sprintf("DB_REPLICA_DSNS[%d]", i), dsn)
+		}
+	}
+	if cfg.DB.AutoMigrate {
+		violate("AUTO_MIGRATE=true is not allowed; run `go run ./cmd/migrate up` as a release step")
+	}
+
+	// rate limit: perilaku RedisLimiter saat Redis error (REDIS_FAIL_RATE_LIMIT)
+	switch cfg.Redis.FailMode.RateLimit {
+	case "open":
+		warn("rate limiting fails open when Redis (%s) is unreachable; requests are not limited during an outage", cfg.Redis.Addr)
+	case "local":
+		warn("rate limiting falls back to per-replica limits when Redis (%s) is unreachable; effective limits scale with the replica count during an outage", cfg.Redis.Addr)
+	}
+	if cfg.Redis.Password == "" {
+		warn("REDIS_PASSWORD is empty")
+	}
+	return r
+}
+
+func isWeakSecret(s string) bool {
+	lower := strings.ToLower(s)
+	if strings.Contains(lower, "change-me") || strings.Contains(lower, "changeme") {
+		return true
+	}
+	for _, weak := range knownWeakSecrets {
+		if lower == weak {
+			return true
+		}
+	}
+	return false
+}
+
+func sslMode(dsn string) string {
+	d, _ := appdb.ParseDSN(dsn)
+	return d.Param("sslmode")
+}
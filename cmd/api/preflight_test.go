@@ -0,0 +1,110 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Quineeryn/go-backend-101/internal/config"
+)
+
+func prodConfig(t *testing.T) config.Config {
+	t.Helper()
+	cfg, _, err := config.Load(nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	cfg.Env = "production"
+	cfg.JWT.Secret = "k3Vq0m1Yb8a2ZrXhP4tLw9sN6cD5fG7jH0eR2uI8oT1="
+	cfg.DB.DSN = "postgres://app:pw@db.internal:5432/app?sslmode=verify-full"
+	cfg.DB.AutoMigrate = false
+	cfg.Redis.Password = "redis-pw"
+	return cfg
+}
+
+func TestPreflight_SkippedOutsideProduction(t *testing.T) {
+	cfg := prodConfig(t)
+	cfg.Env = "development"
+	cfg.JWT.Secret = "dev-secret-change-me"
+	cfg.DB.AutoMigrate = true
+	if rep := preflight(cfg); !rep.OK() || len(rep.Warnings) != 0 {
+		t.Fatalf("dev should not be checked: %+v", rep)
+	}
+}
+
+func TestPreflight_ProductionOK(t *testing.T) {
+	rep := preflight(prodConfig(t))
+	if !rep.OK() {
+		t.Fatalf("unexpected violations: %s", rep)
+	}
+	if len(rep.Warnings) != 1 || !strings.Contains(rep.Warnings[0], "per-replica limits") {
+		t.Fatalf("want only the rate-limit fallback warning, got %v", rep.Warnings)
+	}
+}
+
+func TestPreflight_RateLimitFailMode(t *testing.T) {
+	for mode, want := range map[string]string{"open": "fails open", "local": "per-replica", "closed": ""} {
+		cfg := prodConfig(t)
+		cfg.Redis.FailMode.RateLimit = mode
+		rep := preflight(cfg)
+		if want == "" {
+			if len(rep.Warnings) != 0 {
+				t.Errorf("%s: want no warnings, got %v", mode, rep.Warnings)
+			}
+			continue
+		}
+		if len(rep.Warnings) != 1 || !strings.Contains(rep.Warnings[0], want) {
+			t.Errorf("%s: want warning containing %q, got %v", mode, want, rep.Warnings)
+		}
+	}
+}
+
+func TestPreflight_ReportsEveryViolation(t *testing.T) {
+	cfg := prodConfig(t)
+	cfg.JWT.Secret = "dev-secret-change-me"
+	cfg.DB.DSN = "host=db user=app password=pw dbname=app sslmode=disable"
+	cfg.DB.AutoMigrate = true
+
+	rep := preflight(cfg)
+	if len(rep.Violations) != 4 {
+		t.Fatalf("want 4 violations (default secret, short secret, sslmode, auto-migrate), got %d:\n%s", len(rep.Violations), rep)
+	}
+	msg := rep.String()
+	for _, want := range []string{"default/sample", "needs at least 32", "sslmode=disable", "AUTO_MIGRATE"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("report missing %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestPreflight_ReplicaSSLMode(t *testing.T) {
+	cfg := prodConfig(t)
+	cfg.DB.ReplicaDSNs = []string{
+		"postgres://app:pw@replica-0.internal:5432/app?sslmode=require",
+		"postgres://app:pw@replica-1.internal:5432/app?sslmode=disable",
+		"postgres://app:pw@replica-2.internal:5432/app",
+	}
+	rep := preflight(cfg)
+	if len(rep.Violations) != 2 {
+		t.Fatalf("want 2 replica violations, got %d:\n%s", len(rep.Violations), rep)
+	}
+	msg := rep.String()
+	for _, want := range []string{"DB_REPLICA_DSNS[1] uses sslmode=disable", "DB_REPLICA_DSNS[2] has no sslmode"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("report missing %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestSSLMode(t *testing.T) {
+	cases := map[string]string{
+		"postgres://u:p@h/db?sslmode=require":      "require",
+		"postgres://u:p@h/db":                      "",
+		"host=h user=u sslmode='verify-ca' port=5": "verify-ca",
+		"host=h user=u":                            "",
+	}
+	for dsn, want := range cases {
+		if got := sslMode(dsn); got != want {
+			t.Errorf("sslMode(%q) = %q, want %q", dsn, got, want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Quineeryn/go-backend-101/db/migrations"
+	"github.com/Quineeryn/go-backend-101/internal/auth"
+	"github.com/Quineeryn/go-backend-101/internal/health"
+	"github.com/Quineeryn/go-backend-101/internal/jobs"
+	"github.com/Quineeryn/go-backend-101/internal/migrate"
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
+	"github.com/Quineeryn/go-backend-101/internal/users"
+	"github.com/Quineeryn/go-backend-101/internal/webhooks"
+)
+
+// model yang wajib punya tabel & kolom lengkap sebelum API boleh melayani request
+var schemaModels = []any{
+	&users.User{}, &auth.RefreshToken{},
+	&webhooks.Subscription{}, &webhooks.Delivery{},
+	&jobs.Run{},
+	&tenant.Tenant{},
+}
+
+// runMigrations menjalankan migrasi embedded (sama dengan `go run ./cmd/migrate up`).
+func runMigrations(db *gorm.DB, dialect string) error {
+	r, err := newRunner(db, dialect)
+	if err != nil {
+		return err
+	}
+	done, err := r.Up(context.Background())
+	for _, m := range done {
+		slog.Info("migrate.applied", "version", m.Version, "name", m.Name)
+	}
+	return err
+}
+
+func newRunner(db *gorm.DB, dialect string) (*migrate.Runner, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	ms, err := migrate.Load(migrations.FS, dialect)
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(sqlDB, dialect, ms), nil
+}
+
+// schemaGate memastikan schema DB tidak tertinggal dari binary.
+// Mode (SCHEMA_GUARD):
+//   - strict    : tolak start (default)
+//   - readiness : tetap start, check "migrations" di /readyz gagal sampai migrasi dijalankan
+//   - off       : hanya log
+type schemaGate struct {
+	db      *gorm.DB
+	dialect string
+	mode    string
+	every   time.Duration
+
+	ready atomic.Bool
+	last  atomic.Pointer[migrate.Report]
+}
+
+func newSchemaGate(db *gorm.DB, dialect, mode string) *schemaGate {
+	return &schemaGate{db: db, dialect: dialect, mode: mode, every: 15 * time.Second}
+}
+
+// Guard dipanggil sekali saat startup; error = proses harus berhenti.
+func (g *schemaGate) Guard(ctx context.Context) error {
+	switch g.mode {
+	case "strict", "readiness", "off":
+	default:
+		return fmt.Errorf("SCHEMA_GUARD must be strict, readiness or off (got %q)", g.mode)
+	}
+
+	rep, err := g.verify(ctx)
+	if err != nil {
+		if g.mode == "off" {
+			slog.Warn("schema.guard.error", "err", err)
+			g.ready.Store(true)
+			return nil
+		}
+		return err
+	}
+	if rep.OK() || g.mode == "off" {
+		g.ready.Store(true)
+		return nil
+	}
+	if g.mode == "strict" {
+		return fmt.Errorf("database schema is behind this binary (%s); run `go run ./cmd/migrate up` or set AUTO_MIGRATE=true", rep)
+	}
+	go g.watch()
+	return nil
+}
+
+// Ready false selama schema tertinggal (mode readiness).
+func (g *schemaGate) Ready() bool { return g.ready.Load() }
+
+// Checker untuk /readyz: gagal selama schema tertinggal.
+func (g *schemaGate) Checker() health.Checker {
+	return health.CheckerFunc(func(context.Context) error {
+		if g.Ready() {
+			return nil
+		}
+		if rep := g.last.Load(); rep != nil {
+			return errors.New(rep.String())
+		}
+		return errors.New("schema not verified")
+	})
+}
+
+func (g *schemaGate) verify(ctx context.Context) (migrate.Report, error) {
+	r, err := newRunner(g.db, g.dialect)
+	if err != nil {
+		return migrate.Report{}, err
+	}
+	rep, err := migrate.Verify(ctx, r, g.db, schemaModels...)
+	if err != nil {
+		return rep, err
+	}
+	g.last.Store(&rep)
+
+	for _, d := range rep.Drift {
+		slog.Warn("schema.drift", "version", d.Version, "name", d.Name, "state", d.State)
+	}
+	if !rep.OK() {
+		for _, m := range rep.Pending {
+			slog.Warn("schema.pending", "version", m.Version, "name", m.Name)
+		}
+		for _, s := range rep.Missing {
+			slog.Warn("schema.missing", "object", s)
+		}
+		slog.Warn("schema.behind", "current", rep.Current, "expected", rep.Expected)
+	}
+	return rep, nil
+}
+
+// watch mengecek ulang berkala sampai migrasi dijalankan dari luar
+// (mis. job `cmd/migrate up`), lalu membuka readiness.
+func (g *schemaGate) watch() {
+	t := time.NewTicker(g.every)
+	defer t.Stop()
+	for range t.C {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		rep, err := g.verify(ctx)
+		cancel()
+		if err != nil {
+			slog.Warn("schema.guard.error", "err", err)
+			continue
+		}
+		if rep.OK() {
+			g.ready.Store(true)
+			slog.Info("schema.ready", "version", rep.Current)
+			return
+		}
+	}
+}
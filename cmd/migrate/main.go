@@ -1,91 +1,137 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
-	"strings"
+	"os"
+	"strconv"
+	"text/tabwriter"
 	"time"
 
 	"github.com/joho/godotenv"
 
-	"github.com/Quineeryn/go-backend-101/internal/auth"
+	"github.com/Quineeryn/go-backend-101/db/migrations"
 	"github.com/Quineeryn/go-backend-101/internal/config"
-	"github.com/Quineeryn/go-backend-101/internal/users"
-
-	gormpg "gorm.io/driver/postgres"
-	gormsqlite "gorm.io/driver/sqlite"
-	"gorm.io/gorm"
-	_ "modernc.org/sqlite" // enable sqlite (modernc) when needed
+	appdb "github.com/Quineeryn/go-backend-101/internal/db"
+	"github.com/Quineeryn/go-backend-101/internal/migrate"
 )
 
-func main() {
-	// 1) Load .env
-	_ = godotenv.Load()
+const usage = `usage: migrate [-dir db/migrations] <command>
 
-	// 2) Read DSN from the same config as the server
-	cfg := config.FromEnv()
-	dsn := cfg.DBDSN
-	if dsn == "" {
-		log.Fatal("DB_DSN is empty: set it in .env or environment")
-	}
+commands:
+  up            apply all pending migrations (default)
+  down N        roll back the last N migrations (default 1)
+  goto V        migrate up or down to version V
+  status        list migrations and their state
+  create NAME   write empty NNNNNN_NAME.up.sql/.down.sql into -dir
+`
 
-	// 3) Detect dialect
-	isPG := strings.HasPrefix(dsn, "postgres://") || strings.Contains(dsn, "host=")
+func main() {
+	dir := flag.String("dir", "db/migrations", "migrations directory (used by create)")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
 
-	var db *gorm.DB
-	var err error
+	cmd, args := "up", flag.Args()
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
 
-	if isPG {
-		// ---- PostgreSQL: run idempotent SQL (no AutoMigrate to avoid constraint drama)
-		db, err = gorm.Open(gormpg.Open(dsn), &gorm.Config{})
+	// create tidak butuh DB
+	if cmd == "create" {
+		if len(args) != 1 {
+			log.Fatal("create needs NAME")
+		}
+		up, down, err := migrate.Create(*dir, args[0])
 		if err != nil {
-			log.Fatal("open pg:", err)
+			log.Fatal("create:", err)
 		}
-		sqlDB, _ := db.DB()
-		sqlDB.SetMaxOpenConns(10)
-		sqlDB.SetMaxIdleConns(5)
-		sqlDB.SetConnMaxLifetime(15 * time.Minute)
+		fmt.Println(up)
+		fmt.Println(down)
+		return
+	}
 
-		stmts := []string{
-			// users: add needed columns (safe to rerun)
-			`ALTER TABLE IF EXISTS users ADD COLUMN IF NOT EXISTS password_hash TEXT`,
-			`ALTER TABLE IF EXISTS users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user'`,
-			`ALTER TABLE IF EXISTS users ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now()`,
-			`ALTER TABLE IF EXISTS users ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now()`,
+	// 1) Load .env
+	_ = godotenv.Load()
 
-			// refresh_tokens table (+ indexes)
-			`CREATE TABLE IF NOT EXISTS refresh_tokens (
-				id TEXT PRIMARY KEY,
-				user_id TEXT NOT NULL,
-				jti TEXT NOT NULL,
-				expires_at TIMESTAMPTZ NOT NULL,
-				revoked_at TIMESTAMPTZ,
-				created_at TIMESTAMPTZ NOT NULL DEFAULT now()
-			)`,
-			`CREATE INDEX IF NOT EXISTS idx_refresh_user ON refresh_tokens(user_id)`,
-			`CREATE UNIQUE INDEX IF NOT EXISTS ux_refresh_jti ON refresh_tokens(jti)`,
+	// 2) Read DSN from the same config as the server (env + CONFIG_FILE)
+	cfg, _, err := config.Load(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-			// Add FK if not exists (avoid duplicate_object)
-			`DO $$ BEGIN
-				ALTER TABLE refresh_tokens
-				ADD CONSTRAINT fk_refresh_user
-				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
-			EXCEPTION WHEN duplicate_object THEN NULL; END $$;`,
-		}
-		for _, s := range stmts {
-			if err := db.Exec(s).Error; err != nil {
-				log.Fatalf("migration failed at:\n%s\nerr: %v", s, err)
+	// 3) Open with the same bootstrap as the server (dialect, pool, pragmas, retry)
+	ctx := context.Background()
+	db, dsn, err := appdb.Open(ctx, cfg.DB.Options())
+	if err != nil {
+		log.Fatal(err)
+	}
+	dialect := dsn.Dialect
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	ms, err := migrate.Load(migrations.FS, dialect)
+	if err != nil {
+		log.Fatal("load migrations:", err)
+	}
+	r := migrate.New(sqlDB, dialect, ms)
+
+	var done []migrate.Migration
+	switch cmd {
+	case "up":
+		done, err = r.Up(ctx)
+	case "down":
+		n := 1
+		if len(args) > 0 {
+			if n, err = strconv.Atoi(args[0]); err != nil || n <= 0 {
+				log.Fatalf("down: invalid N %q", args[0])
 			}
 		}
-	} else {
-		// ---- SQLite (dev): AutoMigrate is fine
-		db, err = gorm.Open(gormsqlite.Dialector{DriverName: "sqlite", DSN: dsn}, &gorm.Config{})
-		if err != nil {
-			log.Fatal("open sqlite:", err)
+		done, err = r.Down(ctx, n)
+	case "goto":
+		if len(args) != 1 {
+			log.Fatal("goto needs VERSION")
 		}
-		if err := db.AutoMigrate(&users.User{}, &auth.RefreshToken{}); err != nil {
-			log.Fatal("automigrate sqlite:", err)
+		v, perr := strconv.ParseInt(args[0], 10, 64)
+		if perr != nil {
+			log.Fatalf("goto: invalid version %q", args[0])
 		}
+		done, err = r.Goto(ctx, v)
+	case "status":
+		printStatus(ctx, r)
+		return
+	default:
+		flag.Usage()
+		os.Exit(2)
 	}
 
-	log.Println("migration OK")
+	for _, m := range done {
+		fmt.Printf("%s %06d_%s\n", cmd, m.Version, m.Name)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	v, _ := r.Version(ctx)
+	log.Printf("migration OK (%s, version %d)", dialect, v)
+}
+
+func printStatus(ctx context.Context, r *migrate.Runner) {
+	st, err := r.Status(ctx)
+	if err != nil {
+		log.Fatal("status:", err)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, s := range st {
+		at := "-"
+		if s.AppliedAt != nil {
+			at = s.AppliedAt.Local().Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%06d\t%s\t%s\t%s\n", s.Version, s.Name, s.State, at)
+	}
+	_ = tw.Flush()
 }
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS job_runs (
+    id          TEXT PRIMARY KEY,
+    job         TEXT NOT NULL,
+    instance    TEXT,
+    status      TEXT NOT NULL,           -- succeeded | failed
+    affected    BIGINT NOT NULL DEFAULT 0,
+    error       TEXT,
+    started_at  TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_runs_job ON job_runs(job);
+CREATE INDEX IF NOT EXISTS idx_job_runs_started_at ON job_runs(started_at);
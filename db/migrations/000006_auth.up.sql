@@ -0,0 +1,23 @@
+-- Users: kolom auth (password & role) + timestamps (idempotent)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_hash TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE users ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- Refresh tokens (rotating refresh)
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id         TEXT PRIMARY KEY,        -- uuid
+    user_id    TEXT NOT NULL,
+    jti        TEXT NOT NULL,           -- token id (uuid) tertanam di JWT
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,             -- null = aktif
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_user ON refresh_tokens(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_refresh_jti ON refresh_tokens(jti);
+
+DO $$ BEGIN
+    ALTER TABLE refresh_tokens
+    ADD CONSTRAINT fk_refresh_user
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
@@ -0,0 +1,9 @@
+-- Gagal bila email yang sama sudah dipakai di lebih dari satu tenant.
+DROP INDEX IF EXISTS users_tenant_email_key;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+
+DROP INDEX IF EXISTS idx_refresh_tenant;
+DROP INDEX IF EXISTS idx_users_tenant_id;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+DROP TABLE IF EXISTS tenants;
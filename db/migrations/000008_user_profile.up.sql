@@ -0,0 +1,13 @@
+-- Profil user
+ALTER TABLE users ADD COLUMN IF NOT EXISTS display_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone TEXT NOT NULL DEFAULT '';
+
+-- attributes bebas; filter ?attr.k=v memakai @> → GIN jsonb_path_ops
+ALTER TABLE users ADD COLUMN IF NOT EXISTS attributes JSONB;
+CREATE INDEX IF NOT EXISTS idx_users_attributes ON users USING GIN (attributes jsonb_path_ops);
+
+-- JSON Schema attributes per tenant (null = bebas)
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS attributes_schema JSONB;
@@ -0,0 +1,5 @@
+-- extension pg_trgm dibiarkan (bisa dipakai objek lain)
+DROP INDEX IF EXISTS idx_users_search_trgm;
+DROP INDEX IF EXISTS idx_users_search;
+ALTER TABLE users DROP COLUMN IF EXISTS search_text;
+ALTER TABLE users DROP COLUMN IF EXISTS search;
@@ -0,0 +1,16 @@
+-- Pencarian user (GET /v1/users/search): tsvector untuk kata/prefix (ts_rank)
+-- dan pg_trgm untuk typo/substring. Kolom generated → selalu sinkron dengan
+-- INSERT/UPDATE tanpa trigger.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(display_name, '')), 'A') ||
+    setweight(to_tsvector('simple', translate(coalesce(email, ''), '@._-+', '     ')), 'B')
+) STORED;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS search_text TEXT GENERATED ALWAYS AS (
+    lower(coalesce(name, '') || ' ' || coalesce(display_name, '') || ' ' || coalesce(email, ''))
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_users_search ON users USING GIN (search);
+CREATE INDEX IF NOT EXISTS idx_users_search_trgm ON users USING GIN (search_text gin_trgm_ops);
@@ -0,0 +1,8 @@
+// Package migrations menyimpan file SQL versioned (NNNNNN_name.up/down.sql).
+// Varian khusus SQLite ada di sqlite/ dan menimpa file Postgres dgn versi sama.
+package migrations
+
+import "embed"
+
+//go:embed *.sql sqlite/*.sql
+var FS embed.FS
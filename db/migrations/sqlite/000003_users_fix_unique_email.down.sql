@@ -0,0 +1 @@
+-- no-op
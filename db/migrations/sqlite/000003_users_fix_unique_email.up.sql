@@ -0,0 +1 @@
+-- SQLite: unique index users(email) sudah dibuat di 000002; tidak ada constraint lama yang perlu dibereskan.
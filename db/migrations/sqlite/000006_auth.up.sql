@@ -0,0 +1,16 @@
+-- Users: tambah kolom password & role (timestamps sudah ada sejak 000001)
+ALTER TABLE users ADD COLUMN password_hash TEXT;
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+
+-- Refresh tokens (rotating refresh)
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+  id TEXT PRIMARY KEY,         -- uuid
+  user_id TEXT NOT NULL,
+  jti TEXT NOT NULL,           -- token id (uuid) tertanam di JWT
+  expires_at DATETIME NOT NULL,
+  revoked_at DATETIME,         -- null = aktif
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_user ON refresh_tokens(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_refresh_jti ON refresh_tokens(jti);
@@ -0,0 +1,24 @@
+-- Tenants
+CREATE TABLE IF NOT EXISTS tenants (
+  id TEXT PRIMARY KEY,
+  slug TEXT NOT NULL,
+  name TEXT NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS tenants_slug_key ON tenants(slug);
+
+-- tenant bawaan: semua data lama masuk ke sini
+INSERT OR IGNORE INTO tenants (id, slug, name) VALUES ('default', 'default', 'Default');
+
+-- Users & refresh tokens milik satu tenant.
+-- SQLite: ADD COLUMN dengan REFERENCES wajib default NULL, jadi tanpa FK;
+-- tenant_id selalu diisi dari tenant yang sudah di-resolve.
+ALTER TABLE users ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE refresh_tokens ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS idx_users_tenant_id ON users(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tenant ON refresh_tokens(tenant_id);
+
+-- Email unik per tenant (bukan global lagi)
+DROP INDEX IF EXISTS users_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_email_key ON users(tenant_id, email);
@@ -0,0 +1,13 @@
+-- Profil user
+ALTER TABLE users ADD COLUMN display_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN avatar_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN locale TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN phone TEXT NOT NULL DEFAULT '';
+
+-- attributes bebas (JSON teks); filter ?attr.k=v memakai JSON1
+-- (json_type/json_extract), tanpa index: cukup untuk dev.
+ALTER TABLE users ADD COLUMN attributes TEXT;
+
+-- JSON Schema attributes per tenant (null = bebas)
+ALTER TABLE tenants ADD COLUMN attributes_schema TEXT;
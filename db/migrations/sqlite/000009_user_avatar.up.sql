@@ -0,0 +1,2 @@
+-- prefix blob avatar (avatars/<tenant>/<user>/<uuid>); '' = tanpa avatar
+ALTER TABLE users ADD COLUMN avatar_key TEXT NOT NULL DEFAULT '';
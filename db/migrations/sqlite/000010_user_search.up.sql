@@ -0,0 +1,25 @@
+-- Pencarian user (dev): FTS5 tokenizer trigram → cocok substring (min. 3
+-- huruf). Tabel FTS menyimpan salinan teks, dijaga trigger; dikunci dengan
+-- users.id (rowid users tidak stabil setelah VACUUM).
+CREATE VIRTUAL TABLE users_fts USING fts5(
+    id UNINDEXED, name, display_name, email,
+    tokenize = 'trigram'
+);
+
+INSERT INTO users_fts (id, name, display_name, email)
+SELECT id, name, display_name, email FROM users;
+
+CREATE TRIGGER users_fts_ai AFTER INSERT ON users BEGIN
+    INSERT INTO users_fts (id, name, display_name, email)
+    VALUES (new.id, new.name, new.display_name, new.email);
+END;
+
+CREATE TRIGGER users_fts_ad AFTER DELETE ON users BEGIN
+    DELETE FROM users_fts WHERE id = old.id;
+END;
+
+CREATE TRIGGER users_fts_au AFTER UPDATE OF id, name, display_name, email ON users BEGIN
+    DELETE FROM users_fts WHERE id = old.id;
+    INSERT INTO users_fts (id, name, display_name, email)
+    VALUES (new.id, new.name, new.display_name, new.email);
+END;
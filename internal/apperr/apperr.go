@@ -9,15 +9,18 @@ import (
 type Kind string
 
 const (
-	Validation   Kind = "validation_error"
-	NotFound     Kind = "not_found"
-	Conflict     Kind = "conflict"
-	Unauthorized Kind = "unauthorized"
-	Forbidden    Kind = "forbidden"
-	RateLimited  Kind = "rate_limited"
-	Timeout      Kind = "timeout"
-	Unavailable  Kind = "unavailable"
-	Internal     Kind = "internal_error"
+	Validation    Kind = "validation_error"
+	NotFound      Kind = "not_found"
+	Conflict      Kind = "conflict"
+	Unauthorized  Kind = "unauthorized"
+	Forbidden     Kind = "forbidden"
+	Unprocessable Kind = "unprocessable_entity"
+	TooLarge      Kind = "payload_too_large"
+	Unsupported   Kind = "unsupported_media_type"
+	RateLimited   Kind = "rate_limited"
+	Timeout       Kind = "timeout"
+	Unavailable   Kind = "unavailable"
+	Internal      Kind = "internal_error"
 )
 
 type AppError struct {
@@ -84,6 +87,12 @@ func StatusFor(err error) int {
 			return http.StatusUnauthorized
 		case Forbidden:
 			return http.StatusForbidden
+		case Unprocessable:
+			return http.StatusUnprocessableEntity
+		case TooLarge:
+			return http.StatusRequestEntityTooLarge
+		case Unsupported:
+			return http.StatusUnsupportedMediaType
 		case RateLimited:
 			return http.StatusTooManyRequests
 		case Timeout:
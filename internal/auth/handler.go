@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Quineeryn/go-backend-101/internal/password"
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
 	"github.com/Quineeryn/go-backend-101/internal/users"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -15,6 +16,7 @@ type Handler struct {
 	Users  *users.Store
 	Tokens *Store
 	JWT    *Manager
+	Events users.Publisher // opsional: publish user.created saat register
 }
 
 func (h *Handler) Register(c *gin.Context) {
@@ -48,6 +50,10 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
+	if h.Events != nil {
+		h.Events.Publish(c, users.EventUserCreated, users.EventPayload(u))
+	}
+
 	c.JSON(http.StatusCreated, gin.H{"id": u.ID, "name": u.Name, "email": u.Email, "role": u.Role})
 }
 
@@ -71,17 +77,18 @@ func (h *Handler) Login(c *gin.Context) {
 		role = "user"
 	}
 
+	tid := tenant.ID(c) // user dicari di tenant request, token ikut tenant itu
 	accessJTI := uuid.New().String()
 	refreshJTI := uuid.New().String()
 
-	access, err := h.JWT.SignAccess(u.ID, role, accessJTI)
+	access, err := h.JWT.SignAccess(tid, u.ID, role, accessJTI)
 	if err != nil {
 		c.Status(http.StatusInternalServerError)
 		c.Error(err)
 		return
 	}
 
-	refresh, err := h.JWT.SignRefresh(u.ID, role, refreshJTI)
+	refresh, err := h.JWT.SignRefresh(tid, u.ID, role, refreshJTI)
 	if err != nil {
 		c.Status(http.StatusInternalServerError)
 		c.Error(err)
@@ -118,6 +125,11 @@ func (h *Handler) Refresh(c *gin.Context) {
 		c.Error(err)
 		return
 	}
+	if err := tenant.Bind(c, claims.TenantID); err != nil {
+		c.Status(http.StatusForbidden)
+		c.Error(err)
+		return
+	}
 
 	// cek refresh masih aktif
 	active, err := h.Tokens.IsActive(c, claims.ID)
@@ -136,13 +148,13 @@ func (h *Handler) Refresh(c *gin.Context) {
 	_ = h.Tokens.RevokeByJTI(c, claims.ID)
 
 	newJTI := uuid.New().String()
-	newAccess, err := h.JWT.SignAccess(claims.UserID, claims.Role, uuid.New().String())
+	newAccess, err := h.JWT.SignAccess(tenant.ID(c), claims.UserID, claims.Role, uuid.New().String())
 	if err != nil {
 		c.Status(http.StatusInternalServerError)
 		c.Error(err)
 		return
 	}
-	newRefresh, err := h.JWT.SignRefresh(claims.UserID, claims.Role, newJTI)
+	newRefresh, err := h.JWT.SignRefresh(tenant.ID(c), claims.UserID, claims.Role, newJTI)
 	if err != nil {
 		c.Status(http.StatusInternalServerError)
 		c.Error(err)
@@ -177,6 +189,11 @@ func (h *Handler) Logout(c *gin.Context) {
 		c.Error(err)
 		return
 	}
+	if err := tenant.Bind(c, claims.TenantID); err != nil {
+		c.Status(http.StatusForbidden)
+		c.Error(err)
+		return
+	}
 	if err := h.Tokens.RevokeByJTI(c, claims.ID); err != nil {
 		c.Status(http.StatusInternalServerError)
 		c.Error(err)
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Quineeryn/go-backend-101/internal/testdb"
+	"github.com/Quineeryn/go-backend-101/internal/users"
+)
+
+type capturePub struct{ data []any }
+
+func (p *capturePub) Publish(_ context.Context, event string, data any) {
+	if event == users.EventUserCreated {
+		p.data = append(p.data, data)
+	}
+}
+
+// register & POST /v1/users publish user.created dengan bentuk yang sama
+func TestRegister_UserCreatedPayloadMatchesUsers(t *testing.T) {
+	db := testdb.Open(t)
+	if err := db.AutoMigrate(&users.User{}); err != nil {
+		t.Fatal(err)
+	}
+	store := users.NewStore(db)
+	pub := &capturePub{}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/register", (&Handler{Users: store, Events: pub}).Register)
+	req := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(`{"name":"Ana","email":"ana@example.com","password":"s3cret-pass"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register: %d %s", w.Code, w.Body.String())
+	}
+
+	if _, err := users.NewEventedStore(store, pub).Create(context.Background(), users.User{ID: uuid.NewString(), Name: "Budi", Email: "budi@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(pub.data) != 2 {
+		t.Fatalf("want 2 user.created events, got %d", len(pub.data))
+	}
+	if a, b := reflect.TypeOf(pub.data[0]), reflect.TypeOf(pub.data[1]); a != b {
+		t.Fatalf("payload types differ: register %v, users %v", a, b)
+	}
+	if a, b := jsonKeys(t, pub.data[0]), jsonKeys(t, pub.data[1]); !slices.Equal(a, b) {
+		t.Fatalf("payload fields differ:\n register %v\n users    %v", a, b)
+	}
+}
+
+func jsonKeys(t *testing.T, v any) []string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["password_hash"]; ok {
+		t.Fatalf("payload leaks password hash: %s", b)
+	}
+	return slices.Sorted(maps.Keys(m))
+}
@@ -7,8 +7,9 @@ import (
 )
 
 type Claims struct {
-	UserID string `json:"uid"`
-	Role   string `json:"role"`
+	TenantID string `json:"tid,omitempty"` // kosong = token lama, dianggap tenant default
+	UserID   string `json:"uid"`
+	Role     string `json:"role"`
 	jwt.RegisteredClaims
 }
 
@@ -18,11 +19,12 @@ type Manager struct {
 	RefreshTTL time.Duration
 }
 
-func (m *Manager) SignAccess(userID string, role, jti string) (string, error) {
+func (m *Manager) SignAccess(tenantID, userID, role, jti string) (string, error) {
 	now := time.Now().UTC()
 	claims := Claims{
-		UserID: userID,
-		Role:   role,
+		TenantID: tenantID,
+		UserID:   userID,
+		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(now.Add(m.AccessTTL)),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -33,11 +35,12 @@ func (m *Manager) SignAccess(userID string, role, jti string) (string, error) {
 	return token.SignedString(m.Secret)
 }
 
-func (m *Manager) SignRefresh(userID string, role, jti string) (string, error) {
+func (m *Manager) SignRefresh(tenantID, userID, role, jti string) (string, error) {
 	now := time.Now().UTC()
 	claims := Claims{
-		UserID: userID,
-		Role:   role,
+		TenantID: tenantID,
+		UserID:   userID,
+		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(now.Add(m.RefreshTTL)),
 			IssuedAt:  jwt.NewNumericDate(now),
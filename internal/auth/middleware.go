@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/Quineeryn/go-backend-101/internal/middleware"
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
 	"github.com/gin-gonic/gin"
 )
 
@@ -26,6 +27,13 @@ func RequireAuth(mgr *Manager) gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		// token hanya berlaku di tenant-nya sendiri
+		if err := tenant.Bind(c, claims.TenantID); err != nil {
+			c.Status(http.StatusForbidden)
+			c.Error(err)
+			c.Abort()
+			return
+		}
 		// inject ke context
 		c.Set("user_id", claims.UserID)
 		c.Set("role", claims.Role)
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/Quineeryn/go-backend-101/internal/logger"
+	"github.com/Quineeryn/go-backend-101/internal/middleware"
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
+	"github.com/Quineeryn/go-backend-101/internal/testdb"
+	"github.com/Quineeryn/go-backend-101/internal/users"
+)
+
+// newTenantRouter: tenant default, acme, globex + middleware tenant.
+func newTenantRouter(t *testing.T) (*gin.Engine, *gorm.DB, *Manager) {
+	t.Helper()
+	db := testdb.Open(t)
+	if err := db.AutoMigrate(&tenant.Tenant{}); err != nil {
+		t.Fatal(err)
+	}
+	ts := tenant.NewStore(db)
+	for _, tn := range []tenant.Tenant{{ID: tenant.DefaultID, Slug: "default", Name: "Default"}, {ID: "t-acme", Slug: "acme", Name: "Acme"}, {ID: "t-globex", Slug: "globex", Name: "Globex"}} {
+		if _, err := ts.Create(context.Background(), tn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mgr := &Manager{Secret: []byte("test-secret"), AccessTTL: time.Minute, RefreshTTL: time.Hour}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(tenant.Middleware(ts, tenant.Options{Header: "X-Tenant-ID", Default: "default"}))
+	return r, db, mgr
+}
+
+func TestRequireAuth_TenantBoundToken(t *testing.T) {
+	r, _, mgr := newTenantRouter(t)
+	acmeTok, _ := mgr.SignAccess("t-acme", "u1", "admin", "j1")
+
+	r.GET("/me", RequireAuth(mgr), func(c *gin.Context) { c.String(http.StatusOK, tenant.ID(c)) })
+	r.GET("/admin", RequireAuth(mgr), RequireRole("admin"), tenant.RequirePlatform(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	get := func(path, tenantHdr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+acmeTok)
+		if tenantHdr != "" {
+			req.Header.Set("X-Tenant-ID", tenantHdr)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get("/me", "acme"); w.Code != http.StatusOK || w.Body.String() != "t-acme" {
+		t.Fatalf("own tenant: got %d %q", w.Code, w.Body.String())
+	}
+	if w := get("/me", ""); w.Code != http.StatusOK || w.Body.String() != "t-acme" {
+		t.Fatalf("no header: tenant must follow token, got %d %q", w.Code, w.Body.String())
+	}
+	if w := get("/me", "globex"); w.Code != http.StatusForbidden {
+		t.Fatalf("acme token on globex: want 403, got %d", w.Code)
+	}
+	// admin tenant acme bukan admin platform
+	if w := get("/admin", ""); w.Code == http.StatusNoContent {
+		t.Fatal("tenant admin must not reach platform endpoints")
+	}
+}
+
+func TestUsersRoutes_CrossTenantForbidden(t *testing.T) {
+	logger.L = zap.NewNop()
+	r, db, mgr := newTenantRouter(t)
+	if err := db.AutoMigrate(&users.User{}); err != nil {
+		t.Fatal(err)
+	}
+	store := users.NewStore(db)
+	victim, err := store.Create(tenant.WithID(context.Background(), "t-globex"), users.User{ID: "u-gus", Name: "Gus", Email: "gus@globex.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Use(middleware.ErrorEnvelope())
+	users.RegisterRoutes(r, users.NewHandler(store), RequireAuth(mgr))
+	acmeTok, _ := mgr.SignAccess("t-acme", "u1", "admin", "j1")
+	globexTok, _ := mgr.SignAccess("t-globex", "u2", "user", "j2")
+
+	do := func(method, tok, tenantHdr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/v1/users/"+victim.ID, nil)
+		if tok != "" {
+			req.Header.Set("Authorization", "Bearer "+tok)
+		}
+		req.Header.Set("X-Tenant-ID", tenantHdr)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodGet, "", "globex"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("no token: want 401, got %d", w.Code)
+	}
+	// caller tenant acme mengganti header ke globex
+	for _, m := range []string{http.MethodGet, http.MethodDelete} {
+		if w := do(m, acmeTok, "globex"); w.Code != http.StatusForbidden {
+			t.Fatalf("%s with acme token on globex: want 403, got %d", m, w.Code)
+		}
+	}
+	// tanpa header: tenant mengikuti token → user globex tidak terlihat
+	if w := do(http.MethodGet, acmeTok, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("acme token, no header: want 404, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, globexTok, "globex"); w.Code != http.StatusOK {
+		t.Fatalf("own tenant: want 200, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
 )
 
 type RefreshToken struct {
 	ID        string `gorm:"primaryKey"` // uuid
+	TenantID  string `gorm:"column:tenant_id;not null;default:default;index:idx_refresh_tenant"`
 	UserID    string
 	JTI       string `gorm:"index"`
 	ExpiresAt time.Time
@@ -16,11 +19,14 @@ type RefreshToken struct {
 	CreatedAt time.Time
 }
 
+// Store selalu memakai primary (tidak lewat replica): revoke harus langsung
+// berlaku, replica yang tertinggal bisa menerima token yang sudah dicabut.
 type Store struct{ db *gorm.DB }
 
 func NewStore(db *gorm.DB) *Store { return &Store{db} }
 
 func (s *Store) Save(ctx context.Context, rt *RefreshToken) error {
+	rt.TenantID = tenant.ID(ctx)
 	return s.db.WithContext(ctx).Create(rt).Error
 }
 
@@ -28,17 +34,26 @@ func (s *Store) RevokeByJTI(ctx context.Context, jti string) error {
 	now := time.Now().UTC()
 	return s.db.WithContext(ctx).
 		Model(&RefreshToken{}).
-		Where("jti = ? AND revoked_at IS NULL", jti).
+		Where("tenant_id = ? AND jti = ? AND revoked_at IS NULL", tenant.ID(ctx), jti).
 		Update("revoked_at", now).Error
 }
 
 func (s *Store) IsActive(ctx context.Context, jti string) (bool, error) {
 	var rt RefreshToken
 	err := s.db.WithContext(ctx).
-		Where("jti = ? AND revoked_at IS NULL AND expires_at > ?", jti, time.Now().UTC()).
+		Where("tenant_id = ? AND jti = ? AND revoked_at IS NULL AND expires_at > ?", tenant.ID(ctx), jti, time.Now().UTC()).
 		First(&rt).Error
 	if err == gorm.ErrRecordNotFound {
 		return false, nil
 	}
 	return err == nil, err
 }
+
+// PurgeBefore menghapus refresh token yang expired atau di-revoke sebelum cutoff.
+// Sengaja lintas tenant: dipanggil job housekeeping, bukan request.
+func (s *Store) PurgeBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).
+		Where("expires_at < ? OR (revoked_at IS NOT NULL AND revoked_at < ?)", before, before).
+		Delete(&RefreshToken{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+	"github.com/Quineeryn/go-backend-101/internal/logger"
+)
+
+// ErrCircuitOpen: perintah Redis ditolak tanpa menyentuh jaringan karena
+// breaker terbuka. Konsumen memperlakukannya seperti error koneksi biasa.
+var ErrCircuitOpen = errors.New("redis: circuit breaker open")
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerHalfOpen
+	BreakerOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerHalfOpen:
+		return "half_open"
+	case BreakerOpen:
+		return "open"
+	}
+	return "closed"
+}
+
+// BreakerOptions untuk Breaker.
+type BreakerOptions struct {
+	Name      string        // label metrik (default "redis")
+	Threshold int           // gagal berturut-turut sebelum open (default 5)
+	Cooldown  time.Duration // lama open sebelum satu probe boleh lewat (default 5s)
+}
+
+// Breaker: circuit breaker untuk client Redis, dipasang sebagai redis.Hook.
+// Closed → Open setelah Threshold kegagalan jaringan berturut-turut; selama
+// Open semua perintah langsung ErrCircuitOpen (tanpa menunggu timeout);
+// setelah Cooldown satu perintah (probe) dilewatkan: sukses → Closed,
+// gagal → Open lagi. Error balasan server (redis.Nil, WRONGTYPE, ...) tidak
+// dihitung gagal.
+type Breaker struct {
+	opt BreakerOptions
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewBreaker(opt BreakerOptions) *Breaker {
+	if opt.Name == "" {
+		opt.Name = "redis"
+	}
+	if opt.Threshold <= 0 {
+		opt.Threshold = 5
+	}
+	if opt.Cooldown <= 0 {
+		opt.Cooldown = 5 * time.Second
+	}
+	b := &Breaker{opt: opt}
+	httpx.RedisBreakerState.WithLabelValues(opt.Name).Set(float64(BreakerClosed))
+	return b
+}
+
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// allow: nil bila perintah boleh jalan; probe=true bila ini probe half-open.
+func (b *Breaker) allow() (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.opt.Cooldown {
+			break
+		}
+		b.setState(BreakerHalfOpen)
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probing {
+			break
+		}
+		b.probing = true
+		return true, nil
+	default:
+		return false, nil
+	}
+	httpx.RedisBreakerRejected.WithLabelValues(b.opt.Name).Inc()
+	return false, ErrCircuitOpen
+}
+
+func (b *Breaker) record(probe bool, err error) {
+	failed := isConnError(err)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	if !failed {
+		b.failures = 0
+		if b.state != BreakerClosed && (probe || b.state == BreakerHalfOpen) {
+			b.setState(BreakerClosed)
+		}
+		return
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.opt.Threshold) {
+		b.openedAt = time.Now()
+		b.setState(BreakerOpen)
+	}
+}
+
+// setState: b.mu harus dipegang.
+func (b *Breaker) setState(s BreakerState) {
+	if b.state == s {
+		return
+	}
+	// probe gagal terjadi tiap cooldown selama gangguan: cukup debug
+	log := logger.L.Debug
+	switch {
+	case b.state == BreakerClosed:
+		log = logger.L.Warn
+	case s == BreakerClosed:
+		log = logger.L.Info
+	}
+	log("redis.breaker", zap.String("client", b.opt.Name), zap.String("from", b.state.String()), zap.String("to", s.String()))
+	b.state = s
+	httpx.RedisBreakerState.WithLabelValues(b.opt.Name).Set(float64(s))
+	httpx.RedisBreakerTransitions.WithLabelValues(b.opt.Name, s.String()).Inc()
+}
+
+// isConnError: kegagalan yang menandakan Redis tidak sehat. Balasan error
+// dari server dan pembatalan oleh pemanggil bukan.
+func isConnError(err error) bool {
+	var rerr redis.Error
+	switch {
+	case err == nil, errors.Is(err, redis.Nil), errors.Is(err, ErrCircuitOpen),
+		errors.Is(err, context.Canceled), errors.Is(err, redis.ErrClosed):
+		return false
+	case errors.As(err, &rerr):
+		// LOADING / MASTERDOWN / CLUSTERDOWN / TRYAGAIN: server hidup tapi belum siap
+		return redis.HasErrorPrefix(err, "LOADING") || redis.HasErrorPrefix(err, "MASTERDOWN") ||
+			redis.HasErrorPrefix(err, "CLUSTERDOWN") || redis.HasErrorPrefix(err, "TRYAGAIN")
+	}
+	return true
+}
+
+// Hook: redis.Hook untuk client.AddHook.
+func (b *Breaker) Hook() redis.Hook { return breakerHook{b} }
+
+type breakerHook struct{ b *Breaker }
+
+// DialHook tidak dijaga: dial untuk perintah sudah lewat ProcessHook (dan
+// gagalnya terhitung di sana); dial pubsub punya backoff sendiri.
+func (h breakerHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h breakerHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		probe, err := h.b.allow()
+		if err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		err = next(ctx, cmd)
+		h.b.record(probe, err)
+		return err
+	}
+}
+
+func (h breakerHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		probe, err := h.b.allow()
+		if err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		err = next(ctx, cmds)
+		h.b.record(probe, err)
+		return err
+	}
+}
@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newBreakerRedis(t *testing.T) (*miniredis.Miniredis, *Redis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	r := NewRedisWith(RedisOptions{
+		Addr:    mr.Addr(),
+		Breaker: BreakerOptions{Name: "test_" + t.Name(), Threshold: 2, Cooldown: 50 * time.Millisecond},
+	})
+	t.Cleanup(func() { _ = r.Close() })
+	return mr, r
+}
+
+func TestBreaker_OpensFailsFastAndRecovers(t *testing.T) {
+	mr, r := newBreakerRedis(t)
+	ctx := context.Background()
+
+	if err := r.C.Set(ctx, "k", "v", 0).Err(); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	mr.Close()
+	for i := 0; i < 2; i++ {
+		if err := r.C.Get(ctx, "k").Err(); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("call %d: want connection error, got %v", i, err)
+		}
+	}
+	if s := r.Breaker.State(); s != BreakerOpen {
+		t.Fatalf("want open after threshold, got %s", s)
+	}
+	if r.Available() {
+		t.Fatal("Available should be false while open")
+	}
+
+	start := time.Now()
+	if err := r.C.Get(ctx, "k").Err(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("want ErrCircuitOpen, got %v", err)
+	}
+	if d := time.Since(start); d > 10*time.Millisecond {
+		t.Fatalf("open breaker should fail fast, took %s", d)
+	}
+
+	// tanpa traffic: ping background menutup breaker setelah Redis pulih
+	if err := mr.Restart(); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for r.Breaker.State() != BreakerClosed {
+		if time.Now().After(deadline) {
+			t.Fatalf("breaker did not close after recovery, state=%s", r.Breaker.State())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := r.C.Set(ctx, "k", "v2", 0).Err(); err != nil {
+		t.Fatalf("set after recovery: %v", err)
+	}
+}
+
+func TestBreaker_FailedProbeReopens(t *testing.T) {
+	mr, r := newBreakerRedis(t)
+	ctx := context.Background()
+	mr.Close()
+	for i := 0; i < 2; i++ {
+		_ = r.C.Ping(ctx).Err()
+	}
+	if s := r.Breaker.State(); s != BreakerOpen {
+		t.Fatalf("want open, got %s", s)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	// probe (gagal) → open lagi, perintah berikutnya langsung ditolak
+	if err := r.C.Ping(ctx).Err(); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("probe: want connection error, got %v", err)
+	}
+	if err := r.C.Ping(ctx).Err(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("after failed probe want ErrCircuitOpen, got %v", err)
+	}
+}
+
+func TestBreaker_ReplyErrorsDoNotTrip(t *testing.T) {
+	_, r := newBreakerRedis(t)
+	ctx := context.Background()
+	_ = r.C.LPush(ctx, "list", "x").Err()
+	for i := 0; i < 5; i++ {
+		if err := r.C.Get(ctx, "missing").Err(); !errors.Is(err, redis.Nil) {
+			t.Fatalf("want redis.Nil, got %v", err)
+		}
+		if err := r.C.Get(ctx, "list").Err(); err == nil {
+			t.Fatal("want WRONGTYPE")
+		}
+	}
+	_, _ = r.C.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Get(ctx, "missing")
+		p.Get(ctx, "missing")
+		return nil
+	})
+	if s := r.Breaker.State(); s != BreakerClosed {
+		t.Fatalf("reply errors must not open the breaker, got %s", s)
+	}
+}
+
+func TestIsConnError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{redis.Nil, false},
+		{ErrCircuitOpen, false},
+		{context.Canceled, false},
+		{context.DeadlineExceeded, true},
+		{errors.New("dial tcp: connection refused"), true},
+	}
+	for _, c := range cases {
+		if got := isConnError(c.err); got != c.want {
+			t.Errorf("isConnError(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
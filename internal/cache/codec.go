@@ -0,0 +1,272 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+)
+
+// Codec meng-encode value cache. Nama dipakai di config (cache.codec) dan
+// label metrik.
+type Codec interface {
+	Name() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(b []byte, v any) error
+}
+
+// ProtoMarshaler / ProtoUnmarshaler: tipe yang menulis wire format
+// protobuf-nya sendiri (lewat protowire, tanpa codegen). Dipakai ProtoCodec
+// bila v bukan proto.Message. Encode menerima value, Decode pointer.
+type ProtoMarshaler interface {
+	MarshalProto() ([]byte, error)
+}
+
+type ProtoUnmarshaler interface {
+	UnmarshalProto(b []byte) error
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string                    { return "json" }
+func (JSONCodec) Marshal(v any) ([]byte, error)   { return json.Marshal(v) }
+func (JSONCodec) Unmarshal(b []byte, v any) error { return json.Unmarshal(b, v) }
+
+// MsgpackCodec: field memakai tag `msgpack`, fallback ke tag `json`.
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) Name() string { return "msgpack" }
+
+func (MsgpackCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (MsgpackCodec) Unmarshal(b []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(b))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+// ProtoCodec: v harus proto.Message atau ProtoMarshaler/ProtoUnmarshaler.
+type ProtoCodec struct{}
+
+func (ProtoCodec) Name() string { return "protobuf" }
+
+func (ProtoCodec) Marshal(v any) ([]byte, error) {
+	switch m := v.(type) {
+	case proto.Message:
+		return proto.Marshal(m)
+	case ProtoMarshaler:
+		return m.MarshalProto()
+	}
+	return nil, fmt.Errorf("cache: protobuf codec: %T is not a proto message", v)
+}
+
+func (ProtoCodec) Unmarshal(b []byte, v any) error {
+	switch m := v.(type) {
+	case proto.Message:
+		return proto.Unmarshal(b, m)
+	case ProtoUnmarshaler:
+		return m.UnmarshalProto(b)
+	}
+	return fmt.Errorf("cache: protobuf codec: %T is not a proto message", v)
+}
+
+// id codec & kompresi di header envelope: JANGAN diubah urutannya, entry
+// lama di Redis membawa id ini.
+var codecs = map[byte]Codec{1: JSONCodec{}, 2: MsgpackCodec{}, 3: ProtoCodec{}}
+
+const (
+	compNone byte = iota
+	compZstd
+	compSnappy
+)
+
+var compressions = map[string]byte{"none": compNone, "zstd": compZstd, "snappy": compSnappy}
+
+// Envelope: [magic][versi envelope][codec][kompresi][schema uint16 BE][payload].
+// Data tanpa header yang cocok (format lama, schema lain) ditolak Decode dan
+// pemanggil memperlakukannya sebagai miss, lalu menimpanya.
+const (
+	envMagic     byte = 0xCE
+	envVersion   byte = 1
+	envHeaderLen      = 6
+
+	// batas hasil dekompresi: value cache rusak/berbahaya tidak boleh
+	// menghabiskan memori
+	maxDecodedLen = 16 << 20
+)
+
+var (
+	ErrEnvelope       = errors.New("cache: not a cache envelope")
+	ErrSchemaMismatch = errors.New("cache: schema version mismatch")
+)
+
+// SerializerOptions untuk NewSerializer.
+type SerializerOptions struct {
+	Codec       string // json (default) | msgpack | protobuf
+	Compression string // none (default) | zstd | snappy
+	// CompressMin: payload lebih kecil dari ini tidak dikompres (default
+	// 1024); hasil kompresi yang tidak lebih kecil juga disimpan apa adanya.
+	CompressMin int
+	// Schema: naikkan setiap bentuk value berubah; entry schema lain = miss.
+	Schema uint16
+}
+
+// Serializer: Codec + kompresi opsional + envelope berversi. Decode membaca
+// codec/kompresi dari header, jadi mengganti cache.codec tidak membuat
+// entry lama salah baca (hanya Schema yang membatalkannya).
+type Serializer struct {
+	codec       Codec
+	codecID     byte
+	comp        byte
+	compName    string
+	compressMin int
+	schema      uint16
+}
+
+// DefaultSerializer: JSON tanpa kompresi, schema 0.
+var DefaultSerializer = MustSerializer(SerializerOptions{})
+
+func NewSerializer(opt SerializerOptions) (*Serializer, error) {
+	if opt.Codec == "" {
+		opt.Codec = "json"
+	}
+	if opt.Compression == "" {
+		opt.Compression = "none"
+	}
+	if opt.CompressMin <= 0 {
+		opt.CompressMin = 1024
+	}
+	s := &Serializer{compName: opt.Compression, compressMin: opt.CompressMin, schema: opt.Schema}
+	for id, c := range codecs {
+		if c.Name() == opt.Codec {
+			s.codec, s.codecID = c, id
+		}
+	}
+	if s.codec == nil {
+		return nil, fmt.Errorf("cache: unknown codec %q", opt.Codec)
+	}
+	comp, ok := compressions[opt.Compression]
+	if !ok {
+		return nil, fmt.Errorf("cache: unknown compression %q", opt.Compression)
+	}
+	s.comp = comp
+	return s, nil
+}
+
+// MustSerializer: NewSerializer yang panic; untuk opsi yang sudah divalidasi.
+func MustSerializer(opt SerializerOptions) *Serializer {
+	s, err := NewSerializer(opt)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// WithSchema: salinan s dengan schema lain (tiap tipe value punya versinya
+// sendiri, codec/kompresi dari config).
+func (s *Serializer) WithSchema(v uint16) *Serializer {
+	c := *s
+	c.schema = v
+	return &c
+}
+
+func (s *Serializer) Encode(v any) ([]byte, error) {
+	payload, err := s.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	comp := compNone
+	if s.comp != compNone && len(payload) >= s.compressMin {
+		if c := compress(s.comp, payload); len(c) < len(payload) {
+			payload, comp = c, s.comp
+		}
+	}
+	out := make([]byte, envHeaderLen, envHeaderLen+len(payload))
+	out[0], out[1], out[2], out[3] = envMagic, envVersion, s.codecID, comp
+	binary.BigEndian.PutUint16(out[4:], s.schema)
+	out = append(out, payload...)
+
+	compLabel := "none"
+	if comp != compNone {
+		compLabel = s.compName
+	}
+	httpx.CacheEncodedBytes.WithLabelValues(s.codec.Name(), compLabel).Observe(float64(len(out)))
+	return out, nil
+}
+
+func (s *Serializer) Decode(b []byte, v any) error {
+	if len(b) < envHeaderLen || b[0] != envMagic || b[1] != envVersion {
+		return ErrEnvelope
+	}
+	if binary.BigEndian.Uint16(b[4:]) != s.schema {
+		return ErrSchemaMismatch
+	}
+	codec, ok := codecs[b[2]]
+	if !ok {
+		return ErrEnvelope
+	}
+	payload, err := decompress(b[3], b[envHeaderLen:])
+	if err != nil {
+		return err
+	}
+	return codec.Unmarshal(payload, v)
+}
+
+var (
+	zstdOnce sync.Once
+	zstdEnc  *zstd.Encoder
+	zstdDec  *zstd.Decoder
+)
+
+// encoder/decoder zstd dipakai bersama; EncodeAll/DecodeAll aman konkuren.
+func zstdInit() {
+	zstdOnce.Do(func() {
+		zstdEnc, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+		zstdDec, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecodedLen))
+	})
+}
+
+func compress(comp byte, b []byte) []byte {
+	switch comp {
+	case compZstd:
+		zstdInit()
+		return zstdEnc.EncodeAll(b, nil)
+	case compSnappy:
+		return snappy.Encode(nil, b)
+	}
+	return b
+}
+
+func decompress(comp byte, b []byte) ([]byte, error) {
+	switch comp {
+	case compNone:
+		return b, nil
+	case compZstd:
+		zstdInit()
+		return zstdDec.DecodeAll(b, nil)
+	case compSnappy:
+		if n, err := snappy.DecodedLen(b); err != nil || n > maxDecodedLen {
+			return nil, ErrEnvelope
+		}
+		return snappy.Decode(nil, b)
+	}
+	return nil, ErrEnvelope
+}
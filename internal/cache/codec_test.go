@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type codecSample struct {
+	Name  string            `json:"name"`
+	Tags  []string          `json:"tags,omitempty"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+	Skip  string            `json:"-"`
+}
+
+func TestSerializer_RoundTrip(t *testing.T) {
+	in := codecSample{Name: strings.Repeat("a", 4000), Tags: []string{"x", "y"}, Attrs: map[string]string{"k": "v"}, Skip: "secret"}
+	for _, codec := range []string{"json", "msgpack"} {
+		for _, comp := range []string{"none", "zstd", "snappy"} {
+			s, err := NewSerializer(SerializerOptions{Codec: codec, Compression: comp, Schema: 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := s.Encode(in)
+			if err != nil {
+				t.Fatalf("%s/%s encode: %v", codec, comp, err)
+			}
+			if comp != "none" && (b[3] == compNone || len(b) > 1000) {
+				t.Errorf("%s/%s: 4KB of repeated bytes should be compressed, got %d bytes", codec, comp, len(b))
+			}
+			if bytes.Contains(b, []byte("secret")) {
+				t.Errorf("%s/%s: json:\"-\" field encoded", codec, comp)
+			}
+			var out codecSample
+			if err := s.Decode(b, &out); err != nil {
+				t.Fatalf("%s/%s decode: %v", codec, comp, err)
+			}
+			if out.Name != in.Name || len(out.Tags) != 2 || out.Attrs["k"] != "v" || out.Skip != "" {
+				t.Fatalf("%s/%s: round trip mismatch: %+v", codec, comp, out)
+			}
+		}
+	}
+}
+
+func TestSerializer_SmallValuesNotCompressed(t *testing.T) {
+	s := MustSerializer(SerializerOptions{Compression: "zstd", CompressMin: 512})
+	b, err := s.Encode(codecSample{Name: "small"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b[3] != compNone || !bytes.Contains(b, []byte(`"small"`)) {
+		t.Fatalf("value below CompressMin should be stored as is: %q", b)
+	}
+}
+
+func TestSerializer_Protobuf(t *testing.T) {
+	s := MustSerializer(SerializerOptions{Codec: "protobuf", Compression: "snappy"})
+	in, _ := structpb.NewStruct(map[string]any{"name": strings.Repeat("p", 2000)})
+	b, err := s.Encode(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out structpb.Struct
+	if err := s.Decode(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Fields["name"].GetStringValue() != strings.Repeat("p", 2000) {
+		t.Fatal("protobuf round trip mismatch")
+	}
+	if _, err := s.Encode(codecSample{}); err == nil {
+		t.Fatal("protobuf codec should reject non-proto values")
+	}
+}
+
+func TestSerializer_ReadsOtherCodecsButNotOtherSchemas(t *testing.T) {
+	w := MustSerializer(SerializerOptions{Codec: "msgpack", Compression: "zstd", CompressMin: 1, Schema: 1})
+	b, err := w.Encode(codecSample{Name: "n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// config berganti ke json: entry msgpack lama tetap terbaca
+	r := MustSerializer(SerializerOptions{Codec: "json", Schema: 1})
+	var out codecSample
+	if err := r.Decode(b, &out); err != nil || out.Name != "n" {
+		t.Fatalf("decode across codecs: %+v, %v", out, err)
+	}
+
+	if err := r.WithSchema(2).Decode(b, &out); !errors.Is(err, ErrSchemaMismatch) {
+		t.Fatalf("want ErrSchemaMismatch, got %v", err)
+	}
+	// value lama tanpa envelope (JSON polos)
+	if err := r.Decode([]byte(`{"name":"legacy"}`), &out); !errors.Is(err, ErrEnvelope) {
+		t.Fatalf("want ErrEnvelope, got %v", err)
+	}
+}
+
+func TestSerializer_CorruptPayload(t *testing.T) {
+	s := MustSerializer(SerializerOptions{Compression: "snappy", CompressMin: 1})
+	b, err := s.Encode(codecSample{Name: strings.Repeat("z", 100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b = b[:len(b)-3]
+	var out codecSample
+	if err := s.Decode(b, &out); err == nil {
+		t.Fatal("truncated value should fail to decode")
+	}
+}
+
+func TestNewSerializer_Unknown(t *testing.T) {
+	if _, err := NewSerializer(SerializerOptions{Codec: "xml"}); err == nil {
+		t.Error("want error for unknown codec")
+	}
+	if _, err := NewSerializer(SerializerOptions{Compression: "lz4"}); err == nil {
+		t.Error("want error for unknown compression")
+	}
+}
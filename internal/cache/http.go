@@ -0,0 +1,268 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Quineeryn/go-backend-101/internal/apperr"
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
+)
+
+// HTTPOptions untuk Middleware.
+type HTTPOptions struct {
+	// TTL dibaca tiap request (ikut hot reload); <= 0 = tidak menyimpan.
+	TTL func() time.Duration
+	// Headers: header request yang nilainya ikut key (mis. Accept-Language).
+	Headers []string
+	// Vary: header tambahan di response Vary yang sudah terwakili di key
+	// lewat user/tenant (mis. Authorization, X-Tenant-ID).
+	Vary []string
+	// MaxBody: response lebih besar tidak disimpan (default 1 MiB).
+	MaxBody int
+}
+
+// httpEntry: response yang disimpan di Store (JSON).
+type httpEntry struct {
+	Status   int                 `json:"status"`
+	Header   map[string][]string `json:"header"`
+	Body     []byte              `json:"body"`
+	ETag     string              `json:"etag"`
+	StoredAt time.Time           `json:"stored_at"`
+}
+
+// header yang ditulis ulang middleware atau spesifik per-request
+var skipHeaders = map[string]bool{
+	"X-Request-Id": true, "Date": true, "Content-Length": true, "Set-Cookie": true,
+	"Etag": true, "Last-Modified": true, "Vary": true, "Age": true, "X-Cache": true,
+}
+
+// Middleware meng-cache response GET 200 di s. Key = tenant + user
+// (httpx.CurrentUserID) + route + path params + query + opt.Headers, jadi
+// pasang SETELAH auth. Menghormati Cache-Control request (no-store,
+// no-cache, max-age, only-if-cached), mengirim ETag/Last-Modified/Vary dan
+// 304 untuk If-None-Match/If-Modified-Since. Response di-buffer: jangan
+// dipakai untuk streaming.
+func Middleware(s Store, opt HTTPOptions) gin.HandlerFunc {
+	if opt.MaxBody <= 0 {
+		opt.MaxBody = 1 << 20
+	}
+	vary := strings.Join(append(append([]string(nil), opt.Headers...), opt.Vary...), ", ")
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		cc := parseRequestCacheControl(c.Request.Header)
+		key := httpKey(c, route, opt.Headers)
+
+		if !cc.noStore && !cc.noCache {
+			if e, ok := loadEntry(s, key); ok && (cc.maxAge < 0 || time.Since(e.StoredAt) <= time.Duration(cc.maxAge)*time.Second) {
+				httpx.CacheHit.WithLabelValues(route).Inc()
+				c.Header("Age", strconv.Itoa(int(time.Since(e.StoredAt).Seconds())))
+				writeEntry(c, e, vary, "HIT")
+				c.Abort()
+				return
+			}
+		}
+		if cc.onlyIfCached {
+			httpx.AbortError(c, "cache", apperr.E(apperr.Timeout, "response is not cached (only-if-cached)", nil))
+			return
+		}
+		httpx.CacheMiss.WithLabelValues(route).Inc()
+
+		before := c.Writer.Header().Clone()
+		w := &bufferWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		status := w.Status()
+		h := c.Writer.Header()
+		if len(c.Errors) > 0 || status != http.StatusOK || h.Get("Set-Cookie") != "" ||
+			strings.Contains(strings.ToLower(h.Get("Cache-Control")), "no-store") {
+			w.flush()
+			return
+		}
+		e := httpEntry{
+			Status:   status,
+			Header:   storableHeader(before, h),
+			Body:     w.buf.Bytes(),
+			ETag:     h.Get("ETag"),
+			StoredAt: time.Now().UTC().Truncate(time.Second),
+		}
+		if e.ETag == "" {
+			e.ETag = WeakETag(e.Body)
+		}
+		if ttl := ttlOf(opt); ttl > 0 && !cc.noStore && len(e.Body) <= opt.MaxBody {
+			if b, err := json.Marshal(e); err == nil {
+				s.Set(key, b, ttl)
+			}
+		}
+		writeEntry(c, e, vary, "MISS")
+	}
+}
+
+func ttlOf(opt HTTPOptions) time.Duration {
+	if opt.TTL == nil {
+		return 0
+	}
+	return opt.TTL()
+}
+
+// HTTPUserPrefix: awalan semua key Middleware milik satu user; dipakai
+// untuk invalidasi (Store.DeleteByPrefix) saat user berubah.
+func HTTPUserPrefix(tenantID, userID string) string {
+	return "http:" + tenantID + ":" + userID + ":"
+}
+
+// httpKey: "http:<tenant>:<user>:<route>:<hash params/query/headers>"
+func httpKey(c *gin.Context, route string, headers []string) string {
+	h := sha256.New()
+	params := append(gin.Params(nil), c.Params...)
+	sort.Slice(params, func(i, j int) bool { return params[i].Key < params[j].Key })
+	for _, p := range params {
+		h.Write([]byte(p.Key + "=" + p.Value + "\x00"))
+	}
+	// url.Values.Encode mengurutkan key → ?a=1&b=2 sama dengan ?b=2&a=1
+	h.Write([]byte(c.Request.URL.Query().Encode() + "\x00"))
+	for _, name := range headers {
+		h.Write([]byte(strings.Join(c.Request.Header.Values(name), ",") + "\x00"))
+	}
+	return HTTPUserPrefix(tenant.ID(c), httpx.CurrentUserID(c)) + route + ":" + hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+func loadEntry(s Store, key string) (httpEntry, bool) {
+	b, ok := s.Get(key)
+	if !ok {
+		return httpEntry{}, false
+	}
+	var e httpEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return httpEntry{}, false
+	}
+	return e, true
+}
+
+// writeEntry menulis e ke client, atau 304 bila request kondisional cocok.
+func writeEntry(c *gin.Context, e httpEntry, vary, xcache string) {
+	out := c.Writer.Header()
+	for k, vs := range e.Header {
+		out[k] = append([]string(nil), vs...)
+	}
+	out.Set("ETag", e.ETag)
+	out.Set("Last-Modified", e.StoredAt.UTC().Format(http.TimeFormat))
+	if vary != "" {
+		out.Set("Vary", vary)
+	}
+	out.Set("X-Cache", xcache)
+	if notModified(c.Request, e.ETag, e.StoredAt) {
+		out.Del("Content-Type")
+		out.Del("Content-Length")
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	c.Status(e.Status)
+	_, _ = c.Writer.Write(e.Body)
+}
+
+// notModified: If-None-Match (perbandingan weak) didahulukan; If-Modified-Since
+// hanya dipakai bila If-None-Match tidak ada (RFC 9110 13.1.3).
+func notModified(r *http.Request, etag string, lastMod time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, t := range strings.Split(inm, ",") {
+			t = strings.TrimSpace(t)
+			if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		if t, err := http.ParseTime(ims); err == nil {
+			return !lastMod.Truncate(time.Second).After(t)
+		}
+	}
+	return false
+}
+
+type requestCacheControl struct {
+	noStore, noCache, onlyIfCached bool
+	maxAge                         int // -1 = tidak dibatasi
+}
+
+func parseRequestCacheControl(h http.Header) requestCacheControl {
+	cc := requestCacheControl{maxAge: -1}
+	raw := h.Values("Cache-Control")
+	if len(raw) == 0 && strings.EqualFold(strings.TrimSpace(h.Get("Pragma")), "no-cache") {
+		cc.noCache = true
+	}
+	for _, line := range raw {
+		for _, d := range strings.Split(line, ",") {
+			name, val, _ := strings.Cut(strings.ToLower(strings.TrimSpace(d)), "=")
+			switch name {
+			case "no-store":
+				cc.noStore = true
+			case "no-cache":
+				cc.noCache = true
+			case "only-if-cached":
+				cc.onlyIfCached = true
+			case "max-age":
+				if n, err := strconv.Atoi(strings.Trim(val, `"`)); err == nil && n >= 0 {
+					cc.maxAge = n
+				}
+			}
+		}
+	}
+	return cc
+}
+
+// storableHeader: hanya header yang ditambah/diubah handler; header dari
+// middleware lain (correlation id, rate limit) milik request saat ini.
+func storableHeader(before, h http.Header) map[string][]string {
+	out := make(map[string][]string, len(h))
+	for k, vs := range h {
+		if skipHeaders[http.CanonicalHeaderKey(k)] || slices.Equal(before[k], vs) {
+			continue
+		}
+		out[k] = append([]string(nil), vs...)
+	}
+	return out
+}
+
+// bufferWriter menahan body (dan status) sampai middleware memutuskan
+// 200/304 atau meneruskan apa adanya.
+type bufferWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *bufferWriter) Write(b []byte) (int, error)       { return w.buf.Write(b) }
+func (w *bufferWriter) WriteString(s string) (int, error) { return w.buf.WriteString(s) }
+func (w *bufferWriter) WriteHeaderNow()                   {}
+func (w *bufferWriter) Written() bool                     { return w.buf.Len() > 0 }
+
+// flush meneruskan response yang tidak di-cache ke writer asli.
+func (w *bufferWriter) flush() {
+	if w.buf.Len() == 0 {
+		return // biarkan ErrorEnvelope / gin menulis status
+	}
+	w.ResponseWriter.WriteHeaderNow()
+	_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+}
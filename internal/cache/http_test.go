@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+	"github.com/Quineeryn/go-backend-101/internal/logger"
+	"github.com/Quineeryn/go-backend-101/internal/middleware"
+)
+
+func newCachedRouter(t *testing.T) (*gin.Engine, *int) {
+	t.Helper()
+	logger.L = zap.NewNop()
+	gin.SetMode(gin.TestMode)
+	mem := NewMemory(time.Minute)
+	t.Cleanup(mem.Close)
+
+	calls := 0
+	r := gin.New()
+	r.Use(middleware.ErrorEnvelope())
+	r.Use(func(c *gin.Context) {
+		c.Header("X-Correlation-Id", c.GetHeader("X-Test-Req")) // milik request, bukan response
+		if u := c.GetHeader("X-User"); u != "" {
+			c.Set(httpx.CtxKeyUserID, u)
+		}
+	})
+	r.Use(Middleware(mem, HTTPOptions{
+		TTL:     func() time.Duration { return time.Minute },
+		Headers: []string{"Accept-Language"},
+		Vary:    []string{"Authorization"},
+	}))
+	r.GET("/items/:id", func(c *gin.Context) {
+		calls++
+		if c.Param("id") == "missing" {
+			c.Status(http.StatusNotFound)
+			_ = c.Error(errNotFound)
+			return
+		}
+		c.Header("Content-Language", c.GetHeader("Accept-Language"))
+		c.JSON(http.StatusOK, gin.H{"id": c.Param("id"), "user": httpx.CurrentUserID(c), "q": c.Query("q"), "n": calls})
+	})
+	return r, &calls
+}
+
+var errNotFound = errors.New("not found")
+
+func get(r *gin.Engine, path string, hdr ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for i := 0; i+1 < len(hdr); i += 2 {
+		req.Header.Set(hdr[i], hdr[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_HitMissAndKeys(t *testing.T) {
+	r, calls := newCachedRouter(t)
+
+	w1 := get(r, "/items/1?a=1&b=2", "X-Test-Req", "r1")
+	w2 := get(r, "/items/1?b=2&a=1", "X-Test-Req", "r2")
+	if w1.Code != 200 || w1.Header().Get("X-Cache") != "MISS" || w2.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("want MISS then HIT, got %q %q", w1.Header().Get("X-Cache"), w2.Header().Get("X-Cache"))
+	}
+	if w1.Body.String() != w2.Body.String() || *calls != 1 {
+		t.Fatalf("hit should replay body without calling handler (calls=%d)", *calls)
+	}
+	if w2.Header().Get("Content-Type") != "application/json; charset=utf-8" || w2.Header().Get("X-Correlation-Id") != "r2" {
+		t.Fatalf("hit headers: %v", w2.Header())
+	}
+	if w2.Header().Get("ETag") == "" || w2.Header().Get("ETag") != w1.Header().Get("ETag") ||
+		w2.Header().Get("Last-Modified") == "" || w2.Header().Get("Age") == "" ||
+		w2.Header().Get("Vary") != "Accept-Language, Authorization" {
+		t.Fatalf("validators: %v", w2.Header())
+	}
+
+	// key berbeda: path param, query, header terpilih, user
+	for _, tc := range []struct {
+		path string
+		hdr  []string
+	}{
+		{"/items/2", nil},
+		{"/items/1?a=1&b=3", nil},
+		{"/items/1?a=1&b=2", []string{"Accept-Language", "id"}},
+		{"/items/1?a=1&b=2", []string{"X-User", "u1"}},
+	} {
+		if w := get(r, tc.path, tc.hdr...); w.Header().Get("X-Cache") != "MISS" {
+			t.Fatalf("%s %v: want MISS", tc.path, tc.hdr)
+		}
+	}
+
+	// error tidak di-cache
+	get(r, "/items/missing")
+	if w := get(r, "/items/missing"); w.Code != http.StatusNotFound || w.Header().Get("X-Cache") != "" {
+		t.Fatalf("error response: %d %v", w.Code, w.Header())
+	}
+}
+
+func TestMiddleware_Conditional(t *testing.T) {
+	r, _ := newCachedRouter(t)
+	w := get(r, "/items/1")
+	etag, lm := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+
+	for _, hdr := range [][]string{
+		{"If-None-Match", etag},
+		{"If-None-Match", `"other", ` + etag},
+		{"If-None-Match", "*"},
+		{"If-Modified-Since", lm},
+	} {
+		w := get(r, "/items/1", hdr...)
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("ETag") != etag {
+			t.Fatalf("%v: want 304, got %d %q", hdr, w.Code, w.Body.String())
+		}
+	}
+	if w := get(r, "/items/1", "If-None-Match", `W/"nope"`, "If-Modified-Since", lm); w.Code != http.StatusOK {
+		t.Fatalf("If-None-Match wins over If-Modified-Since: got %d", w.Code)
+	}
+	// ETag milik resource lain tidak cocok
+	if w := get(r, "/items/9", "If-None-Match", etag); w.Code != http.StatusOK {
+		t.Fatalf("other resource: got %d", w.Code)
+	}
+}
+
+func TestMiddleware_RequestCacheControl(t *testing.T) {
+	r, calls := newCachedRouter(t)
+
+	if w := get(r, "/items/1", "Cache-Control", "only-if-cached"); w.Code != http.StatusGatewayTimeout || *calls != 0 {
+		t.Fatalf("only-if-cached miss: %d (calls=%d)", w.Code, *calls)
+	}
+	get(r, "/items/1")
+	if w := get(r, "/items/1", "Cache-Control", "only-if-cached"); w.Header().Get("X-Cache") != "HIT" {
+		t.Fatal("only-if-cached hit")
+	}
+	if w := get(r, "/items/1", "Cache-Control", "no-cache"); w.Header().Get("X-Cache") != "MISS" || *calls != 2 {
+		t.Fatalf("no-cache should revalidate (calls=%d)", *calls)
+	}
+	if w := get(r, "/items/1", "Pragma", "no-cache"); w.Header().Get("X-Cache") != "MISS" {
+		t.Fatal("Pragma: no-cache")
+	}
+	if w := get(r, "/items/1", "Cache-Control", "max-age=3600"); w.Header().Get("X-Cache") != "HIT" {
+		t.Fatal("max-age within age should hit")
+	}
+
+	// no-store: tidak baca & tidak menyimpan
+	get(r, "/items/2", "Cache-Control", "no-store")
+	if w := get(r, "/items/2", "Cache-Control", "only-if-cached"); w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("no-store response was cached: %d", w.Code)
+	}
+}
+
+func TestParseRequestCacheControl(t *testing.T) {
+	h := http.Header{"Cache-Control": {`No-Cache, max-age="0"`}}
+	if cc := parseRequestCacheControl(h); !cc.noCache || cc.maxAge != 0 || cc.noStore {
+		t.Fatalf("%+v", cc)
+	}
+	if cc := parseRequestCacheControl(http.Header{}); cc.maxAge != -1 || cc.noCache {
+		t.Fatalf("%+v", cc)
+	}
+}
@@ -2,29 +2,158 @@ package cache
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// RedisOptions untuk NewRedisWith.
+type RedisOptions struct {
+	Mode             string   // standalone (default) | sentinel | cluster
+	Addr             string   // standalone
+	Addrs            []string // seed sentinel/cluster (kosong → Addr)
+	MasterName       string   // sentinel
+	Password         string
+	SentinelPassword string
+	DB               int // diabaikan di cluster
+
+	DialTimeout, ReadTimeout, WriteTimeout time.Duration // default 500ms
+	Breaker                                BreakerOptions
+}
+
+// FailMode: perilaku konsumen (rate limit, idempotency, job lock) saat
+// Redis tidak tersedia.
+type FailMode string
+
+const (
+	FailOpen   FailMode = "open"   // lanjut tanpa fitur (tidak dibatasi / tanpa dedup)
+	FailClosed FailMode = "closed" // tolak (503) / lewati
+	FailLocal  FailMode = "local"  // pakai implementasi in-process per replica
+)
+
+// Redis: client (standalone/sentinel/cluster) dengan circuit breaker. Saat
+// breaker terbuka, perintah langsung gagal dengan ErrCircuitOpen dan ping
+// di background menutupnya kembali begitu Redis pulih — konsumen cukup
+// memperlakukan error sebagai "Redis tidak tersedia".
 type Redis struct {
-	C *redis.Client
+	C       redis.UniversalClient
+	Breaker *Breaker
+
+	quit      chan struct{}
+	closeOnce sync.Once
 }
 
+// NewRedis: standalone dengan breaker default.
 func NewRedis(addr, pass string, db int) *Redis {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:         addr,
-		Password:     pass,
-		DB:           db,
-		MinIdleConns: 3,
-		PoolSize:     20,
-		ReadTimeout:  500 * time.Millisecond,
-		WriteTimeout: 500 * time.Millisecond,
-		DialTimeout:  500 * time.Millisecond,
-	})
-	return &Redis{C: rdb}
+	return NewRedisWith(RedisOptions{Addr: addr, Password: pass, DB: db})
+}
+
+func NewRedisWith(opt RedisOptions) *Redis {
+	for _, d := range []*time.Duration{&opt.DialTimeout, &opt.ReadTimeout, &opt.WriteTimeout} {
+		if *d <= 0 {
+			*d = 500 * time.Millisecond
+		}
+	}
+	addrs := opt.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{opt.Addr}
+	}
+	u := &redis.UniversalOptions{
+		Addrs:            addrs,
+		MasterName:       opt.MasterName,
+		Password:         opt.Password,
+		SentinelPassword: opt.SentinelPassword,
+		DB:               opt.DB,
+		MinIdleConns:     3,
+		PoolSize:         20,
+		DialTimeout:      opt.DialTimeout,
+		ReadTimeout:      opt.ReadTimeout,
+		WriteTimeout:     opt.WriteTimeout,
+	}
+	var c redis.UniversalClient
+	switch opt.Mode {
+	case "cluster":
+		c = redis.NewClusterClient(u.Cluster())
+	case "sentinel":
+		c = redis.NewFailoverClient(u.Failover())
+	default:
+		c = redis.NewClient(u.Simple())
+	}
+	r := &Redis{C: c, Breaker: NewBreaker(opt.Breaker), quit: make(chan struct{})}
+	c.AddHook(r.Breaker.Hook())
+	go r.reconnect(r.Breaker.opt.Cooldown)
+	return r
 }
 
 func (r *Redis) Ping(ctx context.Context) error {
 	return r.C.Ping(ctx).Err()
 }
+
+// Available: false selama breaker terbuka (tanpa menyentuh jaringan).
+func (r *Redis) Available() bool { return r.Breaker.State() != BreakerOpen }
+
+// reconnect: selama breaker tidak closed, ping tiap cooldown supaya probe
+// tetap jalan walau tidak ada traffic.
+func (r *Redis) reconnect(every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if r.Breaker.State() != BreakerClosed {
+				ctx, cancel := context.WithTimeout(context.Background(), every)
+				_ = r.Ping(ctx)
+				cancel()
+			}
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+func (r *Redis) Close() error {
+	var err error
+	r.closeOnce.Do(func() {
+		close(r.quit)
+		err = r.C.Close()
+	})
+	return err
+}
+
+// ScanKeys memanggil fn untuk setiap key yang cocok pattern; di cluster
+// SCAN dijalankan di setiap master satu per satu, jadi fn tidak pernah
+// dipanggil paralel (pemanggil boleh berbagi state tanpa lock).
+func ScanKeys(ctx context.Context, rdb redis.UniversalClient, pattern string, fn func(ctx context.Context, key string) error) error {
+	scan := func(ctx context.Context, c redis.Cmdable) error {
+		iter := c.Scan(ctx, 0, pattern, 500).Iterator()
+		for iter.Next(ctx) {
+			if err := fn(ctx, iter.Val()); err != nil {
+				return err
+			}
+		}
+		return iter.Err()
+	}
+	cc, ok := rdb.(*redis.ClusterClient)
+	if !ok {
+		return scan(ctx, rdb)
+	}
+	// ForEachMaster memanggil callback paralel (goroutine per master):
+	// kumpulkan dulu, lalu scan berurutan
+	var mu sync.Mutex
+	var masters []*redis.Client
+	if err := cc.ForEachMaster(ctx, func(_ context.Context, c *redis.Client) error {
+		mu.Lock()
+		masters = append(masters, c)
+		mu.Unlock()
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, c := range masters {
+		if err := scan(ctx, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
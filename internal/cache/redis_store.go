@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/Quineeryn/go-backend-101/internal/logger"
+)
+
+// RedisStore: Store di Redis, shared antar replica. Error Redis dicatat dan
+// diperlakukan sebagai miss: cache tidak boleh menjatuhkan request.
+type RedisStore struct {
+	c redis.UniversalClient
+}
+
+func NewRedisStore(c redis.UniversalClient) *RedisStore { return &RedisStore{c: c} }
+
+// warn: breaker terbuka tidak dicatat per request (transisinya sudah).
+func warn(msg string, err error, fields ...zap.Field) {
+	if !errors.Is(err, ErrCircuitOpen) {
+		logger.L.Warn(msg, append(fields, zap.Error(err))...)
+	}
+}
+
+func (s *RedisStore) Get(key string) ([]byte, bool) {
+	b, err := s.c.Get(context.Background(), key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			warn("cache.redis.get_failed", err, zap.String("key", key))
+		}
+		return nil, false
+	}
+	return b, true
+}
+
+func (s *RedisStore) Set(key string, val []byte, ttl time.Duration) {
+	if err := s.c.Set(context.Background(), key, val, ttl).Err(); err != nil {
+		warn("cache.redis.set_failed", err, zap.String("key", key))
+	}
+}
+
+func (s *RedisStore) Delete(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	// UNLINK per key dalam satu pipeline: di cluster key bisa beda slot
+	_, err := s.c.Pipelined(context.Background(), func(p redis.Pipeliner) error {
+		for _, k := range keys {
+			p.Unlink(context.Background(), k)
+		}
+		return nil
+	})
+	if err != nil {
+		warn("cache.redis.delete_failed", err, zap.Strings("keys", keys))
+	}
+}
+
+// DeleteByPrefix: SCAN (tiap master di cluster) + UNLINK per batch, tanpa
+// KEYS yang memblokir Redis.
+func (s *RedisStore) DeleteByPrefix(prefix string) {
+	batch := make([]string, 0, 500)
+	err := ScanKeys(context.Background(), s.c, globEscape(prefix)+"*", func(_ context.Context, k string) error {
+		batch = append(batch, k)
+		if len(batch) == cap(batch) {
+			s.Delete(batch...)
+			batch = batch[:0]
+		}
+		return nil
+	})
+	s.Delete(batch...)
+	if err != nil {
+		warn("cache.redis.scan_failed", err, zap.String("prefix", prefix))
+	}
+}
+
+// Close tidak menutup client (milik pemanggil, dipakai komponen lain).
+func (s *RedisStore) Close() {}
+
+// globEscape: prefix literal untuk pola MATCH.
+func globEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
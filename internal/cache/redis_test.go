@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newMiniCluster: dua miniredis sebagai master, slot dibagi dua. Cukup
+// untuk membuat ForEachMaster berjalan paralel seperti cluster asli.
+func newMiniCluster(t *testing.T) *redis.ClusterClient {
+	t.Helper()
+	a, b := miniredis.RunT(t), miniredis.RunT(t)
+	c := redis.NewClusterClient(&redis.ClusterOptions{
+		ClusterSlots: func(context.Context) ([]redis.ClusterSlot, error) {
+			return []redis.ClusterSlot{
+				{Start: 0, End: 8191, Nodes: []redis.ClusterNode{{Addr: a.Addr()}}},
+				{Start: 8192, End: 16383, Nodes: []redis.ClusterNode{{Addr: b.Addr()}}},
+			}, nil
+		},
+	})
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestScanKeys_ClusterSequential(t *testing.T) {
+	ctx := context.Background()
+	rdb := newMiniCluster(t)
+	for i := 0; i < 200; i++ {
+		if err := rdb.Set(ctx, fmt.Sprintf("u:%d", i), "x", 0).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n := 0 // sengaja tanpa lock: -race akan menangkap kalau fn dipanggil paralel
+	if err := ScanKeys(ctx, rdb, "u:*", func(context.Context, string) error {
+		n++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if n != 200 {
+		t.Fatalf("scanned=%d want 200", n)
+	}
+}
+
+func TestRedisStore_DeleteByPrefixCluster(t *testing.T) {
+	ctx := context.Background()
+	rdb := newMiniCluster(t)
+	s := NewRedisStore(rdb)
+	for i := 0; i < 1200; i++ {
+		s.Set(fmt.Sprintf("u:%d", i), []byte("1"), time.Minute)
+	}
+	s.Set("keep", []byte("1"), time.Minute)
+
+	s.DeleteByPrefix("u:")
+
+	left := 0
+	if err := ScanKeys(ctx, rdb, "*", func(_ context.Context, key string) error {
+		if key != "keep" {
+			t.Errorf("key %q survived DeleteByPrefix", key)
+		}
+		left++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if left != 1 {
+		t.Fatalf("left=%d want 1", left)
+	}
+}
@@ -1,74 +1,243 @@
 package cache
 
 import (
+	"container/list"
+	"hash/fnv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
 )
 
+// Store: cache key → bytes. Implementasi: Memory (per proses), RedisStore
+// (shared) dan Tiered (Memory di depan Redis).
 type Store interface {
 	Get(key string) (val []byte, ok bool)
 	Set(key string, val []byte, ttl time.Duration)
+	Delete(keys ...string)
+	// DeleteByPrefix membuang semua key berawalan prefix (mis. semua
+	// response cache milik satu user, lihat HTTPUserPrefix).
+	DeleteByPrefix(prefix string)
 	Close()
 }
 
+// MemoryOptions untuk NewMemoryWith. Batas dibagi rata per shard.
+type MemoryOptions struct {
+	Name       string        // label metrik (default "default")
+	Shards     int           // dibulatkan ke pangkat 2 (default 16)
+	MaxEntries int           // default 100k
+	MaxBytes   int64         // key + value + overhead per entry (default 64 MiB)
+	GCInterval time.Duration // sweep entry expired (default 1m)
+}
+
+// entryOverhead: perkiraan biaya struct + elemen list + slot map per entry,
+// supaya key-flood dengan value kosong tetap terhitung.
+const entryOverhead = 96
+
 type memEntry struct {
+	key   string
 	val   []byte
 	expAt time.Time
+	size  int64
+}
+
+type memShard struct {
+	mu         sync.Mutex
+	items      map[string]*list.Element
+	lru        *list.List // depan = paling baru dipakai
+	bytes      int64
+	maxEntries int
+	maxBytes   int64
 }
 
+// Memory: cache in-process ber-shard (mutex per shard) dengan eviction LRU
+// berdasarkan jumlah entry dan byte. Expired dibuang saat dibaca dan oleh
+// sweep berkala yang mengunci satu shard per waktu.
 type Memory struct {
-	mu    sync.Mutex
-	items map[string]memEntry
-	quit  chan struct{}
-	ttlGC time.Duration
+	shards []*memShard
+	mask   uint64
+	name   string
+
+	hits, misses atomic.Int64
+	quit         chan struct{}
+	closeOnce    sync.Once
 }
 
+// NewMemory: Memory dengan batas default; ttlGC = interval sweep.
 func NewMemory(ttlGC time.Duration) *Memory {
-	m := &Memory{
-		items: make(map[string]memEntry),
-		quit:  make(chan struct{}),
-		ttlGC: ttlGC,
+	return NewMemoryWith(MemoryOptions{GCInterval: ttlGC})
+}
+
+func NewMemoryWith(opt MemoryOptions) *Memory {
+	if opt.Name == "" {
+		opt.Name = "default"
+	}
+	if opt.Shards <= 0 {
+		opt.Shards = 16
 	}
-	go m.gc()
+	n := 1
+	for n < opt.Shards {
+		n <<= 1
+	}
+	if opt.MaxEntries <= 0 {
+		opt.MaxEntries = 100_000
+	}
+	if opt.MaxBytes <= 0 {
+		opt.MaxBytes = 64 << 20
+	}
+	if opt.GCInterval <= 0 {
+		opt.GCInterval = time.Minute
+	}
+	m := &Memory{shards: make([]*memShard, n), mask: uint64(n - 1), name: opt.Name, quit: make(chan struct{})}
+	for i := range m.shards {
+		m.shards[i] = &memShard{
+			items:      make(map[string]*list.Element),
+			lru:        list.New(),
+			maxEntries: max(1, (opt.MaxEntries+n-1)/n),
+			maxBytes:   max(1, (opt.MaxBytes+int64(n)-1)/int64(n)),
+		}
+	}
+	go m.gc(opt.GCInterval)
 	return m
 }
 
+func (m *Memory) shard(key string) *memShard {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return m.shards[h.Sum64()&m.mask]
+}
+
 func (m *Memory) Get(key string) ([]byte, bool) {
+	s := m.shard(key)
 	now := time.Now()
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	e, ok := m.items[key]
-	if !ok || now.After(e.expAt) {
-		if ok {
-			delete(m.items, key)
+	s.mu.Lock()
+	el, ok := s.items[key]
+	if ok {
+		e := el.Value.(*memEntry)
+		if now.After(e.expAt) {
+			m.remove(s, el, "expired")
+		} else {
+			s.lru.MoveToFront(el)
+			s.mu.Unlock()
+			m.hits.Add(1)
+			httpx.CacheMemRequests.WithLabelValues(m.name, "hit").Inc()
+			return e.val, true
 		}
-		return nil, false
 	}
-	return e.val, true
+	s.mu.Unlock()
+	m.misses.Add(1)
+	httpx.CacheMemRequests.WithLabelValues(m.name, "miss").Inc()
+	return nil, false
 }
 
+// Set menyimpan/menimpa key lalu meng-evict LRU sampai shard kembali di
+// bawah batas. Entry yang sendirian melebihi batas byte shard tidak disimpan.
 func (m *Memory) Set(key string, val []byte, ttl time.Duration) {
-	m.mu.Lock()
-	m.items[key] = memEntry{val: val, expAt: time.Now().Add(ttl)}
-	m.mu.Unlock()
+	s := m.shard(key)
+	e := &memEntry{key: key, val: val, expAt: time.Now().Add(ttl), size: int64(len(key)+len(val)) + entryOverhead}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.items[key]; ok {
+		m.remove(s, el, "")
+	}
+	if e.size > s.maxBytes {
+		httpx.CacheMemEvictions.WithLabelValues(m.name, "too_large").Inc()
+		return
+	}
+	s.items[key] = s.lru.PushFront(e)
+	s.bytes += e.size
+	httpx.CacheMemEntries.WithLabelValues(m.name).Inc()
+	httpx.CacheMemBytes.WithLabelValues(m.name).Add(float64(e.size))
+	for len(s.items) > s.maxEntries || s.bytes > s.maxBytes {
+		m.remove(s, s.lru.Back(), "capacity")
+	}
+}
+
+// remove: s.mu harus dipegang. reason "" = bukan eviction (delete/overwrite).
+func (m *Memory) remove(s *memShard, el *list.Element, reason string) {
+	e := s.lru.Remove(el).(*memEntry)
+	delete(s.items, e.key)
+	s.bytes -= e.size
+	httpx.CacheMemEntries.WithLabelValues(m.name).Dec()
+	httpx.CacheMemBytes.WithLabelValues(m.name).Sub(float64(e.size))
+	if reason != "" {
+		httpx.CacheMemEvictions.WithLabelValues(m.name, reason).Inc()
+	}
+}
+
+func (m *Memory) Delete(keys ...string) {
+	for _, k := range keys {
+		s := m.shard(k)
+		s.mu.Lock()
+		if el, ok := s.items[k]; ok {
+			m.remove(s, el, "")
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (m *Memory) DeleteByPrefix(prefix string) {
+	for _, s := range m.shards {
+		s.mu.Lock()
+		for k, el := range s.items {
+			if strings.HasPrefix(k, prefix) {
+				m.remove(s, el, "")
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// MemoryStats: snapshot untuk debug/test; metrik Prometheus ada di httpx.
+type MemoryStats struct {
+	Entries  int
+	Bytes    int64
+	Hits     int64
+	Misses   int64
+	HitRatio float64
 }
 
-func (m *Memory) Close() { close(m.quit) }
+func (m *Memory) Stats() MemoryStats {
+	var st MemoryStats
+	for _, s := range m.shards {
+		s.mu.Lock()
+		st.Entries += len(s.items)
+		st.Bytes += s.bytes
+		s.mu.Unlock()
+	}
+	st.Hits, st.Misses = m.hits.Load(), m.misses.Load()
+	if total := st.Hits + st.Misses; total > 0 {
+		st.HitRatio = float64(st.Hits) / float64(total)
+	}
+	return st
+}
+
+// Close menghentikan sweep dan mengosongkan cache (gauge ikut turun).
+func (m *Memory) Close() {
+	m.closeOnce.Do(func() {
+		close(m.quit)
+		m.DeleteByPrefix("")
+	})
+}
 
-func (m *Memory) gc() {
-	t := time.NewTicker(m.ttlGC)
+func (m *Memory) gc(every time.Duration) {
+	t := time.NewTicker(every)
 	defer t.Stop()
 	for {
 		select {
 		case <-t.C:
-			now := time.Now()
-			m.mu.Lock()
-			for k, v := range m.items {
-				if now.After(v.expAt) {
-					delete(m.items, k)
+			for _, s := range m.shards {
+				now := time.Now()
+				s.mu.Lock()
+				for _, el := range s.items {
+					if now.After(el.Value.(*memEntry).expAt) {
+						m.remove(s, el, "expired")
+					}
 				}
+				s.mu.Unlock()
 			}
-			m.mu.Unlock()
 		case <-m.quit:
 			return
 		}
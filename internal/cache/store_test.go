@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemory_LRUByEntries(t *testing.T) {
+	m := NewMemoryWith(MemoryOptions{Shards: 1, MaxEntries: 2})
+	defer m.Close()
+
+	m.Set("a", []byte("1"), time.Minute)
+	m.Set("b", []byte("2"), time.Minute)
+	if _, ok := m.Get("a"); !ok { // a jadi paling baru
+		t.Fatal("a missing")
+	}
+	m.Set("c", []byte("3"), time.Minute)
+
+	if _, ok := m.Get("b"); ok {
+		t.Fatal("b (least recently used) should be evicted")
+	}
+	for _, k := range []string{"a", "c"} {
+		if _, ok := m.Get(k); !ok {
+			t.Fatalf("%s evicted", k)
+		}
+	}
+	if st := m.Stats(); st.Entries != 2 {
+		t.Fatalf("entries = %d", st.Entries)
+	}
+}
+
+func TestMemory_LRUByBytes(t *testing.T) {
+	// muat 3 entry 100 byte (+ key + overhead), tidak 4
+	m := NewMemoryWith(MemoryOptions{Shards: 1, MaxBytes: 3 * (100 + 1 + entryOverhead)})
+	defer m.Close()
+
+	val := []byte(strings.Repeat("x", 100))
+	for _, k := range []string{"a", "b", "c", "d"} {
+		m.Set(k, val, time.Minute)
+	}
+	if _, ok := m.Get("a"); ok {
+		t.Fatal("a should be evicted")
+	}
+	st := m.Stats()
+	if st.Entries != 3 || st.Bytes != 3*(100+1+entryOverhead) {
+		t.Fatalf("stats = %+v", st)
+	}
+
+	// overwrite menghitung ulang ukuran
+	m.Set("d", []byte("y"), time.Minute)
+	if st := m.Stats(); st.Bytes != 2*(100+1+entryOverhead)+(1+1+entryOverhead) {
+		t.Fatalf("bytes after overwrite = %d", st.Bytes)
+	}
+
+	// entry yang lebih besar dari batas tidak disimpan & tidak mengusir yang lain
+	m.Set("huge", make([]byte, 1000), time.Minute)
+	if _, ok := m.Get("huge"); ok {
+		t.Fatal("oversized entry stored")
+	}
+	if st := m.Stats(); st.Entries != 3 {
+		t.Fatalf("entries = %d", st.Entries)
+	}
+}
+
+func TestMemory_ExpiryAndGC(t *testing.T) {
+	m := NewMemoryWith(MemoryOptions{GCInterval: 10 * time.Millisecond})
+	defer m.Close()
+
+	m.Set("short", []byte("1"), 5*time.Millisecond)
+	m.Set("long", []byte("2"), time.Minute)
+	deadline := time.Now().Add(time.Second)
+	for m.Stats().Entries != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expired entry not swept: %+v", m.Stats())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, ok := m.Get("long"); !ok {
+		t.Fatal("long swept")
+	}
+
+	m.Set("lazy", []byte("3"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok := m.Get("lazy"); ok {
+		t.Fatal("expired entry returned")
+	}
+}
+
+func TestMemory_DeleteAndStats(t *testing.T) {
+	m := NewMemory(time.Minute)
+	defer m.Close()
+
+	for i := range 50 {
+		m.Set(fmt.Sprintf("u1:%d", i), []byte("x"), time.Minute)
+		m.Set(fmt.Sprintf("u2:%d", i), []byte("x"), time.Minute)
+	}
+	m.DeleteByPrefix("u1:")
+	m.Delete("u2:0", "u2:1", "missing")
+	if st := m.Stats(); st.Entries != 48 {
+		t.Fatalf("entries = %d", st.Entries)
+	}
+	if _, ok := m.Get("u1:3"); ok {
+		t.Fatal("prefix not deleted")
+	}
+	if _, ok := m.Get("u2:3"); !ok {
+		t.Fatal("other prefix deleted")
+	}
+	if st := m.Stats(); st.Hits != 1 || st.Misses != 1 || st.HitRatio != 0.5 {
+		t.Fatalf("stats = %+v", st)
+	}
+
+	m.Close()
+	if st := m.Stats(); st.Entries != 0 || st.Bytes != 0 {
+		t.Fatalf("after close: %+v", st)
+	}
+}
+
+func TestMemory_ConcurrentBounded(t *testing.T) {
+	m := NewMemoryWith(MemoryOptions{MaxEntries: 256})
+	defer m.Close()
+
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 2000 {
+				k := fmt.Sprintf("k%d:%d", g, i)
+				m.Set(k, []byte(k), time.Minute)
+				m.Get(k)
+				if i%100 == 0 {
+					m.DeleteByPrefix(fmt.Sprintf("k%d:1", g))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	// batas dibulatkan ke atas per shard (16 × 16)
+	if st := m.Stats(); st.Entries > 256 {
+		t.Fatalf("entries = %d > max", st.Entries)
+	}
+}
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/Quineeryn/go-backend-101/internal/logger"
+)
+
+// InvalidateChannel: channel pub/sub Redis untuk invalidasi L1 antar replica.
+const InvalidateChannel = "cache:invalidate"
+
+// invalidation: pesan di InvalidateChannel.
+type invalidation struct {
+	Node   string   `json:"node"`
+	Keys   []string `json:"keys,omitempty"`
+	Prefix string   `json:"prefix,omitempty"`
+}
+
+// Tiered: L1 in-process (TTL pendek) di depan L2 (biasanya RedisStore).
+// Delete/DeleteByPrefix (jalur perubahan data) menghapus di dua level lalu
+// publish ke InvalidateChannel supaya L1 replica lain ikut dibuang. Set
+// hanya mengisi L1 & L2 lokal: fill setelah miss tidak boleh mengosongkan
+// L1 replica lain. Pesan yang hilang (Redis putus) hanya membuat L1 replica
+// lain basi paling lama l1TTL.
+type Tiered struct {
+	l1    *Memory
+	l2    Store
+	l1TTL time.Duration
+	rdb   redis.UniversalClient // nil = tanpa pub/sub (single replica)
+	node  string
+
+	ps   *redis.PubSub
+	done chan struct{}
+}
+
+// NewTiered mulai mendengarkan InvalidateChannel bila rdb != nil. Tiered
+// memiliki l1 & l2 (ikut ditutup di Close).
+func NewTiered(l1 *Memory, l2 Store, l1TTL time.Duration, rdb redis.UniversalClient) *Tiered {
+	t := &Tiered{l1: l1, l2: l2, l1TTL: l1TTL, rdb: rdb, node: uuid.NewString(), done: make(chan struct{})}
+	if rdb == nil {
+		close(t.done)
+		return t
+	}
+	t.ps = rdb.Subscribe(context.Background(), InvalidateChannel)
+	// tunggu konfirmasi subscribe supaya invalidasi sesudah ini tidak terlewat;
+	// gagal → go-redis tetap mencoba reconnect di background
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	if _, err := t.ps.Receive(ctx); err != nil {
+		logger.L.Warn("cache.tiered.subscribe_failed", zap.Error(err))
+	}
+	cancel()
+	go t.listen()
+	return t
+}
+
+func (t *Tiered) listen() {
+	defer close(t.done)
+	for msg := range t.ps.Channel() {
+		var inv invalidation
+		if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil || inv.Node == t.node {
+			continue
+		}
+		t.l1.Delete(inv.Keys...)
+		if inv.Prefix != "" {
+			t.l1.DeleteByPrefix(inv.Prefix)
+		}
+	}
+}
+
+func (t *Tiered) publish(inv invalidation) {
+	if t.rdb == nil {
+		return
+	}
+	inv.Node = t.node
+	b, _ := json.Marshal(inv)
+	if err := t.rdb.Publish(context.Background(), InvalidateChannel, b).Err(); err != nil {
+		warn("cache.tiered.publish_failed", err)
+	}
+}
+
+func (t *Tiered) Get(key string) ([]byte, bool) {
+	if v, ok := t.l1.Get(key); ok {
+		return v, true
+	}
+	v, ok := t.l2.Get(key)
+	if ok {
+		t.l1.Set(key, v, t.l1TTL)
+	}
+	return v, ok
+}
+
+func (t *Tiered) Set(key string, val []byte, ttl time.Duration) {
+	t.l2.Set(key, val, ttl)
+	t.l1.Set(key, val, min(ttl, t.l1TTL))
+}
+
+func (t *Tiered) Delete(keys ...string) {
+	t.l2.Delete(keys...)
+	t.l1.Delete(keys...)
+	t.publish(invalidation{Keys: keys})
+}
+
+func (t *Tiered) DeleteByPrefix(prefix string) {
+	t.l2.DeleteByPrefix(prefix)
+	t.l1.DeleteByPrefix(prefix)
+	t.publish(invalidation{Prefix: prefix})
+}
+
+func (t *Tiered) Close() {
+	if t.ps != nil {
+		_ = t.ps.Close()
+	}
+	<-t.done
+	t.l1.Close()
+	t.l2.Close()
+}
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/Quineeryn/go-backend-101/internal/logger"
+)
+
+func newMiniRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	logger.L = zap.NewNop()
+	mr := miniredis.RunT(t)
+	c := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestRedisStore(t *testing.T) {
+	s := NewRedisStore(newMiniRedis(t))
+	s.Set("a", []byte("1"), time.Minute)
+	if v, ok := s.Get("a"); !ok || string(v) != "1" {
+		t.Fatalf("get = %q %v", v, ok)
+	}
+	s.Delete("a")
+	if _, ok := s.Get("a"); ok {
+		t.Fatal("deleted key still present")
+	}
+
+	// prefix dengan karakter glob diperlakukan literal
+	for _, k := range []string{"u*1:a", "u*1:b", "ux1:a", "u*10:a"} {
+		s.Set(k, []byte("x"), time.Minute)
+	}
+	s.DeleteByPrefix("u*1:")
+	for k, want := range map[string]bool{"u*1:a": false, "u*1:b": false, "ux1:a": true, "u*10:a": true} {
+		if _, ok := s.Get(k); ok != want {
+			t.Fatalf("%s present=%v, want %v", k, ok, want)
+		}
+	}
+}
+
+// eventually: pesan pub/sub diproses async
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTiered_CrossReplicaInvalidation(t *testing.T) {
+	rdb := newMiniRedis(t)
+	newNode := func() *Tiered {
+		n := NewTiered(NewMemory(time.Minute), NewRedisStore(rdb), time.Minute, rdb)
+		t.Cleanup(n.Close)
+		return n
+	}
+	a, b := newNode(), newNode()
+
+	a.Set("me:1", []byte("v1"), time.Minute)
+	if v, ok := b.Get("me:1"); !ok || string(v) != "v1" {
+		t.Fatalf("b should read through L2: %q %v", v, ok)
+	}
+	// b sekarang punya L1; ubah L2 diam-diam → b masih menyajikan L1
+	_ = rdb.Set(t.Context(), "me:1", "sneaky", time.Minute).Err()
+	if v, _ := b.Get("me:1"); string(v) != "v1" {
+		t.Fatalf("b should serve from L1, got %q", v)
+	}
+
+	// fill di a tidak menyentuh L1 b; perubahan data lewat Delete
+	a.Set("me:1", []byte("v2"), time.Minute)
+	if v, _ := b.Get("me:1"); string(v) != "v1" {
+		t.Fatalf("Set must not evict other replicas' L1, got %q", v)
+	}
+	a.Delete("me:1")
+	eventually(t, "eviction after Delete", func() bool { _, ok := b.Get("me:1"); return !ok })
+
+	a.Set("http:t:u1:/me:x", []byte("1"), time.Minute)
+	a.Set("http:t:u2:/me:x", []byte("2"), time.Minute)
+	b.Get("http:t:u1:/me:x")
+	b.Get("http:t:u2:/me:x")
+	a.DeleteByPrefix("http:t:u1:")
+	eventually(t, "eviction after DeleteByPrefix", func() bool { _, ok := b.Get("http:t:u1:/me:x"); return !ok })
+	if _, ok := b.Get("http:t:u2:/me:x"); !ok {
+		t.Fatal("other user's entry should survive")
+	}
+}
+
+func TestTiered_SetDoesNotPublish(t *testing.T) {
+	rdb := newMiniRedis(t)
+	ps := rdb.Subscribe(t.Context(), InvalidateChannel)
+	t.Cleanup(func() { _ = ps.Close() })
+	if _, err := ps.Receive(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	s := NewTiered(NewMemory(time.Minute), NewRedisStore(rdb), time.Minute, rdb)
+	defer s.Close()
+
+	s.Set("fill", []byte("v"), time.Minute)
+	s.Delete("changed")
+
+	// publish dari satu client berurutan: pesan pertama harus milik Delete
+	select {
+	case msg := <-ps.Channel():
+		if !strings.Contains(msg.Payload, `"changed"`) {
+			t.Fatalf("first invalidation should come from Delete, got %s", msg.Payload)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no invalidation published for Delete")
+	}
+}
+
+func TestTiered_WithoutPubSub(t *testing.T) {
+	l2 := NewMemory(time.Minute)
+	s := NewTiered(NewMemory(time.Minute), l2, 10*time.Millisecond, nil)
+	defer s.Close()
+
+	s.Set("k", []byte("v"), time.Minute)
+	l2.Set("k", []byte("v2"), time.Minute)
+	if v, _ := s.Get("k"); string(v) != "v" {
+		t.Fatalf("L1 hit expected, got %q", v)
+	}
+	time.Sleep(20 * time.Millisecond) // L1 TTL pendek habis → baca L2
+	if v, _ := s.Get("k"); string(v) != "v2" {
+		t.Fatalf("L2 read expected, got %q", v)
+	}
+}
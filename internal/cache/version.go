@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+)
+
+// Namespace meng-cache hasil query (mis. list) per bentuk query dan
+// membuangnya sekaligus lewat counter versi di Redis: key memuat versi saat
+// ini, Bump cukup INCR sehingga key lama tidak pernah dibaca lagi dan habis
+// sendiri oleh TTL — tanpa SCAN/DEL.
+//
+// Scope memisahkan counter (biasanya tenant). Key versi sengaja di luar
+// prefix key data (cache:ver:*) dan tanpa TTL: bila counter hilang lalu
+// mulai lagi dari 0, entry versi lama yang masih hidup bisa terbaca.
+type Namespace struct {
+	rdb  redis.UniversalClient
+	name string // prefix key & label metrik resource
+	ser  *Serializer
+}
+
+func NewNamespace(rdb redis.UniversalClient, name string) *Namespace {
+	return &Namespace{rdb: rdb, name: name, ser: DefaultSerializer}
+}
+
+// WithSerializer: salinan n yang meng-encode value dengan s (counter versi
+// tetap sama).
+func (n *Namespace) WithSerializer(s *Serializer) *Namespace {
+	c := *n
+	c.ser = s
+	return &c
+}
+
+func (n *Namespace) versionKey(scope string) string {
+	return "cache:ver:" + n.name + ":" + scope
+}
+
+// Version: counter saat ini (0 bila belum pernah di-Bump).
+func (n *Namespace) Version(ctx context.Context, scope string) (int64, error) {
+	v, err := n.rdb.Get(ctx, n.versionKey(scope)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return v, err
+}
+
+// Bump membatalkan semua entry scope. Dipanggil SETELAH tulis ke DB: list
+// yang sempat dibaca sebelum tulis tersimpan di versi lama dan tidak
+// terbaca lagi.
+func (n *Namespace) Bump(ctx context.Context, scope string) {
+	if err := n.rdb.Incr(ctx, n.versionKey(scope)).Err(); err != nil {
+		// entry lama tetap terbaca sampai TTL-nya habis
+		warn("cache.namespace.bump_failed", err, zap.String("namespace", n.name), zap.String("scope", scope))
+	}
+}
+
+// Key: "cache:<name>:<scope>:v<version>:<hash shape>". shape di-encode JSON
+// (key map terurut) jadi harus deterministik untuk query yang sama.
+func (n *Namespace) Key(scope string, version int64, shape any) (string, error) {
+	b, err := json.Marshal(shape)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return "cache:" + n.name + ":" + scope + ":v" + strconv.FormatInt(version, 10) + ":" + hex.EncodeToString(sum[:16]), nil
+}
+
+// Cached: cache-aside untuk n. Redis bermasalah → langsung load (tanpa
+// cache); hasil load tidak pernah di-cache bila error. Value yang tidak bisa
+// di-decode (schema/format lain) = miss dan ditimpa.
+func Cached[T any](ctx context.Context, n *Namespace, scope string, shape any, ttl time.Duration, load func(context.Context) (T, error)) (T, error) {
+	ver, err := n.Version(ctx, scope)
+	if err != nil {
+		return load(ctx)
+	}
+	k, err := n.Key(scope, ver, shape)
+	if err != nil {
+		return load(ctx)
+	}
+	if b, err := n.rdb.Get(ctx, k).Bytes(); err == nil {
+		var v T
+		if n.ser.Decode(b, &v) == nil {
+			httpx.CacheHit.WithLabelValues(n.name).Inc()
+			return v, nil
+		}
+	}
+	httpx.CacheMiss.WithLabelValues(n.name).Inc()
+
+	v, err := load(ctx)
+	if err != nil {
+		return v, err
+	}
+	if b, err := n.ser.Encode(v); err == nil && ttl > 0 {
+		_ = n.rdb.Set(ctx, k, b, ttl).Err()
+	}
+	return v, nil
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestNamespace_VersionedInvalidation(t *testing.T) {
+	rdb := newMiniRedis(t)
+	ns := NewNamespace(rdb, "things")
+	ctx := context.Background()
+
+	calls := 0
+	load := func(context.Context) ([]string, error) {
+		calls++
+		return []string{"v", string(rune('0' + calls))}, nil
+	}
+	get := func(scope string, shape any) []string {
+		t.Helper()
+		v, err := Cached(ctx, ns, scope, shape, time.Minute, load)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	// map di-encode terurut → bentuk query sama = key sama
+	get("t1", map[string]any{"a": 1, "b": 2})
+	get("t1", map[string]any{"b": 2, "a": 1})
+	if calls != 1 {
+		t.Fatalf("loads = %d, want 1", calls)
+	}
+	get("t1", map[string]any{"a": 2})
+	get("t2", map[string]any{"a": 1, "b": 2})
+	if calls != 3 {
+		t.Fatalf("loads = %d, want 3 (other shape, other scope)", calls)
+	}
+
+	ns.Bump(ctx, "t1")
+	if v := get("t1", map[string]any{"a": 1, "b": 2}); v[1] != "4" {
+		t.Fatalf("after bump got %v, want fresh load", v)
+	}
+	get("t2", map[string]any{"a": 1, "b": 2})
+	if calls != 4 {
+		t.Fatalf("bump leaked into other scope: loads = %d", calls)
+	}
+	if ver, _ := ns.Version(ctx, "t1"); ver != 1 {
+		t.Fatalf("version = %d", ver)
+	}
+	if ttl := rdb.TTL(ctx, ns.versionKey("t1")).Val(); ttl != -1 {
+		t.Fatalf("version key must not expire (ttl %v)", ttl)
+	}
+}
+
+func TestNamespace_RedisDownAndLoadError(t *testing.T) {
+	rdb := newMiniRedis(t)
+	ns := NewNamespace(rdb, "things")
+	ctx := context.Background()
+
+	boom := errors.New("boom")
+	if _, err := Cached(ctx, ns, "s", 1, time.Minute, func(context.Context) (int, error) { return 0, boom }); !errors.Is(err, boom) {
+		t.Fatalf("err = %v", err)
+	}
+	// error tidak di-cache
+	if v, _ := Cached(ctx, ns, "s", 1, time.Minute, func(context.Context) (int, error) { return 7, nil }); v != 7 {
+		t.Fatalf("v = %d", v)
+	}
+
+	dead := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer dead.Close()
+	down := NewNamespace(dead, "things")
+	for i := range 2 {
+		if v, err := Cached(ctx, down, "s", 1, time.Minute, func(context.Context) (int, error) { return i, nil }); err != nil || v != i {
+			t.Fatalf("redis down: v=%d err=%v, want direct load", v, err)
+		}
+	}
+	down.Bump(ctx, "s") // hanya log
+}
@@ -1,56 +1,227 @@
 package config
 
 import (
-	"fmt"
-	"os"
+	"time"
+
+	"github.com/Quineeryn/go-backend-101/internal/db"
 )
 
+// Config = seluruh konfigurasi runtime. Tiap field bisa diisi dari (urutan
+// prioritas naik): default → file YAML/TOML → env → flag. Lihat Load.
+//
+// Tag:
+//   - yaml    : path di file & nama flag (--db.max_open_conns)
+//   - env     : nama environment variable
+//   - default : nilai awal
+//   - secret  : "true" = disensor di --print-config; "dsn" = hanya password di DSN
 type Config struct {
-	Port  string
-	Env   string
-	DBDSN string
-
-	RedisAddr     string
-	RedisPassword string
-	RedisDB       int
-
-	// Logging
-	LogFilePath   string
-	LogMaxSizeMB  int
-	LogMaxBackups int
-	LogMaxAgeDays int
-}
-
-func FromEnv() Config {
-	return Config{
-		Port:          getEnv("PORT", "8080"),
-		Env:           getEnv("APP_ENV", "development"),
-		DBDSN:         getEnv("DB_DSN", ""),
-		LogFilePath:   getEnv("LOG_FILE", "./logs/app.log"),
-		LogMaxSizeMB:  getEnvInt("LOG_MAX_SIZE", 10),
-		LogMaxBackups: getEnvInt("LOG_MAX_BACKUPS", 5),
-		LogMaxAgeDays: getEnvInt("LOG_MAX_AGE", 30),
-
-		RedisAddr:     getEnv("REDIS_ADDR", "127.0.0.1:6379"),
-		RedisPassword: getEnv("REDIS_PASSWORD", ""),
-		RedisDB:       getEnvInt("REDIS_DB", 0),
-	}
+	Env string `yaml:"env" env:"APP_ENV" default:"development" help:"development | staging | production"`
+	// interval poll file --config untuk hot reload (rate_limit, cache TTL, log.level)
+	ReloadInterval time.Duration `yaml:"reload_interval" env:"CONFIG_RELOAD_INTERVAL" default:"5s" help:"poll --config file for runtime settings; 0 = SIGHUP only"`
+
+	Server    Server    `yaml:"server"`
+	DB        DB        `yaml:"db"`
+	Redis     Redis     `yaml:"redis"`
+	Log       Log       `yaml:"log"`
+	JWT       JWT       `yaml:"jwt"`
+	RateLimit RateLimit `yaml:"rate_limit"`
+	Cache     Cache     `yaml:"cache"`
+	Idem      Idem      `yaml:"idempotency"`
+	Webhooks  Webhooks  `yaml:"webhooks"`
+	Jobs      Jobs      `yaml:"jobs"`
+	Health    Health    `yaml:"health"`
+	Tenant    Tenant    `yaml:"tenant"`
+	Storage   Storage   `yaml:"storage"`
+	Avatar    Avatar    `yaml:"avatar"`
 }
 
-func getEnv(k, def string) string {
-	if v, ok := os.LookupEnv(k); ok && v != "" {
-		return v
-	}
-	return def
+type Server struct {
+	Port              string        `yaml:"port" env:"PORT" default:"8080"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" default:"5s"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT" default:"10s"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" default:"10s"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" default:"60s"`
+	RequestTimeout    time.Duration `yaml:"request_timeout" env:"SERVER_REQUEST_TIMEOUT" default:"60s" help:"context timeout per request"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"10s"`
+	DrainDelay        time.Duration `yaml:"drain_delay" env:"SHUTDOWN_DRAIN_DELAY" default:"0s" help:"wait after /readyz turns 503 before shutdown"`
+}
+
+type DB struct {
+	DSN             string        `yaml:"dsn" env:"DB_DSN" default:"var/app.db" secret:"dsn" help:"postgres URL/key=value DSN or SQLite file"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS" default:"30"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" default:"10"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" default:"30m"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" default:"0s"`
+	ConnectTimeout  time.Duration `yaml:"connect_timeout" env:"DB_CONNECT_TIMEOUT" default:"5s" help:"per startup ping"`
+	ConnectRetries  int           `yaml:"connect_retries" env:"DB_CONNECT_RETRIES" default:"5" help:"startup ping retries (exponential backoff)"`
+	ConnectBackoff  time.Duration `yaml:"connect_backoff" env:"DB_CONNECT_BACKOFF" default:"500ms"`
+	SQLitePragmas   []string      `yaml:"sqlite_pragmas" env:"DB_SQLITE_PRAGMAS" default:"foreign_keys=ON,journal_mode=WAL,busy_timeout=5000" help:"name=value, applied to every SQLite connection"`
+	// read replica: List/Get/FindByEmail/FindByID users dibaca dari sini
+	ReplicaDSNs          []string      `yaml:"replicas" env:"DB_REPLICA_DSNS" secret:"dsn" help:"comma-separated read replica DSNs (same dialect as db.dsn)"`
+	ReplicaMaxLag        time.Duration `yaml:"replica_max_lag" env:"DB_REPLICA_MAX_LAG" default:"5s" help:"replicas lagging more are taken out of rotation"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" env:"DB_REPLICA_CHECK_INTERVAL" default:"5s"`
+	AutoMigrate          bool          `yaml:"auto_migrate" env:"AUTO_MIGRATE" default:"false" help:"run migrations on boot"`
+	SchemaGuard          string        `yaml:"schema_guard" env:"SCHEMA_GUARD" default:"strict" help:"strict | readiness | off"`
+}
+
+type Redis struct {
+	Mode             string   `yaml:"mode" env:"REDIS_MODE" default:"standalone" help:"standalone | sentinel | cluster"`
+	Addr             string   `yaml:"addr" env:"REDIS_ADDR" default:"127.0.0.1:6379"`
+	Addrs            []string `yaml:"addrs" env:"REDIS_ADDRS" help:"sentinel/cluster seed addresses (empty: addr)"`
+	MasterName       string   `yaml:"master_name" env:"REDIS_MASTER_NAME" help:"sentinel master name"`
+	Password         string   `yaml:"password" env:"REDIS_PASSWORD" secret:"true"`
+	SentinelPassword string   `yaml:"sentinel_password" env:"REDIS_SENTINEL_PASSWORD" secret:"true"`
+	DB               int      `yaml:"db" env:"REDIS_DB" default:"0"`
+
+	DialTimeout  time.Duration `yaml:"dial_timeout" env:"REDIS_DIAL_TIMEOUT" default:"500ms"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" env:"REDIS_READ_TIMEOUT" default:"500ms"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"REDIS_WRITE_TIMEOUT" default:"500ms"`
+	// circuit breaker: open setelah N gagal berturut-turut, probe tiap cooldown
+	BreakerThreshold int           `yaml:"breaker_threshold" env:"REDIS_BREAKER_THRESHOLD" default:"5"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown" env:"REDIS_BREAKER_COOLDOWN" default:"5s"`
+
+	FailMode RedisFailMode `yaml:"fail_mode"`
+}
+
+// RedisFailMode: perilaku tiap konsumen saat Redis tidak tersedia. Cache
+// selalu fail-open (miss → DB).
+type RedisFailMode struct {
+	RateLimit   string `yaml:"rate_limit" env:"REDIS_FAIL_RATE_LIMIT" default:"local" help:"open | closed | local (per-replica buckets)"`
+	Idempotency string `yaml:"idempotency" env:"REDIS_FAIL_IDEMPOTENCY" default:"local" help:"open | closed | local (per-replica memory)"`
+	Jobs        string `yaml:"jobs" env:"REDIS_FAIL_JOBS" default:"local" help:"closed (skip tick) | local (in-process lock)"`
+}
+
+type Log struct {
+	Level      string `yaml:"level" env:"LOG_LEVEL" help:"debug | info | warn | error (empty: debug in dev, else info)"`
+	FilePath   string `yaml:"file" env:"LOG_FILE" default:"./logs/app.log"`
+	MaxSizeMB  int    `yaml:"max_size_mb" env:"LOG_MAX_SIZE" default:"10"`
+	MaxBackups int    `yaml:"max_backups" env:"LOG_MAX_BACKUPS" default:"5"`
+	MaxAgeDays int    `yaml:"max_age_days" env:"LOG_MAX_AGE" default:"30"`
+}
+
+type JWT struct {
+	Secret     string        `yaml:"secret" env:"JWT_SECRET" default:"dev-secret-change-me" secret:"true"`
+	AccessTTL  time.Duration `yaml:"access_ttl" env:"JWT_ACCESS_TTL" default:"15m"`
+	RefreshTTL time.Duration `yaml:"refresh_ttl" env:"JWT_REFRESH_TTL" default:"168h"`
+}
+
+type RateLimit struct {
+	DefaultRPS   float64 `yaml:"default_rps" env:"RATE_LIMIT_DEFAULT_RPS" default:"2"`
+	DefaultBurst int     `yaml:"default_burst" env:"RATE_LIMIT_DEFAULT_BURST" default:"10"`
+	AuthRPS      float64 `yaml:"auth_rps" env:"RATE_LIMIT_AUTH_RPS" default:"0.2"`
+	AuthBurst    int     `yaml:"auth_burst" env:"RATE_LIMIT_AUTH_BURST" default:"5"`
+
+	DefaultAlgorithm string `yaml:"default_algorithm" env:"RATE_LIMIT_DEFAULT_ALGORITHM" default:"token_bucket" help:"token_bucket | sliding_log | sliding_window | gcra"`
+	AuthAlgorithm    string `yaml:"auth_algorithm" env:"RATE_LIMIT_AUTH_ALGORITHM" default:"token_bucket" help:"token_bucket | sliding_log | sliding_window | gcra"`
+
+	// file policy per route/user/plan; bila diisi, nilai default_*/auth_*
+	// di atas tidak dipakai
+	PolicyFile string `yaml:"policy_file" env:"RATE_LIMIT_POLICY_FILE" help:"YAML policy file (routes, keys, plans, stacked limits); empty = built-in default/auth policies"`
+}
+
+type Cache struct {
+	UsersTTL time.Duration `yaml:"users_ttl" env:"USERS_CACHE_TTL" default:"5m"`
+	MeTTL    time.Duration `yaml:"me_ttl" env:"ME_CACHE_TTL" default:"30s"`
+	// stampede protection cache user (users.CacheOptions); 0 = mati
+	UsersStaleTTL    time.Duration `yaml:"users_stale_ttl" env:"USERS_CACHE_STALE_TTL" default:"1m" help:"serve expired entries this long while one request refreshes"`
+	UsersNegativeTTL time.Duration `yaml:"users_negative_ttl" env:"USERS_CACHE_NEGATIVE_TTL" default:"30s" help:"cache not-found lookups"`
+	UsersEarlyBeta   float64       `yaml:"users_early_refresh_beta" env:"USERS_CACHE_EARLY_REFRESH_BETA" default:"1" help:"probabilistic early refresh (XFetch beta)"`
+	UsersListTTL     time.Duration `yaml:"users_list_ttl" env:"USERS_LIST_CACHE_TTL" default:"1m" help:"cache list results per filter; invalidated by any user write"`
+	UsersLock        bool          `yaml:"users_lock" env:"USERS_CACHE_LOCK" default:"true" help:"Redis lock so only one replica reloads a missing key"`
+	MemoryGC         time.Duration `yaml:"memory_gc_interval" env:"CACHE_MEMORY_GC_INTERVAL" default:"5m"`
+	// batas per instance cache in-process (LRU); dibagi rata ke shard
+	MemoryMaxEntries int `yaml:"memory_max_entries" env:"CACHE_MEMORY_MAX_ENTRIES" default:"100000"`
+	MemoryMaxMB      int `yaml:"memory_max_mb" env:"CACHE_MEMORY_MAX_MB" default:"64" help:"key + value + per-entry overhead"`
+	// L1 in-process di depan Redis untuk response cache; invalidasi via pub/sub
+	L1TTL time.Duration `yaml:"l1_ttl" env:"CACHE_L1_TTL" default:"5s" help:"in-process tier in front of Redis (upper bound of staleness if pub/sub is lost)"`
+	// serialisasi value cache user di Redis (cache.Serializer)
+	Codec            string `yaml:"codec" env:"CACHE_CODEC" default:"json" help:"json | msgpack | protobuf"`
+	Compression      string `yaml:"compression" env:"CACHE_COMPRESSION" default:"none" help:"none | zstd | snappy"`
+	CompressMinBytes int    `yaml:"compress_min_bytes" env:"CACHE_COMPRESS_MIN_BYTES" default:"1024" help:"values smaller than this are stored uncompressed"`
+}
+
+type Idem struct {
+	TTL          time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" default:"24h"`
+	MaxBodyBytes int           `yaml:"max_body_bytes" env:"IDEMPOTENCY_MAX_BODY_BYTES" default:"1048576" help:"larger bodies with Idempotency-Key get 413"`
+}
+
+type Webhooks struct {
+	Workers     int           `yaml:"workers" env:"WEBHOOK_WORKERS" default:"4"`
+	MaxAttempts int           `yaml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" default:"8"`
+	BaseBackoff time.Duration `yaml:"base_backoff" env:"WEBHOOK_BASE_BACKOFF" default:"10s"`
+	MaxBackoff  time.Duration `yaml:"max_backoff" env:"WEBHOOK_MAX_BACKOFF" default:"1h"`
+}
+
+type Jobs struct {
+	Enabled                bool          `yaml:"enabled" env:"JOBS_ENABLED" default:"true"`
+	Retention              time.Duration `yaml:"retention" env:"JOBS_RETENTION" default:"720h"`
+	PurgeTokensSchedule    string        `yaml:"purge_tokens_schedule" env:"JOB_PURGE_TOKENS_SCHEDULE" default:"@every 1h"`
+	PurgeRetentionSchedule string        `yaml:"purge_retention_schedule" env:"JOB_PURGE_RETENTION_SCHEDULE" default:"@daily"`
+	RotateCacheSchedule    string        `yaml:"rotate_cache_schedule" env:"JOB_ROTATE_CACHE_SCHEDULE" default:"@every 10m"`
+}
+
+type Health struct {
+	Timeout       time.Duration `yaml:"timeout" env:"HEALTH_TIMEOUT" default:"2s"`
+	DBTimeout     time.Duration `yaml:"db_timeout" env:"HEALTH_DB_TIMEOUT" default:"1s"`
+	DiskMinFreeMB int           `yaml:"disk_min_free_mb" env:"HEALTH_DISK_MIN_FREE_MB" default:"100"`
+}
+
+// Tenant: cara request memilih tenant (header > subdomain > default).
+type Tenant struct {
+	Header     string        `yaml:"header" env:"TENANT_HEADER" default:"X-Tenant-ID" help:"header carrying tenant slug or id"`
+	BaseDomain string        `yaml:"base_domain" env:"TENANT_BASE_DOMAIN" help:"acme.<base_domain> resolves tenant acme; empty = off"`
+	Default    string        `yaml:"default" env:"TENANT_DEFAULT" default:"default" help:"tenant when none is given; empty = tenant required"`
+	CacheTTL   time.Duration `yaml:"cache_ttl" env:"TENANT_CACHE_TTL" default:"1m"`
+}
+
+// Storage: tempat blob (avatar). local = direktori, disajikan di /media
+// kalau public_url kosong; s3 = bucket S3-compatible (AWS, MinIO, R2).
+type Storage struct {
+	Driver    string `yaml:"driver" env:"STORAGE_DRIVER" default:"local" help:"local | s3"`
+	LocalDir  string `yaml:"local_dir" env:"STORAGE_LOCAL_DIR" default:"var/media"`
+	PublicURL string `yaml:"public_url" env:"STORAGE_PUBLIC_URL" help:"base URL of stored objects (CDN); empty = /media (local) or the bucket URL (s3)"`
+	S3        S3     `yaml:"s3"`
+}
+
+type S3 struct {
+	Endpoint  string `yaml:"endpoint" env:"S3_ENDPOINT" help:"e.g. https://s3.eu-west-1.amazonaws.com or http://127.0.0.1:9000"`
+	Region    string `yaml:"region" env:"S3_REGION" default:"us-east-1"`
+	Bucket    string `yaml:"bucket" env:"S3_BUCKET"`
+	AccessKey string `yaml:"access_key" env:"S3_ACCESS_KEY"`
+	SecretKey string `yaml:"secret_key" env:"S3_SECRET_KEY" secret:"true"`
+}
+
+type Avatar struct {
+	MaxBytes  int `yaml:"max_bytes" env:"AVATAR_MAX_BYTES" default:"5242880" help:"upload size limit"`
+	MaxPixels int `yaml:"max_pixels" env:"AVATAR_MAX_PIXELS" default:"40000000" help:"width*height limit (decompression bombs)"`
+}
+
+// IsSQLite: DSN bukan Postgres (lihat db.ParseDSN).
+func (c DB) IsSQLite() bool {
+	d, err := db.ParseDSN(c.DSN)
+	return err == nil && d.Dialect == db.SQLite
+}
+
+// ReplicaOptions: sama dengan Options tapi untuk DSN replica (tanpa ping
+// saat boot; replica yang down masuk rotasi setelah pulih).
+func (c DB) ReplicaOptions(dsn string) db.Options {
+	o := c.Options()
+	o.DSN = dsn
+	o.Lazy = true
+	return o
 }
 
-func getEnvInt(k string, def int) int {
-	if v, ok := os.LookupEnv(k); ok && v != "" {
-		var out int
-		_, err := fmt.Sscanf(v, "%d", &out)
-		if err == nil {
-			return out
-		}
+// Options untuk db.Open.
+func (c DB) Options() db.Options {
+	return db.Options{
+		DSN:             c.DSN,
+		MaxOpenConns:    c.MaxOpenConns,
+		MaxIdleConns:    c.MaxIdleConns,
+		ConnMaxLifetime: c.ConnMaxLifetime,
+		ConnMaxIdleTime: c.ConnMaxIdleTime,
+		SQLitePragmas:   c.SQLitePragmas,
+		ConnectTimeout:  c.ConnectTimeout,
+		ConnectRetries:  c.ConnectRetries,
+		ConnectBackoff:  c.ConnectBackoff,
 	}
-	return def
 }
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, body string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatalf("defaults should be valid: %v", err)
+	}
+	if cfg.Server.Port != "8080" || cfg.JWT.AccessTTL != 15*time.Minute || cfg.RateLimit.AuthRPS != 0.2 || !cfg.Jobs.Enabled {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+}
+
+func TestLoad_Precedence_FileEnvFlag(t *testing.T) {
+	file := writeFile(t, "app.yaml", `
+server:
+  port: "9000"
+jwt:
+  access_ttl: 30m
+  refresh_ttl: 48h
+rate_limit:
+  default_rps: 5
+`)
+	t.Setenv("JWT_ACCESS_TTL", "20m")
+
+	cfg, opt, err := Load([]string{"--config", file, "--jwt.access_ttl=10m", "--jobs.enabled=false"})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if opt.File != file {
+		t.Fatalf("options file = %q", opt.File)
+	}
+	if cfg.Server.Port != "9000" || cfg.RateLimit.DefaultRPS != 5 || cfg.JWT.RefreshTTL != 48*time.Hour {
+		t.Fatalf("file values not applied: %+v", cfg)
+	}
+	if cfg.JWT.AccessTTL != 10*time.Minute {
+		t.Fatalf("flag should win over env & file, got %s", cfg.JWT.AccessTTL)
+	}
+	if cfg.Jobs.Enabled {
+		t.Fatal("bool flag not applied")
+	}
+
+	// tanpa flag: env menang atas file
+	cfg, _, _ = Load([]string{"--config", file})
+	if cfg.JWT.AccessTTL != 20*time.Minute {
+		t.Fatalf("env should win over file, got %s", cfg.JWT.AccessTTL)
+	}
+}
+
+func TestLoad_TOML(t *testing.T) {
+	file := writeFile(t, "app.toml", `
+env = "staging"
+[db]
+max_open_conns = 50
+[webhooks]
+max_backoff = "2h"
+`)
+	t.Setenv("CONFIG_FILE", file)
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Env != "staging" || cfg.DB.MaxOpenConns != 50 || cfg.Webhooks.MaxBackoff != 2*time.Hour {
+		t.Fatalf("toml not applied: %+v", cfg)
+	}
+}
+
+func TestLoad_ReportsAllErrors(t *testing.T) {
+	file := writeFile(t, "app.yaml", "jwt:\n  acces_ttl: 1m\n")
+	t.Setenv("JWT_ACCESS_TTL", "15x")
+	t.Setenv("RATE_LIMIT_DEFAULT_BURST", "ten")
+	t.Setenv("DB_MAX_IDLE_CONNS", "99")
+	t.Setenv("JOB_PURGE_RETENTION_SCHEDULE", "sometimes")
+
+	_, _, err := Load([]string{"--config", file})
+	var cerr *Error
+	if !errors.As(err, &cerr) {
+		t.Fatalf("want *config.Error, got %v", err)
+	}
+	msg := err.Error()
+	for _, want := range []string{
+		`jwt.access_ttl (env JWT_ACCESS_TTL): invalid duration "15x"`,
+		`rate_limit.default_burst (env RATE_LIMIT_DEFAULT_BURST): invalid integer "ten"`,
+		"jwt.acces_ttl (file " + file + "): unknown key",
+		"db.max_idle_conns: must be between 0 and max_open_conns",
+		"jobs.purge_retention_schedule:",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error list missing %q\n%s", want, msg)
+		}
+	}
+}
+
+func TestPrint_RedactsSecrets(t *testing.T) {
+	t.Setenv("JWT_SECRET", "super-secret-value")
+	t.Setenv("REDIS_PASSWORD", "redis-pass")
+	t.Setenv("DB_DSN", "postgres://app:db-pass@db:5432/app?sslmode=require")
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := cfg.Print(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, leak := range []string{"super-secret-value", "redis-pass", "db-pass"} {
+		if strings.Contains(out, leak) {
+			t.Fatalf("secret %q leaked:\n%s", leak, out)
+		}
+	}
+	if !strings.Contains(out, "postgres://app:***@db:5432/app?sslmode=require") || !strings.Contains(out, "access_ttl: 15m0s") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+func TestRedactDSN_KeyValue(t *testing.T) {
+	got := RedactDSN("host=127.0.0.1 user=postgres password=canandra10 dbname=x sslmode=disable")
+	if got != "host=127.0.0.1 user=postgres password=*** dbname=x sslmode=disable" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a, _, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := a
+	b.Cache.MeTTL = time.Minute
+	b.JWT.Secret = "other"
+	got := Diff(a, b)
+	if len(got) != 2 || got[0] != "jwt.secret" || got[1] != "cache.me_ttl" {
+		t.Fatalf("diff = %v", got)
+	}
+	if v := b.Values("jwt"); v["jwt.secret"] != "***" || len(v) != 3 {
+		t.Fatalf("values = %v", v)
+	}
+}
+
+func TestLoad_InvalidDSN(t *testing.T) {
+	t.Setenv("DB_DSN", "mysql://u@h/db")
+	t.Setenv("DB_SQLITE_PRAGMAS", "foreign_keys=ON;x")
+	_, _, err := Load(nil)
+	if err == nil || !strings.Contains(err.Error(), `db.dsn: unsupported DSN scheme "mysql"`) || !strings.Contains(err.Error(), "db.sqlite_pragmas:") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestLoad_InvalidRedis(t *testing.T) {
+	t.Setenv("REDIS_MODE", "sentinel")
+	t.Setenv("REDIS_FAIL_RATE_LIMIT", "maybe")
+	t.Setenv("REDIS_FAIL_JOBS", "open")
+	_, _, err := Load(nil)
+	if err == nil {
+		t.Fatal("want error")
+	}
+	for _, want := range []string{"redis.master_name:", "redis.fail_mode.rate_limit:", "redis.fail_mode.jobs:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error list missing %q\n%s", want, err)
+		}
+	}
+}
+
+func TestLoad_InvalidCacheCodec(t *testing.T) {
+	t.Setenv("CACHE_CODEC", "xml")
+	t.Setenv("CACHE_COMPRESSION", "lz4")
+	_, _, err := Load(nil)
+	if err == nil || !strings.Contains(err.Error(), "cache.codec:") || !strings.Contains(err.Error(), "cache.compression:") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestLoad_InvalidRateLimitAlgorithm(t *testing.T) {
+	t.Setenv("RATE_LIMIT_AUTH_ALGORITHM", "leaky_bucket")
+	_, _, err := Load(nil)
+	if err == nil || !strings.Contains(err.Error(), "rate_limit.auth_algorithm:") || strings.Contains(err.Error(), "rate_limit.default_algorithm:") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestLoad_InvalidRateLimitPolicyFile(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "policies.yaml")
+	if err := os.WriteFile(f, []byte("policies:\n  - name: api\n    routes: [{path: /v1/*}]\n    key: session\n    limits: [{rps: 1, burst: 1}]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("RATE_LIMIT_POLICY_FILE", f)
+	_, _, err := Load(nil)
+	if err == nil || !strings.Contains(err.Error(), "rate_limit.policy_file:") || !strings.Contains(err.Error(), "policies[0].key:") {
+		t.Fatalf("got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Diff mengembalikan path field yang nilainya beda antara a dan b, urut
+// sesuai deklarasi struct. Secret ikut dibandingkan (nilai aslinya).
+func Diff(a, b Config) []string {
+	fa := collect(reflect.ValueOf(&a).Elem(), "")
+	fb := collect(reflect.ValueOf(&b).Elem(), "")
+	var out []string
+	for i := range fa {
+		if !reflect.DeepEqual(fa[i].val.Interface(), fb[i].val.Interface()) {
+			out = append(out, fa[i].path)
+		}
+	}
+	return out
+}
+
+// Values mengembalikan path → nilai tampilan (format sama dengan Print,
+// secret disensor) untuk field yang path-nya diawali salah satu prefix.
+// Tanpa prefix = semua field.
+func (c Config) Values(prefixes ...string) map[string]any {
+	out := map[string]any{}
+	for _, f := range collect(reflect.ValueOf(&c).Elem(), "") {
+		if len(prefixes) == 0 || HasPrefix(f.path, prefixes...) {
+			out[f.path] = f.display()
+		}
+	}
+	return out
+}
+
+// HasPrefix: path sama dengan prefix atau berada di bawahnya
+// ("rate_limit" cocok dengan "rate_limit.auth_rps").
+func HasPrefix(path string, prefixes ...string) bool {
+	for _, p := range prefixes {
+		if path == p || strings.HasPrefix(path, p+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,283 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Options = flag meta yang bukan bagian dari Config.
+type Options struct {
+	File        string // --config / CONFIG_FILE
+	PrintConfig bool   // --print-config
+}
+
+// field = satu leaf Config hasil refleksi.
+type field struct {
+	path   string // "jwt.access_ttl"
+	env    string
+	def    string
+	secret string
+	help   string
+	val    reflect.Value
+}
+
+// Load membaca konfigurasi dengan prioritas: default < file < env < flag.
+// args = os.Args[1:] (nil untuk tanpa flag). Semua kesalahan parse &
+// validasi dikumpulkan lalu dikembalikan sekaligus.
+func Load(args []string) (Config, Options, error) {
+	var cfg Config
+	var opt Options
+	fields := collect(reflect.ValueOf(&cfg).Elem(), "")
+
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&opt.File, "config", "", "YAML/TOML config file (env CONFIG_FILE)")
+	fs.BoolVar(&opt.PrintConfig, "print-config", false, "print effective config (secrets redacted) and exit")
+	fromFlags := map[string]string{}
+	for _, f := range fields {
+		path := f.path
+		set := func(s string) error { fromFlags[path] = s; return nil }
+		if f.val.Kind() == reflect.Bool {
+			fs.BoolFunc(path, f.usage(), set)
+		} else {
+			fs.Func(path, f.usage(), set)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fs.SetOutput(os.Stderr)
+			fs.PrintDefaults()
+		}
+		return cfg, opt, err
+	}
+	if opt.File == "" {
+		opt.File = os.Getenv("CONFIG_FILE")
+	}
+
+	var errs []error
+	for _, f := range fields {
+		if f.def != "" {
+			if err := setValue(f.val, f.def); err != nil {
+				errs = append(errs, fmt.Errorf("%s: bad default %q: %w", f.path, f.def, err))
+			}
+		}
+	}
+
+	if opt.File != "" {
+		fromFile, err := readFile(opt.File)
+		if err != nil {
+			return cfg, opt, err
+		}
+		known := map[string]bool{}
+		for _, f := range fields {
+			known[f.path] = true
+			if raw, ok := fromFile[f.path]; ok {
+				if err := setValue(f.val, raw); err != nil {
+					errs = append(errs, fmt.Errorf("%s (file %s): %w", f.path, opt.File, err))
+				}
+			}
+		}
+		for _, k := range sortedKeys(fromFile) {
+			if !known[k] {
+				errs = append(errs, fmt.Errorf("%s (file %s): unknown key", k, opt.File))
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if raw, ok := os.LookupEnv(f.env); ok && raw != "" {
+			if err := setValue(f.val, raw); err != nil {
+				errs = append(errs, fmt.Errorf("%s (env %s): %w", f.path, f.env, err))
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if raw, ok := fromFlags[f.path]; ok {
+			if err := setValue(f.val, raw); err != nil {
+				errs = append(errs, fmt.Errorf("%s (flag --%s): %w", f.path, f.path, err))
+			}
+		}
+	}
+
+	errs = append(errs, cfg.Validate()...)
+	if len(errs) > 0 {
+		return cfg, opt, &Error{Errs: errs}
+	}
+	return cfg, opt, nil
+}
+
+// Error mengumpulkan semua kesalahan konfigurasi.
+type Error struct{ Errs []error }
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid configuration (%d errors):", len(e.Errs))
+	for _, err := range e.Errs {
+		b.WriteString("\n  - " + err.Error())
+	}
+	return b.String()
+}
+
+func (e *Error) Unwrap() []error { return e.Errs }
+
+func (f field) usage() string {
+	u := f.help
+	if f.env != "" {
+		if u != "" {
+			u += " "
+		}
+		u += "(env " + f.env + ")"
+	}
+	return u
+}
+
+func collect(v reflect.Value, prefix string) []field {
+	var out []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && fv.Type() != durationType {
+			out = append(out, collect(fv, path)...)
+			continue
+		}
+		out = append(out, field{
+			path:   path,
+			env:    sf.Tag.Get("env"),
+			def:    sf.Tag.Get("default"),
+			secret: sf.Tag.Get("secret"),
+			help:   sf.Tag.Get("help"),
+			val:    fv,
+		})
+	}
+	return out
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setValue parse string ketat (tidak ada fallback diam-diam).
+func setValue(v reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var parts []string
+		for _, p := range strings.Split(raw, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				parts = append(parts, p)
+			}
+		}
+		v.Set(reflect.ValueOf(parts))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// readFile membaca YAML/TOML lalu meratakan jadi path → string.
+func readFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+	raw := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &raw)
+	case ".toml":
+		err = toml.Unmarshal(b, &raw)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported extension (use .yaml, .yml or .toml)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	out := map[string]string{}
+	flatten(raw, "", out)
+	return out, nil
+}
+
+func flatten(m map[string]any, prefix string, out map[string]string) {
+	for k, v := range m {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		switch x := v.(type) {
+		case map[string]any:
+			flatten(x, path, out)
+		case []any:
+			parts := make([]string, len(x))
+			for i, p := range x {
+				parts[i] = fmt.Sprint(p)
+			}
+			out[path] = strings.Join(parts, ",")
+		case nil:
+			// key kosong di YAML: abaikan
+		default:
+			out[path] = fmt.Sprint(x)
+		}
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// errorf helper untuk Validate.
+func errorf(path, format string, args ...any) error {
+	return errors.New(path + ": " + fmt.Sprintf(format, args...))
+}
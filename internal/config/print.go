@@ -0,0 +1,81 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "***"
+
+// Print menulis konfigurasi efektif sebagai YAML (format sama dengan file
+// config) dengan secret disensor.
+func (c Config) Print(w io.Writer) error {
+	root := map[string]any{}
+	for _, f := range collect(reflect.ValueOf(&c).Elem(), "") {
+		node := root
+		parts := strings.Split(f.path, ".")
+		for _, p := range parts[:len(parts)-1] {
+			child, ok := node[p].(map[string]any)
+			if !ok {
+				child = map[string]any{}
+				node[p] = child
+			}
+			node = child
+		}
+		node[parts[len(parts)-1]] = f.display()
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	defer enc.Close()
+	return enc.Encode(root)
+}
+
+func (f field) display() any {
+	if f.val.Type() == durationType {
+		return time.Duration(f.val.Int()).String()
+	}
+	switch f.secret {
+	case "true":
+		if f.val.String() != "" {
+			return redacted
+		}
+	case "dsn":
+		if f.val.Kind() == reflect.Slice {
+			out := make([]string, f.val.Len())
+			for i := range out {
+				out[i] = RedactDSN(f.val.Index(i).String())
+			}
+			return out
+		}
+		return RedactDSN(f.val.String())
+	}
+	return f.val.Interface()
+}
+
+var kvPassword = regexp.MustCompile(`(?i)(password=)('[^']*'|\S+)`)
+
+// RedactDSN menyensor password di DSN URL (postgres://u:p@h) maupun key=value.
+func RedactDSN(dsn string) string {
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return redacted
+		}
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+		q := u.Query()
+		if q.Has("password") {
+			q.Set("password", redacted)
+			u.RawQuery = q.Encode()
+		}
+		return strings.Replace(u.String(), url.QueryEscape(redacted), redacted, -1)
+	}
+	return kvPassword.ReplaceAllString(dsn, "${1}"+redacted)
+}
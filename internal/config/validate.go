@@ -0,0 +1,284 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/Quineeryn/go-backend-101/internal/db"
+	"github.com/Quineeryn/go-backend-101/internal/jobs"
+	"github.com/Quineeryn/go-backend-101/internal/ratelimit"
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
+)
+
+// Validate mengembalikan SEMUA pelanggaran, bukan berhenti di yang pertama.
+func (c Config) Validate() []error {
+	var errs []error
+	add := func(path, format string, args ...any) { errs = append(errs, errorf(path, format, args...)) }
+	positive := func(path string, d time.Duration) {
+		if d <= 0 {
+			add(path, "must be > 0 (got %s)", d)
+		}
+	}
+	nonNegative := func(path string, d time.Duration) {
+		if d < 0 {
+			add(path, "must be >= 0 (got %s)", d)
+		}
+	}
+
+	switch c.Env {
+	case "development", "dev", "staging", "production", "prod", "test":
+	default:
+		add("env", "unknown environment %q", c.Env)
+	}
+	nonNegative("reload_interval", c.ReloadInterval)
+
+	// server
+	if p := c.Server.Port; p == "" || strings.Trim(p, "0123456789") != "" {
+		add("server.port", "must be numeric (got %q)", p)
+	}
+	positive("server.read_header_timeout", c.Server.ReadHeaderTimeout)
+	positive("server.read_timeout", c.Server.ReadTimeout)
+	positive("server.write_timeout", c.Server.WriteTimeout)
+	positive("server.idle_timeout", c.Server.IdleTimeout)
+	positive("server.request_timeout", c.Server.RequestTimeout)
+	positive("server.shutdown_timeout", c.Server.ShutdownTimeout)
+	nonNegative("server.drain_delay", c.Server.DrainDelay)
+
+	// db
+	if strings.TrimSpace(c.DB.DSN) == "" {
+		add("db.dsn", "must not be empty")
+	} else if _, err := db.ParseDSN(c.DB.DSN); err != nil {
+		add("db.dsn", "%v", err)
+	}
+	if c.DB.MaxOpenConns < 1 {
+		add("db.max_open_conns", "must be >= 1 (got %d)", c.DB.MaxOpenConns)
+	}
+	if c.DB.MaxIdleConns < 0 || c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		add("db.max_idle_conns", "must be between 0 and max_open_conns (got %d)", c.DB.MaxIdleConns)
+	}
+	nonNegative("db.conn_max_lifetime", c.DB.ConnMaxLifetime)
+	nonNegative("db.conn_max_idle_time", c.DB.ConnMaxIdleTime)
+	positive("db.connect_timeout", c.DB.ConnectTimeout)
+	if c.DB.ConnectRetries < 0 {
+		add("db.connect_retries", "must be >= 0 (got %d)", c.DB.ConnectRetries)
+	}
+	nonNegative("db.connect_backoff", c.DB.ConnectBackoff)
+	if err := db.CheckPragmas(c.DB.SQLitePragmas); err != nil {
+		add("db.sqlite_pragmas", "%v", err)
+	}
+	primary, _ := db.ParseDSN(c.DB.DSN)
+	for i, dsn := range c.DB.ReplicaDSNs {
+		path := fmt.Sprintf("db.replicas[%d]", i)
+		if r, err := db.ParseDSN(dsn); err != nil {
+			add(path, "%v", err)
+		} else if primary.Dialect != "" && r.Dialect != primary.Dialect {
+			add(path, "dialect %s differs from db.dsn (%s)", r.Dialect, primary.Dialect)
+		}
+	}
+	nonNegative("db.replica_max_lag", c.DB.ReplicaMaxLag)
+	positive("db.replica_check_interval", c.DB.ReplicaCheckInterval)
+	switch c.DB.SchemaGuard {
+	case "strict", "readiness", "off":
+	default:
+		add("db.schema_guard", "must be strict, readiness or off (got %q)", c.DB.SchemaGuard)
+	}
+
+	// redis
+	switch c.Redis.Mode {
+	case "standalone", "cluster":
+	case "sentinel":
+		if c.Redis.MasterName == "" {
+			add("redis.master_name", "must be set when mode is sentinel")
+		}
+	default:
+		add("redis.mode", "must be standalone, sentinel or cluster (got %q)", c.Redis.Mode)
+	}
+	if c.Redis.Addr == "" && len(c.Redis.Addrs) == 0 {
+		add("redis.addr", "must not be empty")
+	}
+	if c.Redis.DB < 0 || c.Redis.DB > 15 {
+		add("redis.db", "must be between 0 and 15 (got %d)", c.Redis.DB)
+	}
+	positive("redis.dial_timeout", c.Redis.DialTimeout)
+	positive("redis.read_timeout", c.Redis.ReadTimeout)
+	positive("redis.write_timeout", c.Redis.WriteTimeout)
+	if c.Redis.BreakerThreshold < 1 {
+		add("redis.breaker_threshold", "must be >= 1 (got %d)", c.Redis.BreakerThreshold)
+	}
+	positive("redis.breaker_cooldown", c.Redis.BreakerCooldown)
+	for _, f := range []struct{ path, v string }{
+		{"redis.fail_mode.rate_limit", c.Redis.FailMode.RateLimit},
+		{"redis.fail_mode.idempotency", c.Redis.FailMode.Idempotency},
+	} {
+		if f.v != "open" && f.v != "closed" && f.v != "local" {
+			add(f.path, "must be open, closed or local (got %q)", f.v)
+		}
+	}
+	if m := c.Redis.FailMode.Jobs; m != "closed" && m != "local" {
+		add("redis.fail_mode.jobs", "must be closed or local (got %q)", m)
+	}
+
+	// log
+	if c.Log.MaxSizeMB < 0 || c.Log.MaxBackups < 0 || c.Log.MaxAgeDays < 0 {
+		add("log", "rotation sizes must be >= 0")
+	}
+	if c.Log.Level != "" {
+		if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+			add("log.level", "must be debug, info, warn or error (got %q)", c.Log.Level)
+		}
+	}
+
+	// jwt
+	if c.JWT.Secret == "" {
+		add("jwt.secret", "must not be empty")
+	}
+	positive("jwt.access_ttl", c.JWT.AccessTTL)
+	positive("jwt.refresh_ttl", c.JWT.RefreshTTL)
+	if c.JWT.AccessTTL > 0 && c.JWT.RefreshTTL > 0 && c.JWT.RefreshTTL <= c.JWT.AccessTTL {
+		add("jwt.refresh_ttl", "must be longer than access_ttl (%s <= %s)", c.JWT.RefreshTTL, c.JWT.AccessTTL)
+	}
+
+	// rate limit
+	if c.RateLimit.DefaultRPS <= 0 {
+		add("rate_limit.default_rps", "must be > 0 (got %v)", c.RateLimit.DefaultRPS)
+	}
+	if c.RateLimit.DefaultBurst < 1 {
+		add("rate_limit.default_burst", "must be >= 1 (got %d)", c.RateLimit.DefaultBurst)
+	}
+	if c.RateLimit.AuthRPS <= 0 {
+		add("rate_limit.auth_rps", "must be > 0 (got %v)", c.RateLimit.AuthRPS)
+	}
+	if c.RateLimit.AuthBurst < 1 {
+		add("rate_limit.auth_burst", "must be >= 1 (got %d)", c.RateLimit.AuthBurst)
+	}
+	for _, f := range []struct{ path, v string }{
+		{"rate_limit.default_algorithm", c.RateLimit.DefaultAlgorithm},
+		{"rate_limit.auth_algorithm", c.RateLimit.AuthAlgorithm},
+	} {
+		if _, err := ratelimit.ParseAlgorithm(f.v); err != nil {
+			add(f.path, "must be token_bucket, sliding_log, sliding_window or gcra (got %q)", f.v)
+		}
+	}
+	if f := c.RateLimit.PolicyFile; f != "" {
+		if _, err := ratelimit.LoadPolicyFile(f); err != nil {
+			add("rate_limit.policy_file", "%v", err)
+		}
+	}
+
+	// cache & idempotency
+	positive("cache.users_ttl", c.Cache.UsersTTL)
+	positive("cache.me_ttl", c.Cache.MeTTL)
+	positive("cache.memory_gc_interval", c.Cache.MemoryGC)
+	positive("cache.l1_ttl", c.Cache.L1TTL)
+	nonNegative("cache.users_stale_ttl", c.Cache.UsersStaleTTL)
+	nonNegative("cache.users_negative_ttl", c.Cache.UsersNegativeTTL)
+	nonNegative("cache.users_list_ttl", c.Cache.UsersListTTL)
+	if c.Cache.UsersEarlyBeta < 0 {
+		add("cache.users_early_refresh_beta", "must be >= 0 (got %g)", c.Cache.UsersEarlyBeta)
+	}
+	if c.Cache.MemoryMaxEntries < 1 {
+		add("cache.memory_max_entries", "must be >= 1 (got %d)", c.Cache.MemoryMaxEntries)
+	}
+	if c.Cache.MemoryMaxMB < 1 {
+		add("cache.memory_max_mb", "must be >= 1 (got %d)", c.Cache.MemoryMaxMB)
+	}
+	switch c.Cache.Codec {
+	case "json", "msgpack", "protobuf":
+	default:
+		add("cache.codec", "must be json, msgpack or protobuf (got %q)", c.Cache.Codec)
+	}
+	switch c.Cache.Compression {
+	case "none", "zstd", "snappy":
+	default:
+		add("cache.compression", "must be none, zstd or snappy (got %q)", c.Cache.Compression)
+	}
+	if c.Cache.CompressMinBytes < 1 {
+		add("cache.compress_min_bytes", "must be >= 1 (got %d)", c.Cache.CompressMinBytes)
+	}
+	positive("idempotency.ttl", c.Idem.TTL)
+	if c.Idem.MaxBodyBytes < 1 {
+		add("idempotency.max_body_bytes", "must be >= 1 (got %d)", c.Idem.MaxBodyBytes)
+	}
+
+	// webhooks
+	if c.Webhooks.Workers < 1 {
+		add("webhooks.workers", "must be >= 1 (got %d)", c.Webhooks.Workers)
+	}
+	if c.Webhooks.MaxAttempts < 1 {
+		add("webhooks.max_attempts", "must be >= 1 (got %d)", c.Webhooks.MaxAttempts)
+	}
+	positive("webhooks.base_backoff", c.Webhooks.BaseBackoff)
+	if c.Webhooks.MaxBackoff < c.Webhooks.BaseBackoff {
+		add("webhooks.max_backoff", "must be >= base_backoff (%s < %s)", c.Webhooks.MaxBackoff, c.Webhooks.BaseBackoff)
+	}
+
+	// jobs
+	positive("jobs.retention", c.Jobs.Retention)
+	for _, s := range []struct{ path, spec string }{
+		{"jobs.purge_tokens_schedule", c.Jobs.PurgeTokensSchedule},
+		{"jobs.purge_retention_schedule", c.Jobs.PurgeRetentionSchedule},
+		{"jobs.rotate_cache_schedule", c.Jobs.RotateCacheSchedule},
+	} {
+		if _, err := jobs.Parse(s.spec); err != nil {
+			add(s.path, "%v", err)
+		}
+	}
+
+	// tenant
+	if strings.TrimSpace(c.Tenant.Header) == "" {
+		add("tenant.header", "must not be empty")
+	}
+	if strings.HasPrefix(c.Tenant.BaseDomain, ".") || strings.Contains(c.Tenant.BaseDomain, ":") {
+		add("tenant.base_domain", "must be a bare domain without leading dot or port (got %q)", c.Tenant.BaseDomain)
+	}
+	if c.Tenant.Default != "" && !tenant.ValidSlug(c.Tenant.Default) {
+		add("tenant.default", "must be a tenant slug (got %q)", c.Tenant.Default)
+	}
+	positive("tenant.cache_ttl", c.Tenant.CacheTTL)
+
+	// storage & avatar
+	switch c.Storage.Driver {
+	case "local":
+		if strings.TrimSpace(c.Storage.LocalDir) == "" {
+			add("storage.local_dir", "must not be empty")
+		}
+	case "s3":
+		if u, err := url.Parse(c.Storage.S3.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("storage.s3.endpoint", "must be an http(s) URL (got %q)", c.Storage.S3.Endpoint)
+		}
+		if c.Storage.S3.Bucket == "" {
+			add("storage.s3.bucket", "must not be empty")
+		}
+		if c.Storage.S3.Region == "" {
+			add("storage.s3.region", "must not be empty")
+		}
+		if c.Storage.S3.AccessKey == "" || c.Storage.S3.SecretKey == "" {
+			add("storage.s3", "access_key and secret_key are required")
+		}
+	default:
+		add("storage.driver", "must be local or s3 (got %q)", c.Storage.Driver)
+	}
+	if p := c.Storage.PublicURL; p != "" && !strings.HasPrefix(p, "/") {
+		if u, err := url.Parse(p); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			add("storage.public_url", "must be an http(s) URL or an absolute path (got %q)", p)
+		}
+	}
+	if c.Avatar.MaxBytes < 1 {
+		add("avatar.max_bytes", "must be >= 1 (got %d)", c.Avatar.MaxBytes)
+	}
+	if c.Avatar.MaxPixels < 1 {
+		add("avatar.max_pixels", "must be >= 1 (got %d)", c.Avatar.MaxPixels)
+	}
+
+	// health
+	positive("health.timeout", c.Health.Timeout)
+	positive("health.db_timeout", c.Health.DBTimeout)
+	if c.Health.DiskMinFreeMB < 0 {
+		add("health.disk_min_free_mb", "must be >= 0 (got %d)", c.Health.DiskMinFreeMB)
+	}
+	return errs
+}
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+	"github.com/Quineeryn/go-backend-101/internal/logger"
+)
+
+// LagFunc mengukur seberapa jauh replica tertinggal dari primary.
+type LagFunc func(ctx context.Context, db *gorm.DB) (time.Duration, error)
+
+// PostgresLag: 0 bila semua WAL yang diterima sudah di-replay (primary
+// idle tidak dihitung lag), selain itu umur transaksi terakhir yang di-replay.
+func PostgresLag(ctx context.Context, db *gorm.DB) (time.Duration, error) {
+	var secs float64
+	err := db.WithContext(ctx).Raw(`SELECT CASE
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END`).Scan(&secs).Error
+	return time.Duration(secs * float64(time.Second)), err
+}
+
+type Replica struct {
+	Name string
+	DB   *gorm.DB
+	Lag  LagFunc // nil = tidak diukur (mis. SQLite)
+}
+
+type replica struct {
+	Replica
+	up  atomic.Bool
+	lag atomic.Int64 // time.Duration
+}
+
+// Cluster memilih koneksi: tulis selalu ke primary, baca round-robin ke
+// replica yang sehat. Tanpa replica (atau semua down) baca jatuh ke primary.
+type Cluster struct {
+	primary  *gorm.DB
+	replicas []*replica
+	next     atomic.Uint64
+	maxLag   time.Duration
+}
+
+// NewCluster: replica dianggap sehat sampai CheckReplicas membuktikan
+// sebaliknya. maxLag 0 = lag tidak dibatasi.
+func NewCluster(primary *gorm.DB, maxLag time.Duration, replicas ...Replica) *Cluster {
+	c := &Cluster{primary: primary, maxLag: maxLag}
+	for _, r := range replicas {
+		rr := &replica{Replica: r}
+		rr.up.Store(true)
+		httpx.DBReplicaUp.WithLabelValues(r.Name).Set(1)
+		c.replicas = append(c.replicas, rr)
+	}
+	return c
+}
+
+func (c *Cluster) Primary() *gorm.DB { return c.primary }
+
+// Writer: primary; sekaligus mem-pin session ctx ke primary supaya baca
+// berikutnya di request yang sama melihat tulisan ini.
+func (c *Cluster) Writer(ctx context.Context) *gorm.DB {
+	PinPrimary(ctx)
+	return c.primary
+}
+
+// Reader: replica sehat berikutnya, atau primary bila ctx di-pin / tidak
+// ada replica yang sehat.
+func (c *Cluster) Reader(ctx context.Context) *gorm.DB {
+	if len(c.replicas) == 0 || pinned(ctx) {
+		return c.primary
+	}
+	n := uint64(len(c.replicas))
+	start := c.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if r := c.replicas[(start+i)%n]; r.up.Load() {
+			return r.DB
+		}
+	}
+	return c.primary
+}
+
+// ReplicaStatus untuk log/endpoint admin.
+type ReplicaStatus struct {
+	Name string        `json:"name"`
+	Up   bool          `json:"up"`
+	Lag  time.Duration `json:"lag_ns"`
+}
+
+func (c *Cluster) Replicas() []ReplicaStatus {
+	out := make([]ReplicaStatus, len(c.replicas))
+	for i, r := range c.replicas {
+		out[i] = ReplicaStatus{Name: r.Name, Up: r.up.Load(), Lag: time.Duration(r.lag.Load())}
+	}
+	return out
+}
+
+// CheckReplicas ping & ukur lag tiap replica; yang down atau lag > maxLag
+// dikeluarkan dari rotasi sampai pulih.
+func (c *Cluster) CheckReplicas(ctx context.Context, timeout time.Duration) {
+	for _, r := range c.replicas {
+		cctx, cancel := context.WithTimeout(ctx, timeout)
+		lag, err := c.probe(cctx, r)
+		cancel()
+
+		up := err == nil
+		r.lag.Store(int64(lag))
+		httpx.DBReplicaLag.WithLabelValues(r.Name).Set(lag.Seconds())
+		if up {
+			httpx.DBReplicaUp.WithLabelValues(r.Name).Set(1)
+		} else {
+			httpx.DBReplicaUp.WithLabelValues(r.Name).Set(0)
+		}
+		if was := r.up.Swap(up); was != up {
+			if up {
+				logger.L.Info("db.replica.up", zap.String("replica", r.Name), zap.Duration("lag", lag))
+			} else {
+				logger.L.Warn("db.replica.down", zap.String("replica", r.Name), zap.Duration("lag", lag), zap.Error(err))
+			}
+		}
+	}
+}
+
+func (c *Cluster) probe(ctx context.Context, r *replica) (time.Duration, error) {
+	sqlDB, err := r.DB.DB()
+	if err != nil {
+		return 0, err
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return 0, err
+	}
+	if r.Lag == nil {
+		return 0, nil
+	}
+	lag, err := r.Lag(ctx, r.DB)
+	if err != nil {
+		return lag, err
+	}
+	if c.maxLag > 0 && lag > c.maxLag {
+		return lag, fmt.Errorf("lag %s exceeds %s", lag, c.maxLag)
+	}
+	return lag, nil
+}
+
+// WatchReplicas menjalankan CheckReplicas tiap interval sampai ctx selesai.
+func (c *Cluster) WatchReplicas(ctx context.Context, every, timeout time.Duration) {
+	if len(c.replicas) == 0 {
+		return
+	}
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		c.CheckReplicas(ctx, timeout)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Ping untuk health check: gagal bila ada replica tapi tak satu pun sehat
+// (baca tetap jalan lewat primary, jadi sebaiknya Optional).
+func (c *Cluster) Ping(ctx context.Context) error {
+	if len(c.replicas) == 0 {
+		return nil
+	}
+	for _, r := range c.replicas {
+		if r.up.Load() {
+			return nil
+		}
+	}
+	return errors.New("no healthy replica; reads served by primary")
+}
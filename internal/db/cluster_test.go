@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// openNamed: file SQLite terpisah sebagai stand-in primary/replica; tabel
+// "who" berisi nama DB supaya tujuan query bisa dicek.
+func openNamed(t *testing.T, name string) *gorm.DB {
+	t.Helper()
+	gdb, _, err := Open(context.Background(), Options{DSN: filepath.Join(t.TempDir(), name+".db"), MaxOpenConns: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := gdb.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	if err := gdb.Exec("CREATE TABLE who (name TEXT)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.Exec("INSERT INTO who VALUES (?)", name).Error; err != nil {
+		t.Fatal(err)
+	}
+	return gdb
+}
+
+func who(t *testing.T, gdb *gorm.DB) string {
+	t.Helper()
+	var name string
+	if err := gdb.Raw("SELECT name FROM who").Scan(&name).Error; err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestCluster_ReadsRoundRobinWritesPrimary(t *testing.T) {
+	c := NewCluster(openNamed(t, "primary"), 0,
+		Replica{Name: "r1", DB: openNamed(t, "r1")},
+		Replica{Name: "r2", DB: openNamed(t, "r2")},
+	)
+	ctx := context.Background()
+	seen := map[string]int{}
+	for i := 0; i < 4; i++ {
+		seen[who(t, c.Reader(ctx))]++
+	}
+	if seen["r1"] != 2 || seen["r2"] != 2 {
+		t.Fatalf("reads not balanced across replicas: %v", seen)
+	}
+	if got := who(t, c.Writer(ctx)); got != "primary" {
+		t.Fatalf("writer = %s", got)
+	}
+	// tanpa session, Writer tidak mem-pin apa pun
+	if got := who(t, c.Reader(ctx)); got == "primary" {
+		t.Fatal("read without session should still go to a replica")
+	}
+}
+
+func TestCluster_ReadAfterWritePinnedToPrimary(t *testing.T) {
+	c := NewCluster(openNamed(t, "primary"), 0, Replica{Name: "r1", DB: openNamed(t, "r1")})
+
+	req := WithSession(context.Background())
+	if got := who(t, c.Reader(req)); got != "r1" {
+		t.Fatalf("read before write = %s", got)
+	}
+	c.Writer(req)
+	for i := 0; i < 3; i++ {
+		if got := who(t, c.Reader(req)); got != "primary" {
+			t.Fatalf("read after write = %s", got)
+		}
+	}
+	// request lain tidak ikut ter-pin
+	if got := who(t, c.Reader(WithSession(context.Background()))); got != "r1" {
+		t.Fatalf("other session = %s", got)
+	}
+}
+
+func TestCluster_UnhealthyReplicasLeaveRotation(t *testing.T) {
+	lag := 0 * time.Second
+	r1 := openNamed(t, "r1")
+	c := NewCluster(openNamed(t, "primary"), time.Second,
+		Replica{Name: "r1", DB: r1},
+		Replica{Name: "r2", DB: openNamed(t, "r2"), Lag: func(context.Context, *gorm.DB) (time.Duration, error) { return lag, nil }},
+	)
+	ctx := context.Background()
+
+	// r1 down
+	sqlDB, _ := r1.DB()
+	_ = sqlDB.Close()
+	c.CheckReplicas(ctx, time.Second)
+	for i := 0; i < 3; i++ {
+		if got := who(t, c.Reader(ctx)); got != "r2" {
+			t.Fatalf("read went to %s, want r2 only", got)
+		}
+	}
+
+	// r2 tertinggal > maxLag → semua baca ke primary, health gagal
+	lag = 3 * time.Second
+	c.CheckReplicas(ctx, time.Second)
+	if got := who(t, c.Reader(ctx)); got != "primary" {
+		t.Fatalf("read went to %s, want primary fallback", got)
+	}
+	if err := c.Ping(ctx); err == nil {
+		t.Fatal("Ping should fail with no healthy replica")
+	}
+	st := c.Replicas()
+	if st[0].Up || st[1].Up || st[1].Lag != 3*time.Second {
+		t.Fatalf("status = %+v", st)
+	}
+
+	// r2 pulih
+	lag = 0
+	c.CheckReplicas(ctx, time.Second)
+	if got := who(t, c.Reader(ctx)); got != "r2" {
+		t.Fatalf("recovered replica not back in rotation, got %s", got)
+	}
+	if err := c.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCluster_LagErrorMarksDown(t *testing.T) {
+	c := NewCluster(openNamed(t, "primary"), 0, Replica{
+		Name: "r1", DB: openNamed(t, "r1"),
+		Lag: func(context.Context, *gorm.DB) (time.Duration, error) { return 0, errors.New("not a replica") },
+	})
+	c.CheckReplicas(context.Background(), time.Second)
+	if got := who(t, c.Reader(context.Background())); got != "primary" {
+		t.Fatalf("got %s", got)
+	}
+}
+
+func TestCluster_NoReplicas(t *testing.T) {
+	c := NewCluster(openNamed(t, "primary"), 0)
+	if got := who(t, c.Reader(context.Background())); got != "primary" {
+		t.Fatalf("got %s", got)
+	}
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,149 @@
+// Package db: satu-satunya tempat membuka koneksi database (dipakai
+// cmd/api & cmd/migrate): parse DSN, pilih Postgres/SQLite, pool,
+// pragma SQLite, dan ping dengan retry saat startup.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"go.uber.org/zap"
+	gormpg "gorm.io/driver/postgres"
+	gormsqlite "gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	_ "modernc.org/sqlite" // driver "sqlite" (pure Go, no CGO)
+
+	"github.com/Quineeryn/go-backend-101/internal/logger"
+)
+
+// batas atas jeda antar retry ping
+const maxBackoff = 30 * time.Second
+
+type Options struct {
+	DSN string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// SQLite: "nama=nilai", dijalankan di setiap koneksi baru
+	SQLitePragmas []string
+
+	ConnectTimeout time.Duration // per ping
+	ConnectRetries int           // retry setelah ping pertama gagal
+	ConnectBackoff time.Duration // jeda awal, dobel tiap retry (maks 30s)
+
+	// Lazy: tanpa ping saat Open. Dipakai untuk replica yang boleh belum
+	// hidup saat boot; kesehatannya dicek Cluster.
+	Lazy bool
+}
+
+// Open membuka DB sesuai dialek DSN lalu ping (dengan retry) sampai siap.
+// ctx membatasi total waktu menunggu.
+func Open(ctx context.Context, opt Options) (*gorm.DB, DSN, error) {
+	d, err := ParseDSN(opt.DSN)
+	if err != nil {
+		return nil, d, fmt.Errorf("db: %w", err)
+	}
+
+	var dial gorm.Dialector
+	switch d.Dialect {
+	case Postgres:
+		dial = gormpg.Open(d.raw)
+	case SQLite:
+		if err := CheckPragmas(opt.SQLitePragmas); err != nil {
+			return nil, d, fmt.Errorf("db: %w", err)
+		}
+		if !d.Memory() {
+			if dir := filepath.Dir(d.Path); dir != "." {
+				if err := os.MkdirAll(dir, 0o755); err != nil {
+					return nil, d, fmt.Errorf("db: %w", err)
+				}
+			}
+		}
+		dial = gormsqlite.Dialector{DriverName: "sqlite", DSN: d.sqliteDSN(opt.SQLitePragmas)}
+	}
+
+	// ping sendiri di bawah (dengan retry), bukan ping bawaan gorm.Open
+	gdb, err := gorm.Open(dial, &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		return nil, d, fmt.Errorf("db: open %s: %w", d.Dialect, err)
+	}
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		return nil, d, fmt.Errorf("db: %w", err)
+	}
+	configurePool(sqlDB, d, opt)
+
+	if opt.Lazy {
+		return gdb, d, nil
+	}
+	if err := ping(ctx, sqlDB, d, opt); err != nil {
+		_ = sqlDB.Close()
+		return nil, d, err
+	}
+	return gdb, d, nil
+}
+
+func configurePool(sqlDB *sql.DB, d DSN, opt Options) {
+	if d.Memory() {
+		// tiap koneksi :memory: = database sendiri; jangan pernah ditutup
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetConnMaxIdleTime(0)
+		return
+	}
+	sqlDB.SetMaxOpenConns(opt.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(opt.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(opt.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(opt.ConnMaxIdleTime)
+}
+
+func ping(ctx context.Context, sqlDB *sql.DB, d DSN, opt Options) error {
+	timeout := opt.ConnectTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	backoff := opt.ConnectBackoff
+	for attempt := 0; ; attempt++ {
+		pctx, cancel := context.WithTimeout(ctx, timeout)
+		err := sqlDB.PingContext(pctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt >= opt.ConnectRetries {
+			return fmt.Errorf("db: ping %s failed after %d attempts: %w", d.Dialect, attempt+1, err)
+		}
+		logger.L.Warn("db.connect.retry",
+			zap.String("dialect", d.Dialect), zap.Int("attempt", attempt+1),
+			zap.Duration("backoff", backoff), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("db: ping %s: %w (last error: %v)", d.Dialect, ctx.Err(), err)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+var pragmaRe = regexp.MustCompile(`^[a-z_]+(=[A-Za-z0-9_\-]+)?$`)
+
+// CheckPragmas menolak pragma yang bukan "nama" / "nama=nilai" sederhana.
+func CheckPragmas(pragmas []string) error {
+	var errs []error
+	for _, p := range pragmas {
+		if !pragmaRe.MatchString(p) {
+			errs = append(errs, fmt.Errorf("invalid SQLite pragma %q (want name=value)", p))
+		}
+	}
+	return errors.Join(errs...)
+}
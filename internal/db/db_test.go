@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpen_SQLiteFile_PragmasOnEveryConn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "app.db")
+	gdb, d, err := Open(context.Background(), Options{
+		DSN:           path,
+		MaxOpenConns:  4,
+		MaxIdleConns:  4,
+		SQLitePragmas: []string{"foreign_keys=ON", "busy_timeout=5000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := gdb.DB()
+	defer sqlDB.Close()
+	if d.Dialect != SQLite || d.Path != path {
+		t.Fatalf("dsn = %+v", d)
+	}
+	if got := sqlDB.Stats().MaxOpenConnections; got != 4 {
+		t.Fatalf("max open conns = %d", got)
+	}
+
+	// pegang beberapa koneksi sekaligus supaya pool membuka koneksi baru
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		conn, err := sqlDB.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		var fk int
+		if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&fk); err != nil || fk != 1 {
+			t.Fatalf("conn %d: foreign_keys = %d (%v)", i, fk, err)
+		}
+	}
+}
+
+func TestOpen_SQLiteMemory_SingleConn(t *testing.T) {
+	gdb, _, err := Open(context.Background(), Options{DSN: ":memory:", MaxOpenConns: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := gdb.DB()
+	defer sqlDB.Close()
+	if got := sqlDB.Stats().MaxOpenConnections; got != 1 {
+		t.Fatalf("memory DB must use 1 conn, got %d", got)
+	}
+	if err := gdb.Exec("CREATE TABLE t (id INTEGER)").Error; err != nil {
+		t.Fatal(err)
+	}
+	var n int64
+	if err := gdb.Table("t").Count(&n).Error; err != nil {
+		t.Fatalf("table lost between connections: %v", err)
+	}
+}
+
+func TestOpen_RetriesThenFails(t *testing.T) {
+	start := time.Now()
+	_, _, err := Open(context.Background(), Options{
+		DSN:            "postgres://u:p@127.0.0.1:1/db?sslmode=disable&connect_timeout=1",
+		MaxOpenConns:   1,
+		ConnectTimeout: time.Second,
+		ConnectRetries: 2,
+		ConnectBackoff: 20 * time.Millisecond,
+	})
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Fatalf("want retry error, got %v", err)
+	}
+	if time.Since(start) < 60*time.Millisecond {
+		t.Fatal("backoff not applied")
+	}
+}
+
+func TestOpen_InvalidPragma(t *testing.T) {
+	_, _, err := Open(context.Background(), Options{DSN: ":memory:", SQLitePragmas: []string{"foo=1; DROP TABLE x"}})
+	if err == nil {
+		t.Fatal("want error")
+	}
+}
@@ -0,0 +1,186 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+// DSN = hasil parse DB_DSN. Postgres menerima URL (postgres://...) maupun
+// key=value ala libpq; selain itu dianggap SQLite (path, file: URI, :memory:).
+type DSN struct {
+	Dialect string
+	Path    string // SQLite: path file tanpa "file:" & query; ":memory:" untuk in-memory
+
+	raw    string
+	params map[string]string // Postgres: keyword libpq (host, user, dbname, sslmode, ...)
+	query  url.Values        // SQLite: parameter setelah '?'
+}
+
+// keyword libpq yang menandai DSN key=value sebagai Postgres
+var pgKeywords = map[string]bool{
+	"host": true, "hostaddr": true, "port": true, "dbname": true, "user": true,
+	"password": true, "sslmode": true, "connect_timeout": true, "application_name": true,
+	"search_path": true, "options": true, "TimeZone": true,
+}
+
+func ParseDSN(s string) (DSN, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return DSN{}, errors.New("empty DSN")
+	}
+	if i := strings.Index(s, "://"); i > 0 {
+		switch scheme := s[:i]; scheme {
+		case "postgres", "postgresql":
+			return parsePostgresURL(s)
+		default:
+			return DSN{}, fmt.Errorf("unsupported DSN scheme %q", scheme)
+		}
+	}
+	if strings.HasPrefix(s, "file:") {
+		return parseSQLite(s, strings.TrimPrefix(s, "file:"))
+	}
+	if i := strings.IndexAny(s, "= "); i > 0 && strings.Contains(s, "=") && pgKeywords[s[:i]] {
+		params, err := parseKeyValue(s)
+		if err != nil {
+			return DSN{}, err
+		}
+		return DSN{Dialect: Postgres, raw: s, params: params}, nil
+	}
+	return parseSQLite(s, s)
+}
+
+// Param: nilai keyword Postgres (mis. "sslmode"); "" bila tidak diset.
+func (d DSN) Param(key string) string { return d.params[key] }
+
+// Memory: SQLite in-memory (tiap koneksi = database terpisah).
+func (d DSN) Memory() bool {
+	return d.Dialect == SQLite && (d.Path == ":memory:" || d.query.Get("mode") == "memory")
+}
+
+func (d DSN) String() string { return d.raw }
+
+func parsePostgresURL(s string) (DSN, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return DSN{}, fmt.Errorf("invalid postgres URL: %w", err)
+	}
+	params := map[string]string{}
+	if u.Hostname() != "" {
+		params["host"] = u.Hostname()
+	}
+	if u.Port() != "" {
+		params["port"] = u.Port()
+	}
+	if u.User != nil {
+		params["user"] = u.User.Username()
+		if p, ok := u.User.Password(); ok {
+			params["password"] = p
+		}
+	}
+	if name := strings.TrimPrefix(u.Path, "/"); name != "" {
+		params["dbname"] = name
+	}
+	for k, v := range u.Query() {
+		params[k] = v[len(v)-1]
+	}
+	return DSN{Dialect: Postgres, raw: s, params: params}, nil
+}
+
+// parseKeyValue mengikuti aturan libpq: "k=v k2='v 2'", spasi di sekitar
+// '=' boleh, backslash meng-escape karakter berikutnya.
+func parseKeyValue(s string) (map[string]string, error) {
+	params := map[string]string{}
+	for i := 0; i < len(s); {
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		if i == len(s) {
+			break
+		}
+		start := i
+		for i < len(s) && s[i] != '=' && s[i] != ' ' {
+			i++
+		}
+		key := s[start:i]
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		if i == len(s) || s[i] != '=' {
+			return nil, fmt.Errorf("invalid DSN: missing \"=\" after %q", key)
+		}
+		i++
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		var val strings.Builder
+		quoted := i < len(s) && s[i] == '\''
+		if quoted {
+			i++
+		}
+		closed := !quoted
+		for i < len(s) {
+			c := s[i]
+			if c == '\\' && i+1 < len(s) {
+				val.WriteByte(s[i+1])
+				i += 2
+				continue
+			}
+			if quoted && c == '\'' {
+				closed = true
+				i++
+				break
+			}
+			if !quoted && c == ' ' {
+				break
+			}
+			val.WriteByte(c)
+			i++
+		}
+		if !closed {
+			return nil, fmt.Errorf("invalid DSN: unterminated quote in %q", key)
+		}
+		params[key] = val.String()
+	}
+	return params, nil
+}
+
+func parseSQLite(raw, rest string) (DSN, error) {
+	path, query, _ := strings.Cut(rest, "?")
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		return DSN{}, fmt.Errorf("invalid SQLite DSN query: %w", err)
+	}
+	if path == "" {
+		return DSN{}, errors.New("invalid SQLite DSN: empty path")
+	}
+	return DSN{Dialect: SQLite, Path: path, raw: raw, query: q}, nil
+}
+
+// sqliteDSN = URI untuk driver modernc: query asli + pragma per koneksi
+// (PRAGMA via Exec hanya kena satu koneksi di pool).
+func (d DSN) sqliteDSN(pragmas []string) string {
+	q := url.Values{}
+	for k, v := range d.query {
+		q[k] = append([]string(nil), v...)
+	}
+	for _, p := range pragmas {
+		name, val, ok := strings.Cut(p, "=")
+		if !ok {
+			q.Add("_pragma", strings.TrimSpace(p))
+			continue
+		}
+		q.Add("_pragma", strings.TrimSpace(name)+"("+strings.TrimSpace(val)+")")
+	}
+	path := strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(d.Path)
+	if enc := q.Encode(); enc != "" {
+		return "file:" + path + "?" + enc
+	}
+	return "file:" + path
+}
@@ -0,0 +1,61 @@
+package db
+
+import "testing"
+
+func TestParseDSN(t *testing.T) {
+	cases := []struct {
+		in      string
+		dialect string
+		param   [2]string // key, want (Postgres)
+		path    string    // SQLite
+	}{
+		{in: "postgres://app:p%40ss@db:5433/app?sslmode=require", dialect: Postgres, param: [2]string{"password", "p@ss"}},
+		{in: "postgresql://app@db/app", dialect: Postgres, param: [2]string{"dbname", "app"}},
+		{in: "host=127.0.0.1 user=postgres password='a b\\'c' dbname=x sslmode=disable", dialect: Postgres, param: [2]string{"password", "a b'c"}},
+		{in: "user = u  host=h", dialect: Postgres, param: [2]string{"host", "h"}},
+		{in: "var/app.db", dialect: SQLite, path: "var/app.db"},
+		{in: "file:var/app.db?cache=shared", dialect: SQLite, path: "var/app.db"},
+		{in: ":memory:", dialect: SQLite, path: ":memory:"},
+		// "host=" di tengah path bukan Postgres
+		{in: "data/host=x.db", dialect: SQLite, path: "data/host=x.db"},
+	}
+	for _, c := range cases {
+		d, err := ParseDSN(c.in)
+		if err != nil {
+			t.Errorf("ParseDSN(%q): %v", c.in, err)
+			continue
+		}
+		if d.Dialect != c.dialect {
+			t.Errorf("ParseDSN(%q).Dialect = %s, want %s", c.in, d.Dialect, c.dialect)
+		}
+		if c.param[0] != "" && d.Param(c.param[0]) != c.param[1] {
+			t.Errorf("ParseDSN(%q).Param(%s) = %q, want %q", c.in, c.param[0], d.Param(c.param[0]), c.param[1])
+		}
+		if c.path != "" && d.Path != c.path {
+			t.Errorf("ParseDSN(%q).Path = %q, want %q", c.in, d.Path, c.path)
+		}
+	}
+}
+
+func TestParseDSN_Errors(t *testing.T) {
+	for _, in := range []string{"", "  ", "mysql://u@h/db", "host=h password='x", "host=h user", "file:"} {
+		if _, err := ParseDSN(in); err == nil {
+			t.Errorf("ParseDSN(%q): want error", in)
+		}
+	}
+}
+
+func TestSQLiteDSN_Pragmas(t *testing.T) {
+	d, _ := ParseDSN("file:var/app.db?cache=shared")
+	got := d.sqliteDSN([]string{"foreign_keys=ON", "busy_timeout=5000"})
+	want := "file:var/app.db?_pragma=foreign_keys%28ON%29&_pragma=busy_timeout%285000%29&cache=shared"
+	if got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+	if d.Memory() {
+		t.Fatal("file DSN reported as memory")
+	}
+	if m, _ := ParseDSN("file::memory:?cache=shared"); !m.Memory() {
+		t.Fatal(":memory: not detected")
+	}
+}
@@ -0,0 +1,21 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// RegisterStats mengekspos sql.DBStats (go_sql_open_connections,
+// go_sql_in_use_connections, go_sql_wait_count_total, ...) dengan label
+// db_name=name. Aman dipanggil ulang untuk name yang sama.
+func RegisterStats(sqlDB *sql.DB, name string) error {
+	err := prometheus.Register(collectors.NewDBStatsCollector(sqlDB, name))
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		return nil
+	}
+	return err
+}
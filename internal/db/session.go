@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+type sessionKey struct{}
+
+type session struct{ primary atomic.Bool }
+
+// WithSession menandai ctx sebagai satu unit read-after-write (biasanya
+// satu request): setelah Writer dipakai, Reader di ctx ini ke primary.
+func WithSession(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(sessionKey{}).(*session); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// PinPrimary: sisa session membaca dari primary. No-op tanpa WithSession.
+func PinPrimary(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		s.primary.Store(true)
+	}
+}
+
+func pinned(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	return ok && s.primary.Load()
+}
+
+// Session: middleware gin yang membuka session per request.
+func Session() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(WithSession(c.Request.Context()))
+		c.Next()
+	}
+}
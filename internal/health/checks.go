@@ -0,0 +1,38 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+)
+
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// DB: ping database/sql pool (timeout dari ctx).
+func DB(db *sql.DB) Checker {
+	return CheckerFunc(db.PingContext)
+}
+
+// Ping untuk apa pun yang punya Ping(ctx) error, mis. *cache.Redis.
+func Ping(p pinger) Checker {
+	return CheckerFunc(p.Ping)
+}
+
+// DiskSpace gagal kalau sisa ruang di filesystem tempat path berada
+// kurang dari minFree byte (dipakai untuk file SQLite).
+func DiskSpace(path string, minFree uint64) Checker {
+	dir := filepath.Dir(path)
+	return CheckerFunc(func(ctx context.Context) error {
+		free, err := freeBytes(dir)
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("only %d MiB free on %s (min %d MiB)", free>>20, dir, minFree>>20)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,184 @@
+// Package health menyediakan /livez dan /readyz dengan registry Checker.
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusFail     = "fail"
+	StatusWarn     = "warn" // check Optional yang gagal: dilaporkan tapi tidak menggagalkan readiness
+	StatusDraining = "draining"
+)
+
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error { return f(ctx) }
+
+type Check struct {
+	Name     string
+	Checker  Checker
+	Timeout  time.Duration // default: timeout registry
+	Optional bool          // dependency yang punya fallback (mis. Redis cache)
+}
+
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+type Registry struct {
+	timeout time.Duration
+
+	mu        sync.RWMutex
+	liveness  []Check
+	readiness []Check
+
+	draining atomic.Bool
+}
+
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Registry{timeout: timeout}
+}
+
+// Liveness: hanya untuk hal yang memang butuh restart proses kalau gagal.
+func (r *Registry) Liveness(c Check) {
+	r.mu.Lock()
+	r.liveness = append(r.liveness, c)
+	r.mu.Unlock()
+}
+
+// Readiness: dependency yang dibutuhkan untuk melayani traffic.
+func (r *Registry) Readiness(c Check) {
+	r.mu.Lock()
+	r.readiness = append(r.readiness, c)
+	r.mu.Unlock()
+}
+
+// Drain membuat /readyz langsung 503 (dipanggil di awal graceful shutdown
+// supaya load balancer berhenti mengirim traffic).
+func (r *Registry) Drain() {
+	r.draining.Store(true)
+	httpx.HealthReady.Set(0)
+}
+
+func (r *Registry) Draining() bool { return r.draining.Load() }
+
+func (r *Registry) Live(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]Check(nil), r.liveness...)
+	r.mu.RUnlock()
+	return r.run(ctx, checks)
+}
+
+func (r *Registry) Ready(ctx context.Context) Report {
+	if r.Draining() {
+		return Report{Status: StatusDraining}
+	}
+	r.mu.RLock()
+	checks := append([]Check(nil), r.readiness...)
+	r.mu.RUnlock()
+	rep := r.run(ctx, checks)
+	if rep.Status == StatusOK {
+		httpx.HealthReady.Set(1)
+	} else {
+		httpx.HealthReady.Set(0)
+	}
+	return rep
+}
+
+// run menjalankan semua check paralel, masing-masing dengan timeout sendiri.
+func (r *Registry) run(ctx context.Context, checks []Check) Report {
+	rep := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c Check) {
+			defer wg.Done()
+			res := r.runOne(ctx, c)
+			mu.Lock()
+			rep.Checks[c.Name] = res
+			if res.Status == StatusFail {
+				rep.Status = StatusFail
+			}
+			mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+	return rep
+}
+
+func (r *Registry) runOne(ctx context.Context, c Check) Result {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = r.timeout
+	}
+	cctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+	// checker yang tidak menghormati ctx tetap tidak boleh menahan probe
+	go func() { errc <- c.Checker.Check(cctx) }()
+	var err error
+	select {
+	case err = <-errc:
+	case <-cctx.Done():
+		err = cctx.Err()
+	}
+	took := time.Since(start)
+
+	res := Result{Status: StatusOK, LatencyMS: float64(took.Microseconds()) / 1000}
+	up := 1.0
+	if err != nil {
+		up = 0
+		res.Error = err.Error()
+		res.Status = StatusFail
+		if c.Optional {
+			res.Status = StatusWarn
+		}
+	}
+	httpx.HealthCheckUp.WithLabelValues(c.Name).Set(up)
+	httpx.HealthCheckLatency.WithLabelValues(c.Name).Set(took.Seconds())
+	return res
+}
+
+func (r *Registry) LivezHandler(c *gin.Context) {
+	write(c, r.Live(c.Request.Context()))
+}
+
+func (r *Registry) ReadyzHandler(c *gin.Context) {
+	write(c, r.Ready(c.Request.Context()))
+}
+
+func write(c *gin.Context, rep Report) {
+	code := http.StatusOK
+	if rep.Status != StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(code, rep)
+}
@@ -0,0 +1,107 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ok() Checker { return CheckerFunc(func(context.Context) error { return nil }) }
+
+func failing(msg string) Checker {
+	return CheckerFunc(func(context.Context) error { return errors.New(msg) })
+}
+
+func serve(t *testing.T, reg *Registry, path string) (int, Report) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/livez", reg.LivezHandler)
+	r.GET("/readyz", reg.ReadyzHandler)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	var rep Report
+	if err := json.Unmarshal(w.Body.Bytes(), &rep); err != nil {
+		t.Fatalf("decode %s: %v", w.Body.String(), err)
+	}
+	return w.Code, rep
+}
+
+func TestReadyz_AllOK(t *testing.T) {
+	reg := NewRegistry(time.Second)
+	reg.Readiness(Check{Name: "db", Checker: ok()})
+	reg.Readiness(Check{Name: "redis", Checker: ok()})
+
+	code, rep := serve(t, reg, "/readyz")
+	if code != http.StatusOK || rep.Status != StatusOK || len(rep.Checks) != 2 {
+		t.Fatalf("code=%d rep=%+v", code, rep)
+	}
+	if rep.Checks["db"].Status != StatusOK {
+		t.Fatalf("db check: %+v", rep.Checks["db"])
+	}
+}
+
+func TestReadyz_FailingAndOptional(t *testing.T) {
+	reg := NewRegistry(time.Second)
+	reg.Readiness(Check{Name: "db", Checker: ok()})
+	reg.Readiness(Check{Name: "redis", Checker: failing("connection refused"), Optional: true})
+
+	code, rep := serve(t, reg, "/readyz")
+	if code != http.StatusOK || rep.Checks["redis"].Status != StatusWarn || rep.Checks["redis"].Error == "" {
+		t.Fatalf("optional failure should warn only: code=%d rep=%+v", code, rep)
+	}
+
+	reg.Readiness(Check{Name: "migrations", Checker: failing("schema behind")})
+	code, rep = serve(t, reg, "/readyz")
+	if code != http.StatusServiceUnavailable || rep.Status != StatusFail || rep.Checks["migrations"].Status != StatusFail {
+		t.Fatalf("want 503: code=%d rep=%+v", code, rep)
+	}
+}
+
+func TestReadyz_TimeoutEvenIfCheckerIgnoresCtx(t *testing.T) {
+	reg := NewRegistry(time.Second)
+	block := make(chan struct{})
+	defer close(block)
+	reg.Readiness(Check{Name: "slow", Timeout: 20 * time.Millisecond, Checker: CheckerFunc(func(context.Context) error {
+		<-block
+		return nil
+	})})
+
+	start := time.Now()
+	code, rep := serve(t, reg, "/readyz")
+	if code != http.StatusServiceUnavailable || rep.Checks["slow"].Error == "" {
+		t.Fatalf("want timeout failure: code=%d rep=%+v", code, rep)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("probe should not wait for stuck checker")
+	}
+}
+
+func TestDrain_ReadyzFailsLivezStaysUp(t *testing.T) {
+	reg := NewRegistry(time.Second)
+	reg.Readiness(Check{Name: "db", Checker: ok()})
+	reg.Drain()
+
+	if code, rep := serve(t, reg, "/readyz"); code != http.StatusServiceUnavailable || rep.Status != StatusDraining {
+		t.Fatalf("readyz while draining: code=%d rep=%+v", code, rep)
+	}
+	if code, _ := serve(t, reg, "/livez"); code != http.StatusOK {
+		t.Fatalf("livez must stay 200 while draining, got %d", code)
+	}
+}
+
+func TestDiskSpace(t *testing.T) {
+	dir := t.TempDir()
+	if err := DiskSpace(dir+"/app.db", 1).Check(context.Background()); err != nil {
+		t.Fatalf("1 byte free should pass: %v", err)
+	}
+	if err := DiskSpace(dir+"/app.db", 1<<62).Check(context.Background()); err == nil {
+		t.Fatal("want failure for absurd threshold")
+	}
+}
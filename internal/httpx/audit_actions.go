@@ -7,5 +7,7 @@ const (
 	ActionUserUpdate = "USER_UPDATE"
 	ActionUserDelete = "USER_DELETE"
 	ActionUserView   = "USER_VIEW"
+
+	ActionUserAvatarDelete = "user.avatar.delete"
 	// tambah sesuai domain: ORDER_CREATE, PAYMENT_CHARGE, dsb
 )
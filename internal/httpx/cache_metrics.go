@@ -11,8 +11,38 @@ var (
 		prometheus.CounterOpts{Name: "cache_miss_total", Help: "Total cache misses"},
 		[]string{"resource"},
 	)
+	// stampede protection (users.CachedStore): negative_hit, stale_served,
+	// early_refresh, coalesced, lock_wait
+	CacheEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "cache_events_total", Help: "Cache stampede-protection events"},
+		[]string{"resource", "event"},
+	)
+
+	// cache.Memory; hit ratio = rate(result="hit") / rate(total)
+	CacheMemRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "cache_memory_requests_total", Help: "In-process cache lookups by result (hit|miss)"},
+		[]string{"cache", "result"},
+	)
+	CacheMemEvictions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "cache_memory_evictions_total", Help: "In-process cache evictions by reason (capacity|expired|too_large)"},
+		[]string{"cache", "reason"},
+	)
+	CacheMemEntries = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "cache_memory_entries", Help: "Entries currently held by the in-process cache"},
+		[]string{"cache"},
+	)
+	CacheMemBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "cache_memory_bytes", Help: "Accounted size (key+value+overhead) of the in-process cache"},
+		[]string{"cache"},
+	)
+
+	// cache.Serializer: ukuran value (termasuk header envelope) yang ditulis
+	CacheEncodedBytes = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Name: "cache_encoded_bytes", Help: "Size of encoded cache values by codec and applied compression", Buckets: prometheus.ExponentialBuckets(64, 4, 8)},
+		[]string{"codec", "compression"},
+	)
 )
 
 func init() {
-	prometheus.MustRegister(CacheHit, CacheMiss)
+	prometheus.MustRegister(CacheHit, CacheMiss, CacheEvents, CacheMemRequests, CacheMemEvictions, CacheMemEntries, CacheMemBytes, CacheEncodedBytes)
 }
@@ -0,0 +1,18 @@
+package httpx
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	DBReplicaUp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "db_replica_up", Help: "1 when the read replica is in rotation, 0 when down or lagging"},
+		[]string{"replica"},
+	)
+	DBReplicaLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "db_replica_lag_seconds", Help: "Replication lag measured by the last replica check"},
+		[]string{"replica"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(DBReplicaUp, DBReplicaLag)
+}
@@ -0,0 +1,21 @@
+package httpx
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	HealthCheckUp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "health_check_up", Help: "Last result of a health check (1 = ok, 0 = failing)"},
+		[]string{"check"},
+	)
+	HealthCheckLatency = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "health_check_latency_seconds", Help: "Latency of the last health check run"},
+		[]string{"check"},
+	)
+	HealthReady = prometheus.NewGauge(
+		prometheus.GaugeOpts{Name: "health_ready", Help: "1 when /readyz reports ready, 0 otherwise (incl. draining)"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(HealthCheckUp, HealthCheckLatency, HealthReady)
+}
@@ -0,0 +1,22 @@
+package httpx
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	JobRuns = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "job_runs_total", Help: "Background job runs by status"},
+		[]string{"job", "status"}, // status: succeeded|failed|skipped
+	)
+	JobDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Name: "job_duration_seconds", Help: "Background job run duration", Buckets: prometheus.DefBuckets},
+		[]string{"job"},
+	)
+	JobLastSuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "job_last_success_timestamp_seconds", Help: "Unix time of the last successful run"},
+		[]string{"job"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(JobRuns, JobDuration, JobLastSuccess)
+}
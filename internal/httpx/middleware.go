@@ -13,7 +13,8 @@ func ErrorMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
 
-		if len(c.Errors) == 0 {
+		// sudah ditulis (mis. oleh middleware.ErrorEnvelope) → jangan tulis body kedua
+		if len(c.Errors) == 0 || c.Writer.Written() {
 			return
 		}
 		var ae *apperr.AppError
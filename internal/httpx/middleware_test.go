@@ -0,0 +1,47 @@
+package httpx_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/Quineeryn/go-backend-101/internal/apperr"
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+	"github.com/Quineeryn/go-backend-101/internal/logger"
+	"github.com/Quineeryn/go-backend-101/internal/middleware"
+)
+
+// main memasang ErrorMiddleware (luar) dan ErrorEnvelope (dalam); error
+// yang sudah ditulis envelope tidak boleh ditulis dua kali.
+func TestErrorMiddleware_SkipsWrittenResponse(t *testing.T) {
+	logger.L = zap.NewNop()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(httpx.ErrorMiddleware())
+	r.GET("/alone", func(c *gin.Context) {
+		httpx.AbortError(c, "test", apperr.E(apperr.NotFound, "nope", nil))
+	})
+	r.GET("/stacked", middleware.ErrorEnvelope(), func(c *gin.Context) {
+		httpx.AbortError(c, "test", apperr.E(apperr.Conflict, "taken", nil))
+	})
+
+	for path, want := range map[string]int{"/alone": http.StatusNotFound, "/stacked": http.StatusConflict} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var body map[string]any
+		dec := json.NewDecoder(w.Body)
+		if err := dec.Decode(&body); err != nil || w.Code != want {
+			t.Fatalf("%s: %d %v", path, w.Code, err)
+		}
+		if dec.More() {
+			t.Fatalf("%s: second JSON body written after the first", path)
+		}
+		if int(body["code"].(float64)) != want {
+			t.Fatalf("%s: body code %v", path, body["code"])
+		}
+	}
+}
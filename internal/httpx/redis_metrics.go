@@ -0,0 +1,27 @@
+package httpx
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	RedisBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "redis_breaker_state", Help: "Redis circuit breaker state (0=closed, 1=half_open, 2=open)"},
+		[]string{"client"},
+	)
+	RedisBreakerTransitions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "redis_breaker_transitions_total", Help: "Redis circuit breaker state changes"},
+		[]string{"client", "to"},
+	)
+	RedisBreakerRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "redis_breaker_rejected_total", Help: "Redis commands rejected while the breaker is open"},
+		[]string{"client"},
+	)
+	// keputusan konsumen saat Redis tidak tersedia (fail mode)
+	RedisFallback = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "redis_fallback_total", Help: "Requests handled by a consumer's fail mode while Redis was unavailable"},
+		[]string{"consumer", "mode"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(RedisBreakerState, RedisBreakerTransitions, RedisBreakerRejected, RedisFallback)
+}
@@ -0,0 +1,17 @@
+package httpx
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	SettingsReloads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "settings_reloads_total", Help: "Runtime settings reloads by source and result (applied|unchanged|error)"},
+		[]string{"source", "result"},
+	)
+	SettingsVersion = prometheus.NewGauge(
+		prometheus.GaugeOpts{Name: "settings_version", Help: "Version of the effective runtime settings (bumped on every applied change)"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(SettingsReloads, SettingsVersion)
+}
@@ -0,0 +1,17 @@
+package httpx
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	WebhookDeliveries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "webhook_deliveries_total", Help: "Webhook delivery attempts by result"},
+		[]string{"event", "result"}, // result: success|retry|dead
+	)
+	WebhookEventsDropped = prometheus.NewCounter(
+		prometheus.CounterOpts{Name: "webhook_events_dropped_total", Help: "Events dropped because the webhook queue was full"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(WebhookDeliveries, WebhookEventsDropped)
+}
@@ -0,0 +1,236 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/Quineeryn/go-backend-101/internal/apperr"
+	"github.com/Quineeryn/go-backend-101/internal/cache"
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+	"github.com/Quineeryn/go-backend-101/internal/logger"
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLen = 255
+)
+
+type Options struct {
+	TTL     time.Duration // umur record (default 24h)
+	LockTTL time.Duration // batas request "in-flight" (default 1m)
+	// MaxBodyBytes: body dibaca penuh ke memori untuk fingerprint, jadi
+	// dibatasi; lebih besar → 413 (default 1 MiB).
+	MaxBodyBytes int64
+	// Paths: kalau diisi, hanya route (c.FullPath) ini yang diproses —
+	// berguna saat dipasang global via r.Use. Kosong = semua POST.
+	Paths []string
+	// FailClosed: backend error → 503 alih-alih menjalankan request tanpa
+	// jaminan idempotensi. Untuk fallback per replica pakai Failover.
+	FailClosed bool
+	// UserID: pemilik key. Middleware global berjalan sebelum RequireAuth,
+	// jadi biasanya membaca bearer token sendiri. Default httpx.CurrentUserID
+	// (hanya benar bila dipasang setelah auth).
+	UserID func(*gin.Context) string
+}
+
+// header yang spesifik per-request, tidak ikut di-replay
+var skipHeaders = map[string]bool{
+	"X-Request-Id":   true,
+	"Date":           true,
+	"Content-Length": true,
+	"Set-Cookie":     true,
+}
+
+// Middleware menghormati header Idempotency-Key untuk POST:
+//   - request pertama dieksekusi, status+header+body disimpan
+//   - retry dengan body sama → response tersimpan di-replay
+//   - retry saat request pertama masih jalan → 409
+//   - key sama tapi body beda → 422
+func Middleware(b Backend, opt Options) gin.HandlerFunc {
+	if opt.TTL <= 0 {
+		opt.TTL = 24 * time.Hour
+	}
+	if opt.LockTTL <= 0 {
+		opt.LockTTL = time.Minute
+	}
+	if opt.MaxBodyBytes <= 0 {
+		opt.MaxBodyBytes = 1 << 20
+	}
+	if opt.UserID == nil {
+		opt.UserID = httpx.CurrentUserID
+	}
+	paths := map[string]bool{}
+	for _, p := range opt.Paths {
+		paths[p] = true
+	}
+
+	return func(c *gin.Context) {
+		idemKey := strings.TrimSpace(c.GetHeader(HeaderKey))
+		if c.Request.Method != http.MethodPost || idemKey == "" {
+			c.Next()
+			return
+		}
+		if len(paths) > 0 && !paths[c.FullPath()] {
+			c.Next()
+			return
+		}
+		if len(idemKey) > maxKeyLen {
+			httpx.AbortError(c, "idempotency", apperr.E(apperr.Validation, "Idempotency-Key is too long", nil))
+			return
+		}
+
+		// baca body lalu kembalikan supaya handler tetap bisa decode
+		raw, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, opt.MaxBodyBytes))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			httpx.AbortError(c, "idempotency", apperr.E(apperr.TooLarge, fmt.Sprintf("request body must be at most %d bytes", opt.MaxBodyBytes), err))
+			return
+		}
+		if err != nil {
+			httpx.AbortError(c, "idempotency", apperr.E(apperr.Validation, "cannot read request body", err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(raw))
+
+		ctx := c.Request.Context()
+		key := storageKey(c, opt.UserID(c), idemKey)
+		fp := fingerprint(c.Request.Method, c.FullPath(), raw)
+
+		if rec, err := b.Get(ctx, key); err != nil {
+			backendFailed(c, "idempotency.get.failed", err, opt.FailClosed)
+			return
+		} else if rec != nil {
+			replay(c, rec, fp)
+			return
+		}
+
+		token := uuid.NewString()
+		ok, err := b.Lock(ctx, key, token, opt.LockTTL)
+		if err != nil {
+			backendFailed(c, "idempotency.lock.failed", err, opt.FailClosed)
+			return
+		}
+		if !ok {
+			// bisa jadi request pertama baru saja selesai
+			if rec, _ := b.Get(ctx, key); rec != nil {
+				replay(c, rec, fp)
+				return
+			}
+			httpx.AbortError(c, "idempotency", apperr.E(apperr.Conflict, "a request with this Idempotency-Key is already in progress", nil))
+			return
+		}
+		defer func() { _ = b.Unlock(context.WithoutCancel(ctx), key, token) }()
+
+		w := &captureWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		// hanya simpan response final yang sukses ditulis handler; error (c.Error)
+		// dan 5xx tidak disimpan supaya client bisa retry
+		status := w.Status()
+		if len(c.Errors) > 0 || !w.Written() || status >= http.StatusInternalServerError {
+			return
+		}
+		rec := Record{
+			Fingerprint: fp,
+			Status:      status,
+			Header:      replayableHeader(w.Header()),
+			Body:        w.buf.Bytes(),
+			CreatedAt:   time.Now().UTC(),
+		}
+		if err := b.Put(ctx, key, rec, opt.TTL); err != nil {
+			logger.L.Warn("idempotency.put.failed", zap.Error(err))
+		}
+	}
+}
+
+// backendFailed: fail-closed → 503; selain itu jalankan tanpa jaminan
+// idempotensi.
+func backendFailed(c *gin.Context, msg string, err error, failClosed bool) {
+	logger.L.Warn(msg, zap.Error(err))
+	if failClosed {
+		httpx.RedisFallback.WithLabelValues("idempotency", string(cache.FailClosed)).Inc()
+		httpx.AbortError(c, "idempotency", apperr.E(apperr.Unavailable, "idempotency store is unavailable, retry later", err))
+		return
+	}
+	httpx.RedisFallback.WithLabelValues("idempotency", string(cache.FailOpen)).Inc()
+	c.Next()
+}
+
+func replay(c *gin.Context, rec *Record, fp string) {
+	if rec.Fingerprint != fp {
+		httpx.AbortError(c, "idempotency", apperr.E(apperr.Unprocessable, "Idempotency-Key was already used with a different request body", nil))
+		return
+	}
+	for k, vs := range rec.Header {
+		for _, v := range vs {
+			c.Writer.Header().Add(k, v)
+		}
+	}
+	c.Header(HeaderReplayed, "true")
+	c.Status(rec.Status)
+	_, _ = c.Writer.Write(rec.Body)
+	c.Abort()
+}
+
+// tenant + key + user + route; user "anonymous" untuk endpoint publik (mis. register)
+func storageKey(c *gin.Context, user, idemKey string) string {
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+	return "idem:" + tenant.ID(c) + ":" + user + ":" + route + ":" + idemKey
+}
+
+func fingerprint(method, route string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(route))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replayableHeader(h http.Header) map[string][]string {
+	out := make(map[string][]string, len(h))
+	for k, vs := range h {
+		if skipHeaders[http.CanonicalHeaderKey(k)] {
+			continue
+		}
+		out[k] = append([]string(nil), vs...)
+	}
+	return out
+}
+
+// captureWriter: tee body ke buffer sambil tetap menulis ke client.
+type captureWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.buf.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.buf.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
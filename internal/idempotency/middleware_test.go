@@ -0,0 +1,290 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+
+	"github.com/Quineeryn/go-backend-101/internal/cache"
+	"github.com/Quineeryn/go-backend-101/internal/middleware"
+)
+
+type testServer struct {
+	r     *gin.Engine
+	calls atomic.Int32
+	gate  chan struct{} // kalau tidak nil, handler menunggu sebelum menjawab
+}
+
+func newServer(t *testing.T, b Backend) *testServer {
+	return newServerWith(t, b, Options{TTL: time.Minute, Paths: []string{"/v1/users"}})
+}
+
+func newServerWith(t *testing.T, b Backend, opt Options) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	ts := &testServer{r: gin.New()}
+	ts.r.Use(middleware.ErrorEnvelope())
+	ts.r.Use(Middleware(b, opt))
+	ts.r.POST("/v1/users", func(c *gin.Context) {
+		n := ts.calls.Add(1)
+		if ts.gate != nil {
+			<-ts.gate
+		}
+		c.Header("Location", "/v1/users/u1")
+		c.JSON(http.StatusCreated, gin.H{"id": "u1", "call": n})
+	})
+	ts.r.POST("/v1/other", func(c *gin.Context) {
+		ts.calls.Add(1)
+		c.Status(http.StatusNoContent)
+	})
+	return ts
+}
+
+func (ts *testServer) post(path, key, body string, hdr ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(HeaderKey, key)
+	}
+	for i := 0; i+1 < len(hdr); i += 2 {
+		req.Header.Set(hdr[i], hdr[i+1])
+	}
+	w := httptest.NewRecorder()
+	ts.r.ServeHTTP(w, req)
+	return w
+}
+
+func backends(t *testing.T) map[string]Backend {
+	mr := miniredis.RunT(t)
+	mem := cache.NewMemory(time.Minute)
+	t.Cleanup(mem.Close)
+	return map[string]Backend{
+		"memory": NewMemoryBackend(mem),
+		"redis":  NewRedisBackend(cache.NewRedis(mr.Addr(), "", 0)),
+	}
+}
+
+func TestMiddleware_ReplaysFirstResponse(t *testing.T) {
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			ts := newServer(t, b)
+			body := `{"name":"A","email":"a@example.com"}`
+
+			first := ts.post("/v1/users", "k-1", body)
+			second := ts.post("/v1/users", "k-1", body)
+
+			if first.Code != http.StatusCreated || second.Code != http.StatusCreated {
+				t.Fatalf("want 201 twice, got %d and %d", first.Code, second.Code)
+			}
+			if first.Body.String() != second.Body.String() {
+				t.Fatalf("replay body differs:\n%s\n%s", first.Body, second.Body)
+			}
+			if second.Header().Get(HeaderReplayed) != "true" || second.Header().Get("Location") != "/v1/users/u1" {
+				t.Fatalf("replay headers missing: %v", second.Header())
+			}
+			if n := ts.calls.Load(); n != 1 {
+				t.Fatalf("handler should run once, ran %d", n)
+			}
+		})
+	}
+}
+
+func TestMiddleware_DifferentBody_422(t *testing.T) {
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			ts := newServer(t, b)
+			_ = ts.post("/v1/users", "k-2", `{"email":"a@example.com"}`)
+			w := ts.post("/v1/users", "k-2", `{"email":"b@example.com"}`)
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("want 422, got %d body=%s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMiddleware_ConcurrentDuplicate_409(t *testing.T) {
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			ts := newServer(t, b)
+			ts.gate = make(chan struct{})
+
+			done := make(chan *httptest.ResponseRecorder)
+			go func() { done <- ts.post("/v1/users", "k-3", `{}`) }()
+
+			// tunggu request pertama masuk handler
+			for ts.calls.Load() == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			w := ts.post("/v1/users", "k-3", `{}`)
+			if w.Code != http.StatusConflict {
+				t.Fatalf("want 409 while in flight, got %d body=%s", w.Code, w.Body.String())
+			}
+
+			close(ts.gate)
+			if first := <-done; first.Code != http.StatusCreated {
+				t.Fatalf("first request want 201, got %d", first.Code)
+			}
+		})
+	}
+}
+
+// request lama yang selesai setelah lock-nya expired tidak boleh melepas
+// lock milik retry.
+func TestBackend_UnlockOnlyOwnToken(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mem := cache.NewMemory(time.Minute)
+	t.Cleanup(mem.Close)
+	cases := map[string]struct {
+		b      Backend
+		expire func()
+	}{
+		"memory": {NewMemoryBackend(mem), func() { time.Sleep(60 * time.Millisecond) }},
+		"redis":  {NewRedisBackend(cache.NewRedis(mr.Addr(), "", 0)), func() { mr.FastForward(time.Second) }},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx, b := context.Background(), tc.b
+			if ok, err := b.Lock(ctx, "k", "old", 50*time.Millisecond); err != nil || !ok {
+				t.Fatalf("first lock: ok=%v err=%v", ok, err)
+			}
+			tc.expire()
+			if ok, err := b.Lock(ctx, "k", "retry", time.Minute); err != nil || !ok {
+				t.Fatalf("retry lock: ok=%v err=%v", ok, err)
+			}
+
+			if err := b.Unlock(ctx, "k", "old"); err != nil {
+				t.Fatal(err)
+			}
+			if ok, _ := b.Lock(ctx, "k", "third", time.Minute); ok {
+				t.Fatal("stale unlock released the retry's lock")
+			}
+
+			if err := b.Unlock(ctx, "k", "retry"); err != nil {
+				t.Fatal(err)
+			}
+			if ok, _ := b.Lock(ctx, "k", "third", time.Minute); !ok {
+				t.Fatal("owner unlock did not release the lock")
+			}
+		})
+	}
+}
+
+func TestMiddleware_BodyTooLarge_413(t *testing.T) {
+	mem := cache.NewMemory(time.Minute)
+	t.Cleanup(mem.Close)
+	ts := newServerWith(t, NewMemoryBackend(mem), Options{TTL: time.Minute, MaxBodyBytes: 16, Paths: []string{"/v1/users"}})
+
+	w := ts.post("/v1/users", "k-big", strings.Repeat("x", 17))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("want 413, got %d body=%s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"error"`) {
+		t.Fatalf("want error envelope, got %s", w.Body.String())
+	}
+	if ts.calls.Load() != 0 {
+		t.Fatal("handler must not run")
+	}
+
+	if w := ts.post("/v1/users", "k-small", strings.Repeat("x", 16)); w.Code != http.StatusCreated {
+		t.Fatalf("body at the limit want 201, got %d", w.Code)
+	}
+}
+
+func TestMiddleware_SkipsWithoutKeyOrOtherRoutes(t *testing.T) {
+	ts := newServer(t, backends(t)["memory"])
+
+	_ = ts.post("/v1/users", "", `{}`)
+	_ = ts.post("/v1/users", "", `{}`)
+	_ = ts.post("/v1/other", "k-4", `{}`)
+	_ = ts.post("/v1/other", "k-4", `{}`)
+	if n := ts.calls.Load(); n != 4 {
+		t.Fatalf("want 4 handler calls, got %d", n)
+	}
+}
+
+// deadRedis: backend Redis yang tidak bisa dihubungi.
+func deadRedis(t *testing.T) Backend {
+	mr := miniredis.RunT(t)
+	rc := cache.NewRedis(mr.Addr(), "", 0)
+	t.Cleanup(func() { _ = rc.Close() })
+	mr.Close()
+	return NewRedisBackend(rc)
+}
+
+func TestMiddleware_RedisDown_FailModes(t *testing.T) {
+	body := `{"email":"a@example.com"}`
+
+	t.Run("open", func(t *testing.T) {
+		ts := newServer(t, deadRedis(t))
+		for i := 0; i < 2; i++ {
+			if w := ts.post("/v1/users", "k-5", body); w.Code != http.StatusCreated {
+				t.Fatalf("want 201, got %d body=%s", w.Code, w.Body.String())
+			}
+		}
+		if n := ts.calls.Load(); n != 2 {
+			t.Fatalf("fail-open runs every request, ran %d", n)
+		}
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		ts := newServerWith(t, deadRedis(t), Options{TTL: time.Minute, Paths: []string{"/v1/users"}, FailClosed: true})
+		w := ts.post("/v1/users", "k-5", body)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("want 503, got %d body=%s", w.Code, w.Body.String())
+		}
+		if n := ts.calls.Load(); n != 0 {
+			t.Fatalf("handler must not run, ran %d", n)
+		}
+		// tanpa Idempotency-Key tidak terpengaruh
+		if w := ts.post("/v1/users", "", body); w.Code != http.StatusCreated {
+			t.Fatalf("want 201 without key, got %d", w.Code)
+		}
+	})
+
+	t.Run("local", func(t *testing.T) {
+		mem := cache.NewMemory(time.Minute)
+		t.Cleanup(mem.Close)
+		ts := newServer(t, Failover{Primary: deadRedis(t), Fallback: NewMemoryBackend(mem)})
+		first := ts.post("/v1/users", "k-5", body)
+		second := ts.post("/v1/users", "k-5", body)
+		if first.Code != http.StatusCreated || second.Header().Get(HeaderReplayed) != "true" {
+			t.Fatalf("want replay from fallback, got %d / %v", second.Code, second.Header())
+		}
+		if n := ts.calls.Load(); n != 1 {
+			t.Fatalf("handler should run once, ran %d", n)
+		}
+	})
+}
+
+func TestMiddleware_KeyScopedPerUser(t *testing.T) {
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			ts := newServerWith(t, b, Options{
+				TTL:    time.Minute,
+				Paths:  []string{"/v1/users"},
+				UserID: func(c *gin.Context) string { return c.GetHeader("X-Test-User") },
+			})
+			body := `{"name":"a"}`
+			alice := ts.post("/v1/users", "shared-key", body, "X-Test-User", "alice")
+			bob := ts.post("/v1/users", "shared-key", body, "X-Test-User", "bob")
+			if bob.Header().Get(HeaderReplayed) != "" || ts.calls.Load() != 2 {
+				t.Fatalf("bob got alice's response: replayed=%q calls=%d", bob.Header().Get(HeaderReplayed), ts.calls.Load())
+			}
+			if alice.Body.String() == bob.Body.String() {
+				t.Fatalf("same body for both users: %s", bob.Body.String())
+			}
+			// retry alice tetap replay miliknya sendiri
+			if w := ts.post("/v1/users", "shared-key", body, "X-Test-User", "alice"); w.Header().Get(HeaderReplayed) != "true" || w.Body.String() != alice.Body.String() {
+				t.Fatalf("alice retry: replayed=%q body=%s", w.Header().Get(HeaderReplayed), w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Quineeryn/go-backend-101/internal/cache"
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+)
+
+// Record: response pertama yang disimpan untuk di-replay.
+type Record struct {
+	Fingerprint string              `json:"fp"`
+	Status      int                 `json:"status"`
+	Header      map[string][]string `json:"header"`
+	Body        []byte              `json:"body"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+// Backend menyimpan record + lock "in-flight" per key. Lock dipegang oleh
+// token acak per request; Unlock hanya melepas bila token masih cocok
+// (lock yang sudah expired lalu diambil retry tidak ikut terhapus).
+type Backend interface {
+	Get(ctx context.Context, key string) (*Record, error) // nil, nil bila belum ada
+	Put(ctx context.Context, key string, rec Record, ttl time.Duration) error
+	Lock(ctx context.Context, key, token string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, key, token string) error
+}
+
+// ==== Redis ====
+
+type RedisBackend struct {
+	r *cache.Redis
+}
+
+func NewRedisBackend(r *cache.Redis) *RedisBackend { return &RedisBackend{r: r} }
+
+func (b *RedisBackend) Get(ctx context.Context, key string) (*Record, error) {
+	raw, err := b.r.C.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rec Record
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (b *RedisBackend) Put(ctx context.Context, key string, rec Record, ttl time.Duration) error {
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return b.r.C.Set(ctx, key, raw, ttl).Err()
+}
+
+func (b *RedisBackend) Lock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	return b.r.C.SetNX(ctx, key+":lock", token, ttl).Result()
+}
+
+// unlockScript: DEL hanya bila lock masih milik token ini.
+var unlockScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+
+func (b *RedisBackend) Unlock(ctx context.Context, key, token string) error {
+	return unlockScript.Run(ctx, b.r.C, []string{key + ":lock"}, token).Err()
+}
+
+// ==== Memory (fallback single-replica) ====
+
+type MemoryBackend struct {
+	store cache.Store
+
+	mu    sync.Mutex
+	locks map[string]memLock
+}
+
+type memLock struct {
+	token string
+	exp   time.Time
+}
+
+func NewMemoryBackend(store cache.Store) *MemoryBackend {
+	return &MemoryBackend{store: store, locks: make(map[string]memLock)}
+}
+
+func (b *MemoryBackend) Get(_ context.Context, key string) (*Record, error) {
+	raw, ok := b.store.Get(key)
+	if !ok {
+		return nil, nil
+	}
+	var rec Record
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (b *MemoryBackend) Put(_ context.Context, key string, rec Record, ttl time.Duration) error {
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	b.store.Set(key, raw, ttl)
+	return nil
+}
+
+func (b *MemoryBackend) Lock(_ context.Context, key, token string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if l, ok := b.locks[key]; ok && now.Before(l.exp) {
+		return false, nil
+	}
+	b.locks[key] = memLock{token: token, exp: now.Add(ttl)}
+	return true, nil
+}
+
+func (b *MemoryBackend) Unlock(_ context.Context, key, token string) error {
+	b.mu.Lock()
+	if l, ok := b.locks[key]; ok && l.token == token {
+		delete(b.locks, key)
+	}
+	b.mu.Unlock()
+	return nil
+}
+
+// ==== Failover (Redis → memory) ====
+
+// Failover memakai primary, dan fallback untuk operasi yang gagal di primary
+// (mis. Redis down / breaker terbuka). Selama gangguan jaminan idempotensi
+// hanya per replica; record yang tersimpan di fallback tidak terlihat lagi
+// setelah primary pulih.
+type Failover struct {
+	Primary, Fallback Backend
+}
+
+func (f Failover) fellBack() {
+	httpx.RedisFallback.WithLabelValues("idempotency", string(cache.FailLocal)).Inc()
+}
+
+func (f Failover) Get(ctx context.Context, key string) (*Record, error) {
+	rec, err := f.Primary.Get(ctx, key)
+	if err != nil {
+		f.fellBack()
+		return f.Fallback.Get(ctx, key)
+	}
+	return rec, nil
+}
+
+func (f Failover) Put(ctx context.Context, key string, rec Record, ttl time.Duration) error {
+	if err := f.Primary.Put(ctx, key, rec, ttl); err != nil {
+		f.fellBack()
+		return f.Fallback.Put(ctx, key, rec, ttl)
+	}
+	return nil
+}
+
+func (f Failover) Lock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	ok, err := f.Primary.Lock(ctx, key, token, ttl)
+	if err != nil {
+		f.fellBack()
+		return f.Fallback.Lock(ctx, key, token, ttl)
+	}
+	return ok, nil
+}
+
+// Unlock melepas di keduanya: lock bisa diambil di fallback lalu primary
+// pulih sebelum request selesai.
+func (f Failover) Unlock(ctx context.Context, key, token string) error {
+	_ = f.Fallback.Unlock(ctx, key, token)
+	return f.Primary.Unlock(ctx, key, token)
+}
@@ -0,0 +1,63 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Quineeryn/go-backend-101/internal/cache"
+)
+
+// Purger: store yang bisa menghapus data lebih tua dari cutoff.
+type Purger interface {
+	PurgeBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// PurgeBefore: jalankan Purger dengan cutoff now - retention.
+func PurgeBefore(p Purger, retention time.Duration) Func {
+	return func(ctx context.Context) (int64, error) {
+		return p.PurgeBefore(ctx, time.Now().UTC().Add(-retention))
+	}
+}
+
+// RotateCache memastikan key cache yang cocok dengan pattern tidak hidup lebih
+// lama dari maxTTL (mis. key lama yang tersimpan tanpa expiry).
+func RotateCache(rdb redis.UniversalClient, pattern string, maxTTL time.Duration) Func {
+	return func(ctx context.Context) (int64, error) {
+		var n int64
+		err := cache.ScanKeys(ctx, rdb, pattern, func(ctx context.Context, key string) error {
+			ttl, err := rdb.TTL(ctx, key).Result()
+			if err != nil {
+				return err
+			}
+			// -1 = tanpa expiry; -2 = key sudah hilang
+			if ttl == -1 || ttl > maxTTL {
+				if err := rdb.Expire(ctx, key, maxTTL).Err(); err != nil {
+					return err
+				}
+				n++
+			}
+			return nil
+		})
+		return n, err
+	}
+}
+
+// Chain menjalankan beberapa Func berurutan; affected dijumlahkan,
+// error dikumpulkan (satu gagal tidak menghentikan yang lain).
+func Chain(fns ...Func) Func {
+	return func(ctx context.Context) (int64, error) {
+		var total int64
+		var errs []error
+		for _, fn := range fns {
+			n, err := fn(ctx)
+			total += n
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return total, errors.Join(errs...)
+	}
+}
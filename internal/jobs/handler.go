@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Quineeryn/go-backend-101/internal/apperr"
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+)
+
+// RunsHandler: GET riwayat job (?job=<name>&limit=50).
+func RunsHandler(h *History) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 500 {
+			httpx.AbortError(c, "jobs.runs", apperr.E(apperr.Validation, "limit must be between 1 and 500", err))
+			return
+		}
+		runs, err := h.Recent(c.Request.Context(), c.Query("job"), limit)
+		if err != nil {
+			httpx.AbortError(c, "jobs.runs", apperr.E(apperr.Internal, "failed to list job runs", err))
+			return
+		}
+		c.JSON(http.StatusOK, runs)
+	}
+}
@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+)
+
+type Run struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	Job        string    `json:"job" gorm:"index;not null"`
+	Instance   string    `json:"instance"`
+	Status     string    `json:"status" gorm:"not null"`
+	Affected   int64     `json:"affected"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at" gorm:"index"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+func (Run) TableName() string { return "job_runs" }
+
+// History menyimpan riwayat eksekusi job.
+type History struct {
+	db *gorm.DB
+}
+
+func NewHistory(db *gorm.DB) *History { return &History{db: db} }
+
+func AutoMigrate(db *gorm.DB) error { return db.AutoMigrate(&Run{}) }
+
+func (h *History) Record(ctx context.Context, r Run) error {
+	return h.db.WithContext(ctx).Create(&r).Error
+}
+
+// Recent: run terbaru dulu; job kosong = semua job.
+func (h *History) Recent(ctx context.Context, job string, limit int) ([]Run, error) {
+	q := h.db.WithContext(ctx).Order("started_at DESC").Limit(limit)
+	if job != "" {
+		q = q.Where("job = ?", job)
+	}
+	var out []Run
+	if err := q.Find(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PurgeBefore menghapus riwayat lebih tua dari cutoff.
+func (h *History) PurgeBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := h.db.WithContext(ctx).Where("started_at < ?", before).Delete(&Run{})
+	return res.RowsAffected, res.Error
+}
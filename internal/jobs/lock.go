@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+)
+
+// Locker memastikan satu tick job hanya dijalankan oleh satu replica.
+// Lock TIDAK dilepas setelah job selesai: key-nya per tick dan kedaluwarsa
+// sendiri, sehingga replica yang timer-nya telat tidak menjalankan ulang.
+type Locker interface {
+	TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+type RedisLocker struct {
+	rdb    redis.UniversalClient
+	prefix string
+	owner  string
+}
+
+// owner biasanya hostname/instance id, disimpan sebagai value untuk debugging.
+func NewRedisLocker(rdb redis.UniversalClient, owner string) *RedisLocker {
+	return &RedisLocker{rdb: rdb, prefix: "jobs:lock:", owner: owner}
+}
+
+func (l *RedisLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return l.rdb.SetNX(ctx, l.prefix+key, l.owner, ttl).Result()
+}
+
+// LocalLocker: fallback in-process (hanya aman untuk satu replica).
+type LocalLocker struct {
+	mu    sync.Mutex
+	locks map[string]time.Time
+}
+
+func NewLocalLocker() *LocalLocker { return &LocalLocker{locks: map[string]time.Time{}} }
+
+func (l *LocalLocker) TryLock(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for k, exp := range l.locks {
+		if now.After(exp) {
+			delete(l.locks, k)
+		}
+	}
+	if _, held := l.locks[key]; held {
+		return false, nil
+	}
+	l.locks[key] = now.Add(ttl)
+	return true, nil
+}
+
+// FallbackLocker: Primary (Redis), dan Fallback (lokal) bila Primary error.
+// Selama gangguan job bisa jalan di lebih dari satu replica; tanpa fallback
+// (Primary saja) tick dilewati.
+type FallbackLocker struct {
+	Primary, Fallback Locker
+}
+
+func (l FallbackLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ok, err := l.Primary.TryLock(ctx, key, ttl)
+	if err != nil {
+		httpx.RedisFallback.WithLabelValues("jobs", "local").Inc()
+		return l.Fallback.TryLock(ctx, key, ttl)
+	}
+	return ok, nil
+}
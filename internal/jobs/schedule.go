@@ -0,0 +1,152 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule menghitung waktu eksekusi berikutnya (selalu > after).
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// Parse menerima:
+//   - "@every <durasi>"  (mis. "@every 15m"), disejajarkan ke epoch supaya
+//     semua replica menghitung tick yang sama
+//   - "@hourly", "@daily", "@weekly"
+//   - cron 5 field: "menit jam tanggal bulan hari-minggu" (*, a-b, a,b, */n)
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	}
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("jobs: bad @every duration %q: %w", rest, err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("jobs: @every must be >= 1s, got %s", d)
+		}
+		return every(d), nil
+	}
+	return parseCron(spec)
+}
+
+type every time.Duration
+
+func (e every) Next(after time.Time) time.Time {
+	d := time.Duration(e)
+	return after.Truncate(d).Add(d)
+}
+
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64 // bitset
+	domStar, dowStar              bool
+}
+
+type field struct {
+	min, max int
+}
+
+var cronFields = [5]field{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+
+func parseCron(spec string) (Schedule, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("jobs: cron spec %q must have 5 fields", spec)
+	}
+	var bits [5]uint64
+	for i, p := range parts {
+		b, err := parseField(p, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("jobs: cron spec %q: %w", spec, err)
+		}
+		bits[i] = b
+	}
+	return &cronSchedule{
+		minute: bits[0], hour: bits[1], dom: bits[2], month: bits[3], dow: bits[4],
+		domStar: parts[2] == "*", dowStar: parts[4] == "*",
+	}, nil
+}
+
+func parseField(s string, f field) (uint64, error) {
+	var out uint64
+	for _, part := range strings.Split(s, ",") {
+		step := 1
+		if rng, st, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(st)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("bad step %q", part)
+			}
+			step, part = n, rng
+		}
+		lo, hi := f.min, f.max
+		if part != "*" {
+			a, b, isRange := strings.Cut(part, "-")
+			var err error
+			if lo, err = strconv.Atoi(a); err != nil {
+				return 0, fmt.Errorf("bad value %q", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(b); err != nil {
+					return 0, fmt.Errorf("bad range %q", part)
+				}
+			} else if step > 1 {
+				hi = f.max // "5/10" = mulai 5 tiap 10
+			}
+		}
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, fmt.Errorf("value out of range %q (%d-%d)", part, f.min, f.max)
+		}
+		for v := lo; v <= hi; v += step {
+			out |= 1 << uint(v)
+		}
+	}
+	return out, nil
+}
+
+func has(set uint64, v int) bool { return set&(1<<uint(v)) != 0 }
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom, dow := has(s.dom, t.Day()), has(s.dow, int(t.Weekday()))
+	// semantik cron klasik: kalau dua-duanya dibatasi, cukup salah satu cocok
+	if !s.domStar && !s.dowStar {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+func (s *cronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	// batas aman: 5 tahun ke depan
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(s.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
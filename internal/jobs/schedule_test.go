@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_Next(t *testing.T) {
+	base := time.Date(2025, 3, 14, 10, 7, 30, 0, time.UTC) // Jumat
+
+	cases := []struct {
+		spec string
+		want time.Time
+	}{
+		{"@every 15m", time.Date(2025, 3, 14, 10, 15, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"*/5 * * * *", time.Date(2025, 3, 14, 10, 10, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2025, 3, 15, 2, 30, 0, 0, time.UTC)},
+		{"0 9-17 * * 1-5", time.Date(2025, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 0", time.Date(2025, 3, 16, 12, 0, 0, 0, time.UTC)},
+		{"15,45 * * * *", time.Date(2025, 3, 14, 10, 15, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		s, err := Parse(tc.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.spec, err)
+		}
+		if got := s.Next(base); !got.Equal(tc.want) {
+			t.Fatalf("%q: want %s, got %s", tc.spec, tc.want, got)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "61 * * * *", "*/0 * * * *", "@every 10ms", "@every nope", "5-1 * * * *"} {
+		if _, err := Parse(spec); err == nil {
+			t.Fatalf("Parse(%q): want error", spec)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+	"github.com/Quineeryn/go-backend-101/internal/logger"
+)
+
+// Func mengembalikan jumlah baris/entry yang diproses (untuk history).
+type Func func(ctx context.Context) (int64, error)
+
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Timeout  time.Duration // default 5m
+	Run      Func
+}
+
+type Scheduler struct {
+	locker   Locker
+	history  *History // opsional
+	instance string
+
+	mu   sync.Mutex
+	jobs []Job
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewScheduler(locker Locker, history *History, instance string) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{locker: locker, history: history, instance: instance, ctx: ctx, cancel: cancel}
+}
+
+// Add mendaftarkan job; spec lihat Parse.
+func (s *Scheduler) Add(name, spec string, timeout time.Duration, fn Func) error {
+	sched, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	s.mu.Lock()
+	s.jobs = append(s.jobs, Job{Name: name, Schedule: sched, Timeout: timeout, Run: fn})
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Scheduler) Jobs() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Job(nil), s.jobs...)
+}
+
+func (s *Scheduler) Start() {
+	for _, j := range s.Jobs() {
+		s.wg.Add(1)
+		go s.loop(j)
+	}
+}
+
+// Stop membatalkan context job yang sedang jalan lalu menunggu sampai selesai
+// (atau ctx habis).
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.cancel()
+	done := make(chan struct{})
+	go func() { s.wg.Wait(); close(done) }()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) loop(j Job) {
+	defer s.wg.Done()
+	for {
+		next := j.Schedule.Next(time.Now())
+		if next.IsZero() {
+			return
+		}
+		t := time.NewTimer(time.Until(next))
+		select {
+		case <-t.C:
+			s.RunOnce(s.ctx, j, next)
+		case <-s.ctx.Done():
+			t.Stop()
+			return
+		}
+	}
+}
+
+// RunOnce menjalankan satu tick job bila lock untuk tick tsb didapat.
+func (s *Scheduler) RunOnce(ctx context.Context, j Job, tick time.Time) {
+	lockKey := fmt.Sprintf("%s:%d", j.Name, tick.Unix())
+	ok, err := s.locker.TryLock(ctx, lockKey, j.Timeout)
+	if err != nil {
+		logger.L.Warn("job.lock.failed", zap.String("job", j.Name), zap.Error(err))
+		httpx.JobRuns.WithLabelValues(j.Name, "skipped").Inc()
+		return
+	}
+	if !ok {
+		httpx.JobRuns.WithLabelValues(j.Name, "skipped").Inc()
+		return
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, j.Timeout)
+	defer cancel()
+
+	started := time.Now().UTC()
+	affected, runErr := safeRun(runCtx, j.Run)
+	finished := time.Now().UTC()
+
+	run := Run{
+		ID:         uuid.NewString(),
+		Job:        j.Name,
+		Instance:   s.instance,
+		Status:     RunSucceeded,
+		Affected:   affected,
+		StartedAt:  started,
+		FinishedAt: finished,
+	}
+	if runErr != nil {
+		run.Status = RunFailed
+		run.Error = runErr.Error()
+		logger.L.Error("job.failed", zap.String("job", j.Name), zap.Error(runErr))
+	} else {
+		httpx.JobLastSuccess.WithLabelValues(j.Name).Set(float64(finished.Unix()))
+		logger.L.Info("job.done", zap.String("job", j.Name), zap.Int64("affected", affected), zap.Duration("took", finished.Sub(started)))
+	}
+	httpx.JobRuns.WithLabelValues(j.Name, run.Status).Inc()
+	httpx.JobDuration.WithLabelValues(j.Name).Observe(finished.Sub(started).Seconds())
+
+	if s.history != nil {
+		// context terpisah: history tetap tercatat walau job di-cancel saat shutdown
+		hctx, hcancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer hcancel()
+		if err := s.history.Record(hctx, run); err != nil {
+			logger.L.Warn("job.history.failed", zap.String("job", j.Name), zap.Error(err))
+		}
+	}
+}
+
+func safeRun(ctx context.Context, fn Func) (n int64, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic: %v", rec)
+		}
+	}()
+	return fn(ctx)
+}
@@ -0,0 +1,149 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/glebarez/sqlite"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+func newHistory(t *testing.T) *History {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := AutoMigrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewHistory(db)
+}
+
+func TestRunOnce_OnlyOneReplicaPerTick(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	hist := newHistory(t)
+
+	var runs atomic.Int32
+	job := Job{Name: "count", Timeout: time.Minute, Run: func(context.Context) (int64, error) {
+		runs.Add(1)
+		return 7, nil
+	}}
+
+	a := NewScheduler(NewRedisLocker(rdb, "a"), hist, "a")
+	b := NewScheduler(NewRedisLocker(rdb, "b"), hist, "b")
+	tick := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	a.RunOnce(context.Background(), job, tick)
+	b.RunOnce(context.Background(), job, tick)
+	if n := runs.Load(); n != 1 {
+		t.Fatalf("same tick: want 1 run, got %d", n)
+	}
+
+	b.RunOnce(context.Background(), job, tick.Add(time.Minute))
+	if n := runs.Load(); n != 2 {
+		t.Fatalf("next tick: want 2 runs, got %d", n)
+	}
+
+	got, err := hist.Recent(context.Background(), "count", 10)
+	if err != nil {
+		t.Fatalf("recent: %v", err)
+	}
+	if len(got) != 2 || got[0].Affected != 7 || got[0].Status != RunSucceeded {
+		t.Fatalf("unexpected history: %+v", got)
+	}
+}
+
+func TestFallbackLocker_RedisDown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = rdb.Close() })
+	mr.Close()
+	ctx := context.Background()
+
+	if _, err := NewRedisLocker(rdb, "a").TryLock(ctx, "tick", time.Minute); err == nil {
+		t.Fatal("redis locker should fail while Redis is down")
+	}
+	l := FallbackLocker{Primary: NewRedisLocker(rdb, "a"), Fallback: NewLocalLocker()}
+	if ok, err := l.TryLock(ctx, "tick", time.Minute); !ok || err != nil {
+		t.Fatalf("first lock via fallback: ok=%v err=%v", ok, err)
+	}
+	if ok, _ := l.TryLock(ctx, "tick", time.Minute); ok {
+		t.Fatal("fallback should hold the lock for the same tick")
+	}
+}
+
+func TestRunOnce_RecordsFailureAndPanic(t *testing.T) {
+	hist := newHistory(t)
+	s := NewScheduler(NewLocalLocker(), hist, "test")
+	tick := time.Now()
+
+	s.RunOnce(context.Background(), Job{Name: "fail", Timeout: time.Second, Run: func(context.Context) (int64, error) {
+		return 0, errors.New("boom")
+	}}, tick)
+	s.RunOnce(context.Background(), Job{Name: "panic", Timeout: time.Second, Run: func(context.Context) (int64, error) {
+		panic("kaboom")
+	}}, tick)
+
+	runs, _ := hist.Recent(context.Background(), "", 10)
+	if len(runs) != 2 {
+		t.Fatalf("want 2 runs, got %d", len(runs))
+	}
+	for _, r := range runs {
+		if r.Status != RunFailed || r.Error == "" {
+			t.Fatalf("want failed run with error, got %+v", r)
+		}
+	}
+}
+
+func TestScheduler_StopCancelsRunningJob(t *testing.T) {
+	s := NewScheduler(NewLocalLocker(), nil, "test")
+	started := make(chan struct{})
+	var cancelled atomic.Bool
+	if err := s.Add("slow", "@every 1s", time.Minute, func(ctx context.Context) (int64, error) {
+		close(started)
+		<-ctx.Done()
+		cancelled.Store(true)
+		return 0, ctx.Err()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("job never started")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := s.Stop(ctx); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if !cancelled.Load() {
+		t.Fatal("running job should see context cancellation")
+	}
+}
+
+func TestChain_SumsAndJoinsErrors(t *testing.T) {
+	fn := Chain(
+		func(context.Context) (int64, error) { return 2, nil },
+		func(context.Context) (int64, error) { return 1, errors.New("x") },
+		func(context.Context) (int64, error) { return 3, nil },
+	)
+	n, err := fn(context.Background())
+	if n != 6 || err == nil {
+		t.Fatalf("want 6 + error, got %d, %v", n, err)
+	}
+}
@@ -15,9 +15,31 @@ type Config struct {
 	MaxSizeMB  int
 	MaxBackups int
 	MaxAgeDays int
+	Level      string // "debug" | "info" | "warn" | "error"; kosong = dari Env
 }
 
-var L *zap.Logger // global (aman karena diinit sekali di main)
+var L = zap.NewNop() // global; no-op sampai Init dipanggil (aman untuk test)
+
+// Level bisa diganti saat runtime (lihat SetLevel) tanpa membangun ulang L.
+var Level = zap.NewAtomicLevel()
+
+// level bawaan dari Env, dipakai saat SetLevel("")
+var defaultLevel = zap.InfoLevel
+
+// SetLevel mengganti level global, mis. saat settings di-reload.
+// "" = kembali ke default sesuai Env.
+func SetLevel(s string) error {
+	if s == "" {
+		Level.SetLevel(defaultLevel)
+		return nil
+	}
+	lvl, err := zapcore.ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	Level.SetLevel(lvl)
+	return nil
+}
 
 func Init(cfg Config) error {
 	encCfg := zap.NewProductionEncoderConfig()
@@ -26,11 +48,14 @@ func Init(cfg Config) error {
 	}
 	encCfg.TimeKey = "ts"
 
-	level := zap.InfoLevel
+	defaultLevel = zap.InfoLevel
 	if cfg.Env == "dev" {
-		level = zap.DebugLevel
+		defaultLevel = zap.DebugLevel
 		encCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	}
+	if err := SetLevel(cfg.Level); err != nil {
+		return err
+	}
 
 	encoder := zapcore.NewJSONEncoder(encCfg)
 
@@ -50,7 +75,7 @@ func Init(cfg Config) error {
 		syncs = append(syncs, zapcore.AddSync(rotate))
 	}
 
-	core := zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(syncs...), level)
+	core := zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(syncs...), Level)
 
 	// sampling untuk prod biar nggak spam
 	var opts []zap.Option
@@ -26,9 +26,9 @@ func ErrorEnvelope() gin.HandlerFunc {
 		}
 
 		gerr := c.Errors.Last() // *gin.Error
-		status := apperr.StatusFor(gerr.Err)
+		status := statusFor(c, gerr.Err)
 		msg := safeMessage(gerr.Err)
-		traceID := c.GetString("request_id")
+		traceID := traceIDFrom(c)
 
 		logger.L.Error("request.error",
 			zap.Int("status", status),
@@ -48,6 +48,24 @@ func ErrorEnvelope() gin.HandlerFunc {
 	}
 }
 
+// AppError menentukan status; untuk error biasa hormati status yang sudah
+// diset handler (mis. c.Status(401) lalu c.Error(err)).
+func statusFor(c *gin.Context, err error) int {
+	var ae *apperr.AppError
+	if !errors.As(err, &ae) && c.Writer.Status() >= http.StatusBadRequest {
+		return c.Writer.Status()
+	}
+	return apperr.StatusFor(err)
+}
+
+// traceIDFrom: EnsureCorrelationID menyimpan di "trace_id", httpx.RequestID di "request_id".
+func traceIDFrom(c *gin.Context) string {
+	if v := c.GetString(ContextTraceID); v != "" {
+		return v
+	}
+	return c.GetString("request_id")
+}
+
 // Ambil pesan aman dari AppError; fallback generik.
 func safeMessage(err error) string {
 	var ae *apperr.AppError
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/Quineeryn/go-backend-101/internal/apperr"
+	"github.com/Quineeryn/go-backend-101/internal/logger"
+)
+
+type envelope struct {
+	Code    int    `json:"code"`
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	TraceID string `json:"trace_id"`
+}
+
+func serve(t *testing.T, r *gin.Engine, path string, hdr ...string) (int, envelope) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for i := 0; i+1 < len(hdr); i += 2 {
+		req.Header.Set(hdr[i], hdr[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var e envelope
+	if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil {
+		t.Fatalf("%s: invalid envelope %q: %v", path, w.Body.String(), err)
+	}
+	return w.Code, e
+}
+
+func TestErrorEnvelope_Status(t *testing.T) {
+	logger.L = zap.NewNop()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(EnsureCorrelationID(), ErrorEnvelope())
+	// handler lama: c.Status(4xx) lalu c.Error(err biasa)
+	r.GET("/status", func(c *gin.Context) {
+		c.Status(http.StatusUnauthorized)
+		_ = c.Error(errors.New("missing token"))
+	})
+	// AppError menang atas status yang sudah diset
+	r.GET("/apperr", func(c *gin.Context) {
+		c.Status(http.StatusBadRequest)
+		_ = c.Error(apperr.E(apperr.NotFound, "user not found", nil))
+	})
+	// error biasa tanpa status = 500
+	r.GET("/plain", func(c *gin.Context) { _ = c.Error(errors.New("boom")) })
+
+	cases := []struct {
+		path string
+		want int
+		msg  string
+	}{
+		{"/status", http.StatusUnauthorized, "request failed"},
+		{"/apperr", http.StatusNotFound, "user not found"},
+		{"/plain", http.StatusInternalServerError, "request failed"},
+	}
+	for _, tc := range cases {
+		code, e := serve(t, r, tc.path, HeaderRequestID, "trace-1")
+		if code != tc.want || e.Code != tc.want || e.Message != tc.msg {
+			t.Errorf("%s: got %d %+v, want %d %q", tc.path, code, e, tc.want, tc.msg)
+		}
+		// trace id dari EnsureCorrelationID (context "trace_id")
+		if e.TraceID != "trace-1" {
+			t.Errorf("%s: trace_id = %q", tc.path, e.TraceID)
+		}
+	}
+}
+
+func TestErrorEnvelope_TraceIDFallsBackToRequestID(t *testing.T) {
+	logger.L = zap.NewNop()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("request_id", "rid-9") }, ErrorEnvelope(), RecoveryJSON())
+	r.GET("/err", func(c *gin.Context) { _ = c.Error(apperr.E(apperr.Conflict, "taken", nil)) })
+	r.GET("/panic", func(*gin.Context) { panic("boom") })
+
+	for _, path := range []string{"/err", "/panic"} {
+		if _, e := serve(t, r, path); e.TraceID != "rid-9" {
+			t.Errorf("%s: trace_id = %q, want rid-9", path, e.TraceID)
+		}
+	}
+}
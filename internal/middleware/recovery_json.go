@@ -14,7 +14,7 @@ import (
 func RecoveryJSON() gin.HandlerFunc {
 	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, rec any) {
 		err := apperr.E(apperr.Internal, "unexpected server error", nil)
-		traceID := c.GetString("request_id")
+		traceID := traceIDFrom(c)
 		logger.L.Error("panic",
 			zap.Any("recover", rec),
 			zap.String("request_id", traceID),
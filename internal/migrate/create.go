@@ -0,0 +1,56 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var nameSanitizer = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create menulis pasangan file kosong NNNNNN_name.up.sql/.down.sql di dir
+// dengan versi = versi tertinggi yang ada + 1.
+func Create(dir, name string) (up, down string, err error) {
+	name = strings.Trim(nameSanitizer.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", fmt.Errorf("migrate: empty migration name")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", "", err
+	}
+	var next int64 = 1
+	for _, e := range entries {
+		match := fileRe.FindStringSubmatch(e.Name())
+		if match == nil {
+			continue
+		}
+		if v, _ := strconv.ParseInt(match[1], 10, 64); v >= next {
+			next = v + 1
+		}
+	}
+
+	base := fmt.Sprintf("%06d_%s", next, name)
+	up = filepath.Join(dir, base+".up.sql")
+	down = filepath.Join(dir, base+".down.sql")
+	header := "-- " + base + "\n"
+	for _, f := range []string{up, down} {
+		// O_EXCL: jangan pernah menimpa migrasi yang sudah ada
+		fh, err := os.OpenFile(f, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return "", "", err
+		}
+		_, werr := fh.WriteString(header)
+		if cerr := fh.Close(); werr == nil {
+			werr = cerr
+		}
+		if werr != nil {
+			return "", "", werr
+		}
+	}
+	return up, down, nil
+}
@@ -0,0 +1,95 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Report = hasil pengecekan schema saat startup.
+type Report struct {
+	Current  int64       // versi tertinggi di schema_migrations
+	Expected int64       // versi tertinggi yang dibawa binary
+	Pending  []Migration // belum ter-apply
+	Drift    []Status    // modified / missing (tidak memblokir, cukup di-log)
+	Missing  []string    // "table x" / "column x.y" yang dibutuhkan model tapi tidak ada
+}
+
+// OK = schema cukup untuk binary ini (tidak ada migrasi pending & tidak ada
+// tabel/kolom yang hilang). Versi DB lebih baru dari binary dianggap OK
+// supaya rolling deploy/rollback binary tetap jalan.
+func (r Report) OK() bool { return len(r.Pending) == 0 && len(r.Missing) == 0 }
+
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "schema version %d, binary expects %d", r.Current, r.Expected)
+	for _, m := range r.Pending {
+		fmt.Fprintf(&b, "; pending %06d_%s", m.Version, m.Name)
+	}
+	for _, s := range r.Missing {
+		b.WriteString("; missing " + s)
+	}
+	return b.String()
+}
+
+// Verify membandingkan versi migrasi di DB dengan yang dibawa binary dan
+// mengecek tabel/kolom yang dibutuhkan models benar-benar ada.
+func Verify(ctx context.Context, r *Runner, db *gorm.DB, models ...any) (Report, error) {
+	rep := Report{Expected: Latest(r.migrations)}
+	st, err := r.Status(ctx)
+	if err != nil {
+		return rep, err
+	}
+	for _, s := range st {
+		switch s.State {
+		case StatePending:
+			if m := r.find(s.Version); m != nil {
+				rep.Pending = append(rep.Pending, *m)
+			}
+		case StateModified, StateMissing:
+			rep.Drift = append(rep.Drift, s)
+		}
+		if s.State != StatePending && s.Version > rep.Current {
+			rep.Current = s.Version
+		}
+	}
+	rep.Missing, err = MissingSchema(db.WithContext(ctx), models...)
+	return rep, err
+}
+
+// MissingSchema mendaftar tabel & kolom milik models (GORM) yang tidak ada
+// di database.
+func MissingSchema(db *gorm.DB, models ...any) ([]string, error) {
+	m := db.Migrator()
+	var missing []string
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		tbl := stmt.Schema.Table
+		if !m.HasTable(tbl) {
+			missing = append(missing, "table "+tbl)
+			continue
+		}
+		cols, err := m.ColumnTypes(tbl)
+		if err != nil {
+			return nil, err
+		}
+		have := make(map[string]bool, len(cols))
+		for _, c := range cols {
+			have[strings.ToLower(c.Name())] = true
+		}
+		for _, f := range stmt.Schema.Fields {
+			if f.DBName == "" || f.IgnoreMigration {
+				continue
+			}
+			if !have[strings.ToLower(f.DBName)] {
+				missing = append(missing, "column "+tbl+"."+f.DBName)
+			}
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,59 @@
+package migrate
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	gormsqlite "gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type guardUser struct {
+	ID           string
+	Name         string
+	PasswordHash *string
+	CreatedAt    time.Time
+}
+
+func (guardUser) TableName() string { return "a" }
+
+type guardToken struct {
+	ID string
+}
+
+func (guardToken) TableName() string { return "tokens" }
+
+func TestVerify_ReportsPendingAndMissing(t *testing.T) {
+	db := openSQLite(t)
+	r := newRunner(t, db, testFS())
+	ctx := context.Background()
+	if _, err := r.Goto(ctx, 1); err != nil {
+		t.Fatalf("goto 1: %v", err)
+	}
+	gdb, err := gorm.Open(gormsqlite.Dialector{DriverName: "sqlite", Conn: db}, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("gorm: %v", err)
+	}
+
+	rep, err := Verify(ctx, r, gdb, &guardUser{}, &guardToken{})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if rep.OK() || rep.Current != 1 || rep.Expected != 3 || len(rep.Pending) != 2 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	want := []string{"column a.name", "column a.password_hash", "column a.created_at", "table tokens"}
+	if strings.Join(rep.Missing, ",") != strings.Join(want, ",") {
+		t.Fatalf("missing = %v, want %v", rep.Missing, want)
+	}
+
+	if _, err := r.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	rep, _ = Verify(ctx, r, gdb)
+	if !rep.OK() || rep.Current != 3 {
+		t.Fatalf("after up want OK, got %s", rep)
+	}
+}
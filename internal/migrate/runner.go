@@ -0,0 +1,483 @@
+// Package migrate menjalankan migrasi SQL versioned (lihat db/migrations).
+// Versi yang sudah jalan dicatat di schema_migrations beserta checksum file
+// up, sehingga file yang diubah setelah di-apply ketahuan.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	table       = "schema_migrations"
+	legacyTable = "schema_migrations_legacy" // tabel lama golang-migrate (version, dirty)
+
+	// kunci pg_advisory_lock; bebas asal konsisten antar instance
+	advisoryLockKey int64 = 7_301_029_001
+)
+
+var ErrChecksumMismatch = errors.New("migrate: applied migration was modified")
+
+// State per versi untuk Status.
+const (
+	StateApplied  = "applied"
+	StatePending  = "pending"
+	StateModified = "modified" // sudah jalan, tapi isi file up berubah
+	StateMissing  = "missing"  // tercatat di DB, file tidak ada di binary ini
+)
+
+type Status struct {
+	Version   int64
+	Name      string
+	State     string
+	AppliedAt *time.Time
+}
+
+type applied struct {
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+type Runner struct {
+	db         *sql.DB
+	dialect    string
+	migrations []Migration
+}
+
+func New(db *sql.DB, dialect string, ms []Migration) *Runner {
+	return &Runner{db: db, dialect: dialect, migrations: ms}
+}
+
+func (r *Runner) Migrations() []Migration { return r.migrations }
+
+// Up menjalankan semua migrasi pending (urut versi). Menolak jalan kalau ada
+// migrasi ter-apply yang checksum-nya berubah atau tidak dikenal binary ini.
+func (r *Runner) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := r.withConn(ctx, func(conn *sql.Conn) error {
+		state, err := r.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := r.verify(state); err != nil {
+			return err
+		}
+		for _, m := range r.migrations {
+			if _, ok := state[m.Version]; ok {
+				continue
+			}
+			if err := r.apply(ctx, conn, m, true); err != nil {
+				return err
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down me-rollback n migrasi terakhir yang sudah ter-apply.
+func (r *Runner) Down(ctx context.Context, n int) ([]Migration, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("migrate: down needs n > 0")
+	}
+	var done []Migration
+	err := r.withConn(ctx, func(conn *sql.Conn) error {
+		state, err := r.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		versions := sortedDesc(state)
+		if n > len(versions) {
+			n = len(versions)
+		}
+		for _, v := range versions[:n] {
+			m, err := r.forRollback(v)
+			if err != nil {
+				return err
+			}
+			if err := r.apply(ctx, conn, m, false); err != nil {
+				return err
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Goto membawa schema ke versi target: up untuk versi <= target yang belum
+// jalan, down untuk versi > target yang sudah jalan.
+func (r *Runner) Goto(ctx context.Context, target int64) ([]Migration, error) {
+	if target != 0 && r.find(target) == nil {
+		return nil, fmt.Errorf("migrate: unknown version %d", target)
+	}
+	var done []Migration
+	err := r.withConn(ctx, func(conn *sql.Conn) error {
+		state, err := r.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, v := range sortedDesc(state) {
+			if v <= target {
+				break
+			}
+			m, err := r.forRollback(v)
+			if err != nil {
+				return err
+			}
+			if err := r.apply(ctx, conn, m, false); err != nil {
+				return err
+			}
+			delete(state, v)
+			done = append(done, m)
+		}
+		if err := r.verify(state); err != nil {
+			return err
+		}
+		for _, m := range r.migrations {
+			if m.Version > target {
+				break
+			}
+			if _, ok := state[m.Version]; ok {
+				continue
+			}
+			if err := r.apply(ctx, conn, m, true); err != nil {
+				return err
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status menggabungkan migrasi di binary dengan yang tercatat di DB.
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	var out []Status
+	err := r.withConn(ctx, func(conn *sql.Conn) error {
+		state, err := r.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		out = r.status(state)
+		return nil
+	})
+	return out, err
+}
+
+// Version = versi ter-apply tertinggi (0 kalau belum ada).
+func (r *Runner) Version(ctx context.Context) (int64, error) {
+	var v int64
+	err := r.withConn(ctx, func(conn *sql.Conn) error {
+		state, err := r.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if vs := sortedDesc(state); len(vs) > 0 {
+			v = vs[0]
+		}
+		return nil
+	})
+	return v, err
+}
+
+func (r *Runner) status(state map[int64]applied) []Status {
+	var out []Status
+	for _, m := range r.migrations {
+		s := Status{Version: m.Version, Name: m.Name, State: StatePending}
+		if a, ok := state[m.Version]; ok {
+			at := a.AppliedAt
+			s.AppliedAt = &at
+			s.State = StateApplied
+			if a.Checksum != m.Checksum {
+				s.State = StateModified
+			}
+		}
+		out = append(out, s)
+	}
+	for v, a := range state {
+		if r.find(v) == nil {
+			at := a.AppliedAt
+			out = append(out, Status{Version: v, Name: a.Name, State: StateMissing, AppliedAt: &at})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out
+}
+
+func (r *Runner) verify(state map[int64]applied) error {
+	var bad []string
+	for _, s := range r.status(state) {
+		switch s.State {
+		case StateModified:
+			bad = append(bad, fmt.Sprintf("%06d_%s (checksum changed)", s.Version, s.Name))
+		case StateMissing:
+			bad = append(bad, fmt.Sprintf("%06d_%s (not in this binary)", s.Version, s.Name))
+		}
+	}
+	if len(bad) > 0 {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, strings.Join(bad, ", "))
+	}
+	return nil
+}
+
+func (r *Runner) find(v int64) *Migration {
+	for i := range r.migrations {
+		if r.migrations[i].Version == v {
+			return &r.migrations[i]
+		}
+	}
+	return nil
+}
+
+func (r *Runner) forRollback(v int64) (Migration, error) {
+	m := r.find(v)
+	if m == nil {
+		return Migration{}, fmt.Errorf("migrate: version %d is applied but not in this binary", v)
+	}
+	if strings.TrimSpace(m.Down) == "" {
+		return Migration{}, fmt.Errorf("migrate: %06d_%s has no down migration", m.Version, m.Name)
+	}
+	return *m, nil
+}
+
+// withConn memakai satu koneksi untuk seluruh operasi; di Postgres koneksi
+// tsb memegang advisory lock supaya dua proses tidak migrasi bersamaan.
+func (r *Runner) withConn(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if r.dialect == DialectPostgres {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, advisoryLockKey); err != nil {
+			return fmt.Errorf("migrate: acquire lock: %w", err)
+		}
+		defer func() {
+			// ctx bisa sudah habis; unlock tetap harus terkirim
+			_, _ = conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, advisoryLockKey)
+		}()
+	}
+
+	if err := r.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (r *Runner) apply(ctx context.Context, conn *sql.Conn, m Migration, up bool) error {
+	body, dir := m.Up, "up"
+	record := fmt.Sprintf(`INSERT INTO %s (version, name, checksum, applied_at) VALUES (%s, %s, %s, %s)`,
+		table, r.ph(1), r.ph(2), r.ph(3), r.ph(4))
+	args := []any{m.Version, m.Name, m.Checksum, time.Now().UTC()}
+	if !up {
+		body, dir = m.Down, "down"
+		record = fmt.Sprintf(`DELETE FROM %s WHERE version = %s`, table, r.ph(1))
+		args = []any{m.Version}
+	}
+	wrap := func(err error) error {
+		return fmt.Errorf("migrate: %06d_%s (%s): %w", m.Version, m.Name, dir, err)
+	}
+	if up && r.dialect == DialectSQLite {
+		var err error
+		if body, err = r.skipExistingColumns(ctx, conn, body); err != nil {
+			return wrap(err)
+		}
+	}
+
+	if m.NoTx {
+		if _, err := conn.ExecContext(ctx, body); err != nil {
+			return wrap(err)
+		}
+		if _, err := conn.ExecContext(ctx, record, args...); err != nil {
+			return wrap(err)
+		}
+		return nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return wrap(err)
+	}
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		_ = tx.Rollback()
+		return wrap(err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		_ = tx.Rollback()
+		return wrap(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return wrap(err)
+	}
+	return nil
+}
+
+func (r *Runner) applied(ctx context.Context, conn *sql.Conn) (map[int64]applied, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM `+table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[int64]applied{}
+	for rows.Next() {
+		var v int64
+		var a applied
+		if err := rows.Scan(&v, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		out[v] = a
+	}
+	return out, rows.Err()
+}
+
+// ensureTable membuat schema_migrations. Kalau yang ada masih format
+// golang-migrate (version, dirty), tabel itu di-rename ke
+// schema_migrations_legacy dan versi <= versi lama dicatat sebagai applied.
+func (r *Runner) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	cols, err := r.columns(ctx, conn, table)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(cols) == 0:
+		return r.createTable(ctx, conn)
+	case cols["checksum"]:
+		return nil
+	case cols["dirty"]:
+		return r.adoptLegacy(ctx, conn)
+	default:
+		return fmt.Errorf("migrate: table %s exists with an unknown layout", table)
+	}
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func (r *Runner) createTable(ctx context.Context, ex execer) error {
+	ts := "TIMESTAMPTZ"
+	if r.dialect == DialectSQLite {
+		ts = "DATETIME" // driver sqlite hanya parse time utk decl type DATE/DATETIME/TIMESTAMP
+	}
+	_, err := ex.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		version    BIGINT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		checksum   TEXT NOT NULL,
+		applied_at %s NOT NULL
+	)`, table, ts))
+	return err
+}
+
+func (r *Runner) adoptLegacy(ctx context.Context, conn *sql.Conn) error {
+	var version int64
+	var dirty bool
+	err := conn.QueryRowContext(ctx, `SELECT version, dirty FROM `+table+` LIMIT 1`).Scan(&version, &dirty)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("migrate: legacy %s is dirty at version %d; fix it manually first", table, version)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s RENAME TO %s`, table, legacyTable)); err != nil {
+		return err
+	}
+	if err := r.createTable(ctx, tx); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for _, m := range r.migrations {
+		if m.Version > version {
+			break
+		}
+		q := fmt.Sprintf(`INSERT INTO %s (version, name, checksum, applied_at) VALUES (%s, %s, %s, %s)`,
+			table, r.ph(1), r.ph(2), r.ph(3), r.ph(4))
+		if _, err := tx.ExecContext(ctx, q, m.Version, m.Name, m.Checksum, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// addColumnRe: satu statement "ALTER TABLE t ADD COLUMN c ...;" per baris.
+var addColumnRe = regexp.MustCompile(`(?im)^[ \t]*ALTER[ \t]+TABLE[ \t]+(\w+)[ \t]+ADD[ \t]+COLUMN[ \t]+(\w+)\b[^;]*;`)
+
+// skipExistingColumns: SQLite tidak punya ADD COLUMN IF NOT EXISTS. DB dev
+// dari jalur AutoMigrate lama sudah punya sebagian kolom (mis. password_hash,
+// role), jadi ADD COLUMN untuk kolom yang sudah ada dibuang sebelum dijalankan.
+// Checksum tetap dihitung dari file asli.
+func (r *Runner) skipExistingColumns(ctx context.Context, conn *sql.Conn, body string) (string, error) {
+	cols := map[string]map[string]bool{}
+	var err error
+	out := addColumnRe.ReplaceAllStringFunc(body, func(stmt string) string {
+		if err != nil {
+			return stmt
+		}
+		sm := addColumnRe.FindStringSubmatch(stmt)
+		tbl := strings.ToLower(sm[1])
+		if cols[tbl] == nil {
+			if cols[tbl], err = r.columns(ctx, conn, tbl); err != nil {
+				return stmt
+			}
+		}
+		if cols[tbl][strings.ToLower(sm[2])] {
+			return ""
+		}
+		return stmt
+	})
+	return out, err
+}
+
+func (r *Runner) columns(ctx context.Context, conn *sql.Conn, tbl string) (map[string]bool, error) {
+	q := `SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1`
+	if r.dialect == DialectSQLite {
+		q = `SELECT name FROM pragma_table_info(?)`
+	}
+	rows, err := conn.QueryContext(ctx, q, tbl)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		out[strings.ToLower(name)] = true
+	}
+	return out, rows.Err()
+}
+
+// placeholder sesuai dialect ($1 vs ?)
+func (r *Runner) ph(i int) string {
+	if r.dialect == DialectPostgres {
+		return fmt.Sprintf("$%d", i)
+	}
+	return "?"
+}
+
+func sortedDesc(state map[int64]applied) []int64 {
+	vs := make([]int64, 0, len(state))
+	for v := range state {
+		vs = append(vs, v)
+	}
+	sort.Slice(vs, func(i, j int) bool { return vs[i] > vs[j] })
+	return vs
+}
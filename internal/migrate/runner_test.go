@@ -0,0 +1,252 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/Quineeryn/go-backend-101/db/migrations"
+)
+
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"000001_a.up.sql":          {Data: []byte("CREATE TABLE a (id TEXT);")},
+		"000001_a.down.sql":        {Data: []byte("DROP TABLE a;")},
+		"000002_b.up.sql":          {Data: []byte("CREATE TABLE b (id TEXT);")},
+		"000002_b.down.sql":        {Data: []byte("DROP TABLE b;")},
+		"000003_c.up.sql":          {Data: []byte("CREATE TABLE c (id TIMESTAMPTZ);")},
+		"000003_c.down.sql":        {Data: []byte("DROP TABLE c;")},
+		"sqlite/000003_c.up.sql":   {Data: []byte("CREATE TABLE c (id DATETIME);")},
+		"sqlite/000003_c.down.sql": {Data: []byte("DROP TABLE c;")},
+	}
+}
+
+func newRunner(t *testing.T, db *sql.DB, fsys fstest.MapFS) *Runner {
+	t.Helper()
+	ms, err := Load(fsys, DialectSQLite)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	return New(db, DialectSQLite, ms)
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+	var n int
+	_ = db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type='table' AND name=?`, name).Scan(&n)
+	return n == 1
+}
+
+func TestLoad_SQLiteOverrideAndBadNames(t *testing.T) {
+	ms, err := Load(testFS(), DialectSQLite)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(ms) != 3 || ms[2].Up != "CREATE TABLE c (id DATETIME);" {
+		t.Fatalf("sqlite override not applied: %+v", ms)
+	}
+	pg, _ := Load(testFS(), DialectPostgres)
+	if pg[2].Up != "CREATE TABLE c (id TIMESTAMPTZ);" || pg[2].Checksum == ms[2].Checksum {
+		t.Fatalf("postgres should use root file")
+	}
+
+	bad := testFS()
+	bad["0002_auth.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	if _, err := Load(bad, DialectPostgres); err == nil {
+		t.Fatal("want error for file not following naming scheme")
+	}
+}
+
+func TestRunner_UpDownGotoStatus(t *testing.T) {
+	db := openSQLite(t)
+	r := newRunner(t, db, testFS())
+	ctx := context.Background()
+
+	done, err := r.Up(ctx)
+	if err != nil || len(done) != 3 {
+		t.Fatalf("up: done=%d err=%v", len(done), err)
+	}
+	if again, err := r.Up(ctx); err != nil || len(again) != 0 {
+		t.Fatalf("second up should be no-op: done=%d err=%v", len(again), err)
+	}
+
+	if _, err := r.Down(ctx, 2); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if v, _ := r.Version(ctx); v != 1 || tableExists(t, db, "b") || !tableExists(t, db, "a") {
+		t.Fatalf("after down 2: version=%d", v)
+	}
+
+	if _, err := r.Goto(ctx, 2); err != nil {
+		t.Fatalf("goto 2: %v", err)
+	}
+	st, err := r.Status(ctx)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	want := []string{StateApplied, StateApplied, StatePending}
+	for i, s := range st {
+		if s.State != want[i] {
+			t.Fatalf("status[%d]=%s want %s", i, s.State, want[i])
+		}
+	}
+
+	if _, err := r.Goto(ctx, 0); err != nil {
+		t.Fatalf("goto 0: %v", err)
+	}
+	if v, _ := r.Version(ctx); v != 0 || tableExists(t, db, "a") {
+		t.Fatalf("goto 0 should roll back everything, version=%d", v)
+	}
+}
+
+func TestRunner_RefusesModifiedMigration(t *testing.T) {
+	db := openSQLite(t)
+	ctx := context.Background()
+	if _, err := newRunner(t, db, testFS()).Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+
+	edited := testFS()
+	edited["000002_b.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE b (id TEXT, x TEXT);")}
+	edited["000004_d.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE d (id TEXT);")}
+	r := newRunner(t, db, edited)
+
+	if _, err := r.Up(ctx); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("want ErrChecksumMismatch, got %v", err)
+	}
+	if tableExists(t, db, "d") {
+		t.Fatal("pending migration must not run when checksums mismatch")
+	}
+	st, _ := r.Status(ctx)
+	if st[1].State != StateModified {
+		t.Fatalf("want modified state, got %s", st[1].State)
+	}
+}
+
+func TestRunner_FailedMigrationRollsBack(t *testing.T) {
+	db := openSQLite(t)
+	fsys := testFS()
+	fsys["000004_bad.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE d (id TEXT); SELECT * FROM nope;")}
+	r := newRunner(t, db, fsys)
+
+	if _, err := r.Up(context.Background()); err == nil {
+		t.Fatal("want error from broken migration")
+	}
+	if tableExists(t, db, "d") {
+		t.Fatal("partial migration should be rolled back")
+	}
+	if v, _ := r.Version(context.Background()); v != 3 {
+		t.Fatalf("want version 3 after failure, got %d", v)
+	}
+}
+
+func TestRunner_AdoptsLegacyTable(t *testing.T) {
+	db := openSQLite(t)
+	if _, err := db.Exec(`CREATE TABLE schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL);
+		INSERT INTO schema_migrations VALUES (2, false);
+		CREATE TABLE a (id TEXT); CREATE TABLE b (id TEXT);`); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	done, err := newRunner(t, db, testFS()).Up(context.Background())
+	if err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if len(done) != 1 || done[0].Version != 3 {
+		t.Fatalf("only version 3 should run, got %+v", done)
+	}
+	if !tableExists(t, db, legacyTable) {
+		t.Fatal("legacy table should be kept as backup")
+	}
+}
+
+// migrasi yang di-embed harus bisa jalan penuh up → down → up di SQLite
+func TestEmbeddedMigrations_SQLite(t *testing.T) {
+	ms, err := Load(migrations.FS, DialectSQLite)
+	if err != nil {
+		t.Fatalf("load embedded: %v", err)
+	}
+	db := openSQLite(t)
+	r := New(db, DialectSQLite, ms)
+	ctx := context.Background()
+
+	if _, err := r.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	for _, tbl := range []string{"users", "refresh_tokens", "webhook_subscriptions", "webhook_deliveries", "job_runs", "tenants"} {
+		if !tableExists(t, db, tbl) {
+			t.Fatalf("missing table %s", tbl)
+		}
+	}
+	if _, err := r.Goto(ctx, 0); err != nil {
+		t.Fatalf("down all: %v", err)
+	}
+	if _, err := r.Up(ctx); err != nil {
+		t.Fatalf("up again: %v", err)
+	}
+	if v, _ := r.Version(ctx); v != Latest(ms) {
+		t.Fatalf("want version %d, got %d", Latest(ms), v)
+	}
+}
+
+// DB dev lama dibuat lewat GORM AutoMigrate (tanpa schema_migrations) dan
+// sudah punya password_hash/role; migrate up harus tetap jalan.
+func TestEmbeddedMigrations_SQLiteFromAutoMigrate(t *testing.T) {
+	ms, err := Load(migrations.FS, DialectSQLite)
+	if err != nil {
+		t.Fatalf("load embedded: %v", err)
+	}
+	db := openSQLite(t)
+	// DDL persis hasil AutoMigrate(&users.User{}, &auth.RefreshToken{}) lama
+	if _, err := db.Exec("CREATE TABLE `users` (`id` text,`name` text,`email` text,`role` text DEFAULT \"user\",`password_hash` text,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`));" +
+		"CREATE TABLE `refresh_tokens` (`id` text,`user_id` text,`jti` text,`expires_at` datetime,`revoked_at` datetime,`created_at` datetime,PRIMARY KEY (`id`));" +
+		"CREATE INDEX `idx_refresh_tokens_jti` ON `refresh_tokens`(`jti`);" +
+		"INSERT INTO users (id, name, email, role, password_hash) VALUES ('u1', 'A', 'a@example.com', 'admin', 'h');"); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	r := New(db, DialectSQLite, ms)
+	if _, err := r.Up(context.Background()); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if v, _ := r.Version(context.Background()); v != Latest(ms) {
+		t.Fatalf("want version %d, got %d", Latest(ms), v)
+	}
+	var role, hash, tenantID string
+	if err := db.QueryRow(`SELECT role, password_hash, tenant_id FROM users WHERE id = 'u1'`).Scan(&role, &hash, &tenantID); err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if role != "admin" || hash != "h" || tenantID != "default" {
+		t.Fatalf("existing row changed: role=%q hash=%q tenant=%q", role, hash, tenantID)
+	}
+}
+
+func TestCreate_NextVersion(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "000007_x.up.sql"), nil, 0o644)
+
+	up, down, err := Create(dir, "Add Orders-Table")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if filepath.Base(up) != "000008_add_orders_table.up.sql" || filepath.Base(down) != "000008_add_orders_table.down.sql" {
+		t.Fatalf("unexpected names %s %s", up, down)
+	}
+}
@@ -0,0 +1,107 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DialectPostgres = "postgres"
+	DialectSQLite   = "sqlite"
+
+	// taruh di baris mana pun dalam file untuk menjalankan migrasi di luar
+	// transaksi (mis. CREATE INDEX CONCURRENTLY)
+	noTxDirective = "-- migrate:no-transaction"
+)
+
+// NNNNNN_nama.up.sql / NNNNNN_nama.down.sql
+var fileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string // kosong = tidak bisa di-rollback
+	Checksum string // sha256 dari Up
+	NoTx     bool
+}
+
+// Load membaca migrasi dari root fsys. Untuk dialect sqlite, file di
+// subfolder sqlite/ menimpa file root dengan versi & arah yang sama.
+// File yang namanya tidak mengikuti pola ditolak (bukan diabaikan diam-diam).
+func Load(fsys fs.FS, dialect string) ([]Migration, error) {
+	byVersion := map[int64]*Migration{}
+	if err := loadDir(fsys, ".", byVersion); err != nil {
+		return nil, err
+	}
+	if dialect == DialectSQLite {
+		if _, err := fs.Stat(fsys, "sqlite"); err == nil {
+			if err := loadDir(fsys, "sqlite", byVersion); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %06d_%s: missing .up.sql", m.Version, m.Name)
+		}
+		sum := sha256.Sum256([]byte(m.Up))
+		m.Checksum = hex.EncodeToString(sum[:])
+		m.NoTx = strings.Contains(m.Up, noTxDirective) || strings.Contains(m.Down, noTxDirective)
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+func loadDir(fsys fs.FS, dir string, byVersion map[int64]*Migration) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".sql") {
+			continue
+		}
+		match := fileRe.FindStringSubmatch(e.Name())
+		if match == nil {
+			return fmt.Errorf("migration file %q does not match NNNNNN_name.(up|down).sql", path.Join(dir, e.Name()))
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		body, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] && dir == "." {
+			return fmt.Errorf("migration version %d used by both %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+	return nil
+}
+
+// Latest = versi tertinggi yang dibawa binary (0 kalau tidak ada migrasi).
+func Latest(ms []Migration) int64 {
+	if len(ms) == 0 {
+		return 0
+	}
+	return ms[len(ms)-1].Version
+}
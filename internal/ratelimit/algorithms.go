@@ -0,0 +1,143 @@
+package ratelimit
+
+import "math"
+
+// Langkah tiap algoritma untuk varian memory. Semua waktu dalam µs dan
+// urutan operasi float sengaja sama dengan script Lua di redis_scripts.go,
+// supaya varian memory dan Redis memberi keputusan yang identik.
+
+// state per key; field yang dipakai tergantung algoritma.
+type state struct {
+	init bool
+	f    float64 // token bucket: tokens; gcra: TAT (µs)
+	t    int64   // token bucket: last (µs); sliding window: indeks window
+	cur  int64   // sliding window: hitungan window ini
+	prev int64   // sliding window: hitungan window sebelumnya
+	log  []int64 // sliding log: waktu request yang lolos, terurut
+}
+
+// outcome: hasil mentah satu langkah (µs).
+type outcome struct {
+	allowed   bool
+	remaining int64
+	retry     int64
+	reset     int64
+}
+
+type stepFunc func(s *state, now int64, l Limit) outcome
+
+var steps = map[Algorithm]stepFunc{
+	TokenBucket:   tokenBucketStep,
+	SlidingLog:    slidingLogStep,
+	SlidingWindow: slidingWindowStep,
+	GCRA:          gcraStep,
+}
+
+// floor dengan toleransi galat float (0.9999999 → 1)
+func floorEps(f float64) int64 { return int64(math.Floor(f + 1e-9)) }
+
+func ceilUs(f float64) int64 { return int64(math.Ceil(f)) }
+
+// tokenBucketStep: bucket berisi Burst token, terisi Rate per detik.
+func tokenBucketStep(s *state, now int64, l Limit) outcome {
+	burst := float64(l.Burst)
+	if !s.init {
+		s.init, s.f, s.t = true, burst, now
+	} else if now > s.t {
+		s.f = math.Min(burst, s.f+(float64(now-s.t)/1e6)*l.Rate)
+		s.t = now
+	}
+	var o outcome
+	if s.f >= 1 {
+		s.f--
+		o.allowed = true
+	} else {
+		o.retry = ceilUs((1 - s.f) / l.Rate * 1e6)
+	}
+	o.remaining = floorEps(s.f)
+	o.reset = ceilUs((burst - s.f) / l.Rate * 1e6)
+	return o
+}
+
+// slidingLogStep: simpan waktu setiap request yang lolos; lolos bila dalam
+// window terakhir jumlahnya < Burst.
+func slidingLogStep(s *state, now int64, l Limit) outcome {
+	w := l.window()
+	i := 0
+	for i < len(s.log) && s.log[i] <= now-w {
+		i++
+	}
+	s.log = s.log[i:]
+	var o outcome
+	if int64(len(s.log)) < int64(l.Burst) {
+		s.log = append(s.log, now)
+		o.allowed = true
+	} else {
+		o.retry = s.log[0] + w - now
+	}
+	o.remaining = int64(l.Burst) - int64(len(s.log))
+	if n := len(s.log); n > 0 {
+		o.reset = s.log[n-1] + w - now
+	}
+	return o
+}
+
+// slidingWindowStep: counter window tetap ini + window sebelumnya yang
+// dibobot sisa porsinya di window geser.
+func slidingWindowStep(s *state, now int64, l Limit) outcome {
+	w := l.window()
+	idx := now / w
+	elapsed := now - idx*w
+	if !s.init || idx != s.t {
+		if s.init && idx == s.t+1 {
+			s.prev = s.cur
+		} else {
+			s.prev = 0
+		}
+		s.init, s.t, s.cur = true, idx, 0
+	}
+	burst := float64(l.Burst)
+	est := float64(s.prev)*(float64(w-elapsed)/float64(w)) + float64(s.cur)
+	var o outcome
+	if est+1 <= burst {
+		s.cur++
+		est++
+		o.allowed = true
+	} else if float64(s.cur)+1 > burst {
+		// window ini penuh: tunggu window berikutnya sampai bobot counter
+		// ini cukup turun
+		need := float64(w) * (1 - (burst-1)/float64(s.cur))
+		o.retry = (w - elapsed) + ceilUs(math.Max(0, need))
+	} else {
+		o.retry = ceilUs(float64(w-elapsed) - float64(w)*(burst-1-float64(s.cur))/float64(s.prev))
+	}
+	o.remaining = max(0, floorEps(burst-est))
+	switch {
+	case s.cur > 0:
+		o.reset = 2*w - elapsed
+	case s.prev > 0:
+		o.reset = w - elapsed
+	}
+	return o
+}
+
+// gcraStep: Generic Cell Rate Algorithm — hanya menyimpan theoretical
+// arrival time (TAT). Emission interval T = 1/Rate, toleransi = Burst·T.
+func gcraStep(s *state, now int64, l Limit) outcome {
+	t := 1e6 / l.Rate
+	tau := t * float64(l.Burst)
+	tat := math.Max(s.f, float64(now))
+	newTat := tat + t
+	allowAt := newTat - tau
+	var o outcome
+	if float64(now) < allowAt {
+		o.retry = ceilUs(allowAt - float64(now))
+		o.reset = ceilUs(tat - float64(now))
+		return o
+	}
+	s.f = newTat
+	o.allowed = true
+	o.remaining = max(0, floorEps((tau-(newTat-float64(now)))/t))
+	o.reset = ceilUs(newTat - float64(now))
+	return o
+}
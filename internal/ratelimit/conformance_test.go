@@ -0,0 +1,199 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// Suite bersama: setiap algoritma × varian (memory, Redis via miniredis)
+// harus lolos skenario yang sama dengan waktu simulasi.
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newFakeClock() *fakeClock { return &fakeClock{now: time.Unix(1_760_000_000, 0)} }
+
+type variant struct {
+	name string
+	new  func(t *testing.T, alg Algorithm, clock Clock) Limiter
+}
+
+var variants = []variant{
+	{"memory", func(t *testing.T, alg Algorithm, clock Clock) Limiter {
+		l, err := NewMemory(alg, clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return l
+	}},
+	{"redis", func(t *testing.T, alg Algorithm, clock Clock) Limiter {
+		mr := miniredis.RunT(t)
+		rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { _ = rdb.Close() })
+		l, err := NewRedis(rdb, alg, clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return l
+	}},
+}
+
+func forEach(t *testing.T, fn func(t *testing.T, alg Algorithm, lim Limiter, clock *fakeClock)) {
+	for _, v := range variants {
+		for _, alg := range Algorithms {
+			t.Run(v.name+"/"+string(alg), func(t *testing.T) {
+				clock := newFakeClock()
+				fn(t, alg, v.new(t, alg, clock.Now), clock)
+			})
+		}
+	}
+}
+
+func allow(t *testing.T, lim Limiter, key string, l Limit) Result {
+	t.Helper()
+	res, err := lim.Allow(context.Background(), key, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestConformance_Burst(t *testing.T) {
+	l := Limit{Rate: 2, Burst: 5}
+	forEach(t, func(t *testing.T, _ Algorithm, lim Limiter, _ *fakeClock) {
+		for i := 0; i < l.Burst; i++ {
+			res := allow(t, lim, "k", l)
+			if !res.Allowed || res.Limit != l.Burst || res.Remaining != l.Burst-i-1 {
+				t.Fatalf("request %d: %+v", i, res)
+			}
+		}
+		res := allow(t, lim, "k", l)
+		if res.Allowed || res.Remaining != 0 || res.RetryAfter <= 0 || res.ResetAfter <= 0 {
+			t.Fatalf("request over burst: %+v", res)
+		}
+		// key lain punya kuota sendiri
+		if res := allow(t, lim, "other", l); !res.Allowed {
+			t.Fatalf("independent key denied: %+v", res)
+		}
+	})
+}
+
+func TestConformance_RetryAfterIsAccurate(t *testing.T) {
+	l := Limit{Rate: 3, Burst: 2}
+	forEach(t, func(t *testing.T, _ Algorithm, lim Limiter, clock *fakeClock) {
+		denied := 0
+		for i := 0; i < 200; i++ {
+			res := allow(t, lim, "k", l)
+			if res.Allowed {
+				clock.Advance(37 * time.Millisecond)
+				continue
+			}
+			denied++
+			clock.Advance(res.RetryAfter)
+			if res := allow(t, lim, "k", l); !res.Allowed {
+				t.Fatalf("still denied after RetryAfter: %+v", res)
+			}
+		}
+		if denied == 0 {
+			t.Fatal("scenario never hit the limit")
+		}
+	})
+}
+
+func TestConformance_LongRunThroughput(t *testing.T) {
+	l := Limit{Rate: 10, Burst: 5}
+	const dur = 30 * time.Second
+	forEach(t, func(t *testing.T, alg Algorithm, lim Limiter, clock *fakeClock) {
+		var allowed []time.Time
+		for start := clock.Now(); clock.Now().Sub(start) < dur; clock.Advance(7 * time.Millisecond) {
+			if allow(t, lim, "k", l).Allowed {
+				allowed = append(allowed, clock.Now())
+			}
+		}
+		// jangka panjang ≈ Burst + Rate·D
+		want := float64(l.Burst) + l.Rate*dur.Seconds()
+		lo := want * 0.95
+		if alg == SlidingWindow {
+			// estimasi tertimbang konservatif saat jenuh: ±(Burst-1) per window
+			lo = want * float64(l.Burst-1) / float64(l.Burst) * 0.95
+		}
+		if got := float64(len(allowed)); got < lo || got > want*1.02 {
+			t.Fatalf("allowed %d over %s, want ≈ %.0f", len(allowed), dur, want)
+		}
+		// di window mana pun: sliding log tepat ≤ Burst, lainnya ≤ Burst + Rate·W
+		w := time.Duration(l.window()) * time.Microsecond
+		bound := 2 * l.Burst
+		if alg == SlidingLog {
+			bound = l.Burst
+		}
+		j := 0
+		for i := range allowed {
+			for allowed[i].Sub(allowed[j]) >= w {
+				j++
+			}
+			if n := i - j + 1; n > bound {
+				t.Fatalf("%d requests within %s at %s (bound %d)", n, w, allowed[i].Sub(allowed[0]), bound)
+			}
+		}
+	})
+}
+
+func TestConformance_IdleRefillsFully(t *testing.T) {
+	l := Limit{Rate: 1, Burst: 3}
+	forEach(t, func(t *testing.T, _ Algorithm, lim Limiter, clock *fakeClock) {
+		var last Result
+		for i := 0; i < 10; i++ {
+			last = allow(t, lim, "k", l)
+		}
+		clock.Advance(last.ResetAfter)
+		for i := 0; i < l.Burst; i++ {
+			if res := allow(t, lim, "k", l); !res.Allowed {
+				t.Fatalf("request %d after ResetAfter denied: %+v", i, res)
+			}
+		}
+	})
+}
+
+// Varian memory dan Redis harus memberi keputusan identik untuk urutan
+// request yang sama (termasuk Remaining/RetryAfter), supaya fallback lokal
+// dan perpindahan backend tidak mengubah perilaku.
+func TestConformance_MemoryMatchesRedis(t *testing.T) {
+	limits := []Limit{{Rate: 3.7, Burst: 4}, {Rate: 0.2, Burst: 5}, {Rate: 50, Burst: 1}}
+	for _, alg := range Algorithms {
+		t.Run(string(alg), func(t *testing.T) {
+			clock := newFakeClock()
+			mem := variants[0].new(t, alg, clock.Now)
+			rds := variants[1].new(t, alg, clock.Now)
+			rnd := rand.New(rand.NewSource(1))
+			for i := 0; i < 2000; i++ {
+				clock.Advance(time.Duration(rnd.Intn(400_000)) * time.Microsecond)
+				l := limits[i%len(limits)]
+				key := fmt.Sprintf("k%d:%d", i%len(limits), rnd.Intn(2))
+				a, b := allow(t, mem, key, l), allow(t, rds, key, l)
+				if a != b {
+					t.Fatalf("step %d %s: memory %+v != redis %+v", i, key, a, b)
+				}
+			}
+		})
+	}
+}
+
+func TestNew_UnknownAlgorithm(t *testing.T) {
+	if _, err := NewMemory("leaky", nil); err == nil {
+		t.Error("NewMemory: want error")
+	}
+	if _, err := NewRedis(nil, "leaky", nil); err == nil {
+		t.Error("NewRedis: want error")
+	}
+	if _, err := ParseAlgorithm("gcra"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// namedResult: hasil satu limit beserta nama policy-nya di header.
+type namedResult struct {
+	policy string
+	Result
+}
+
+// setHeaders menulis header rate limit di setiap response: format IETF
+// (draft-ietf-httpapi-ratelimit-headers: RateLimit-Policy & RateLimit,
+// satu item per limit) dan legacy X-RateLimit-* dari limit yang paling
+// ketat. Reset dalam detik relatif, bukan epoch. Retry-After hanya saat
+// ditolak.
+func setHeaders(c *gin.Context, rs ...namedResult) {
+	if len(rs) == 0 {
+		return
+	}
+	policies := make([]string, len(rs))
+	states := make([]string, len(rs))
+	tight := rs[0]
+	for i, r := range rs {
+		q := strconv.Quote(r.policy)
+		policies[i] = q + ";q=" + itoa(r.Limit) + ";w=" + itoa(max(1, ceilSeconds(r.Window)))
+		states[i] = q + ";r=" + itoa(r.Remaining) + ";t=" + itoa(ceilSeconds(r.ResetAfter))
+		if !tight.Allowed {
+			continue
+		}
+		if !r.Allowed || r.Remaining < tight.Remaining {
+			tight = r
+		}
+	}
+	c.Header("RateLimit-Policy", strings.Join(policies, ", "))
+	c.Header("RateLimit", strings.Join(states, ", "))
+	c.Header("X-RateLimit-Limit", itoa(tight.Limit))
+	c.Header("X-RateLimit-Remaining", itoa(tight.Remaining))
+	c.Header("X-RateLimit-Reset", itoa(ceilSeconds(tight.ResetAfter)))
+	if !tight.Allowed {
+		c.Header("Retry-After", formatRetryAfter(tight.RetryAfter))
+	}
+}
+
+// ceilSeconds: dibulatkan ke atas supaya client yang patuh tidak mencoba
+// terlalu cepat.
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
 )
 
 func clientIP(c *gin.Context) string {
@@ -23,13 +25,19 @@ func clientIP(c *gin.Context) string {
 	return ip
 }
 
-// KeyPerIP per route (default)
+// KeyPerIP per route (default). Semua key diawali tenant: kuota satu
+// tenant tidak menghabiskan kuota tenant lain.
 func KeyPerIP(c *gin.Context) string {
-	return "ip:" + clientIP(c) + ":path:" + c.FullPath()
+	return "t:" + tenant.ID(c) + ":ip:" + clientIP(c) + ":path:" + c.FullPath()
 }
 
 // KeyLogin — gabung IP + email (non-destructive bind, body tetap bisa dipakai handler)
 func KeyLogin(c *gin.Context) string {
+	return "login:" + tenant.ID(c) + ":" + clientIP(c) + ":" + loginEmail(c)
+}
+
+// loginEmail: field email dari body JSON tanpa mengkonsumsi body.
+func loginEmail(c *gin.Context) string {
 	var email string
 
 	if c.Request.Body != nil {
@@ -46,6 +54,5 @@ func KeyLogin(c *gin.Context) string {
 		email = b.Email
 	}
 
-	email = strings.ToLower(strings.TrimSpace(email))
-	return "login:" + clientIP(c) + ":" + email
+	return strings.ToLower(strings.TrimSpace(email))
 }
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Limit: kuota per key. Burst = request sekaligus (kapasitas bucket /
+// jumlah per window), Rate = rata-rata per detik. Algoritma window memakai
+// window = Burst / Rate, jadi Limit yang sama memberi throughput jangka
+// panjang yang sama di semua algoritma.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// window: panjang window (µs) untuk algoritma sliding.
+func (l Limit) window() int64 { return int64(float64(l.Burst) / l.Rate * 1e6) }
+
+// Result satu keputusan Allow.
+type Result struct {
+	Allowed    bool
+	Limit      int           // = Burst
+	Remaining  int           // sisa kuota setelah request ini
+	RetryAfter time.Duration // > 0 bila ditolak: kapan request berikutnya lolos
+	ResetAfter time.Duration // kapan kuota penuh lagi
+	Window     time.Duration // periode kuota = Burst / Rate (RateLimit-Policy w)
+}
+
+// Limiter memutuskan satu request untuk key. Implementasi aman konkuren;
+// error hanya dari backend (Redis).
+type Limiter interface {
+	Allow(ctx context.Context, key string, l Limit) (Result, error)
+}
+
+type Algorithm string
+
+const (
+	TokenBucket   Algorithm = "token_bucket"
+	SlidingLog    Algorithm = "sliding_log"    // presisi, memori O(burst) per key
+	SlidingWindow Algorithm = "sliding_window" // dua counter, estimasi tertimbang
+	GCRA          Algorithm = "gcra"           // setara token bucket, state satu angka
+)
+
+// Algorithms: semua nilai yang valid (urutan untuk pesan error/config).
+var Algorithms = []Algorithm{TokenBucket, SlidingLog, SlidingWindow, GCRA}
+
+func ParseAlgorithm(s string) (Algorithm, error) {
+	for _, a := range Algorithms {
+		if string(a) == s {
+			return a, nil
+		}
+	}
+	return "", fmt.Errorf("ratelimit: unknown algorithm %q", s)
+}
+
+// Clock: sumber waktu; bisa diganti di test (waktu simulasi).
+type Clock func() time.Time
+
+// NewMemory: limiter in-process (per replica).
+func NewMemory(alg Algorithm, clock Clock) (Limiter, error) {
+	step, ok := steps[alg]
+	if !ok {
+		return nil, fmt.Errorf("ratelimit: unknown algorithm %q", alg)
+	}
+	if clock == nil {
+		clock = time.Now
+	}
+	return newMemory(step, clock), nil
+}
+
+func micros(us int64) time.Duration { return time.Duration(us) * time.Microsecond }
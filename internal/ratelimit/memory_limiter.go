@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryLimiter: varian in-process untuk semua algoritma. Key idle dibuang
+// secara lazy (sweep paling sering sekali per menit) — tanpa goroutine,
+// jadi tidak perlu Close.
+type memoryLimiter struct {
+	step  stepFunc
+	clock Clock
+
+	mu        sync.Mutex
+	items     map[string]*memItem
+	lastSweep int64
+}
+
+type memItem struct {
+	st      state
+	expires int64 // µs; setelah ini state sama dengan key baru
+}
+
+const sweepEvery = int64(time.Minute / time.Microsecond)
+
+func newMemory(step stepFunc, clock Clock) *memoryLimiter {
+	return &memoryLimiter{step: step, clock: clock, items: map[string]*memItem{}}
+}
+
+func (m *memoryLimiter) Allow(_ context.Context, key string, l Limit) (Result, error) {
+	now := m.clock().UnixMicro()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if now-m.lastSweep > sweepEvery {
+		for k, it := range m.items {
+			if it.expires < now {
+				delete(m.items, k)
+			}
+		}
+		m.lastSweep = now
+	}
+	it, ok := m.items[key]
+	if !ok || it.expires < now {
+		it = &memItem{}
+		m.items[key] = it
+	}
+	o := m.step(&it.st, now, l)
+	it.expires = now + o.reset + int64(time.Second/time.Microsecond)
+	return o.result(l), nil
+}
+
+func (o outcome) result(l Limit) Result {
+	return Result{
+		Allowed:    o.allowed,
+		Limit:      l.Burst,
+		Remaining:  int(o.remaining),
+		RetryAfter: micros(o.retry),
+		ResetAfter: micros(o.reset),
+		Window:     micros(l.window()),
+	}
+}
@@ -1,61 +1,49 @@
 package ratelimit
 
 import (
-	"errors"
-	"fmt"
-	"net/http"
+	"math"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
+
+	"github.com/Quineeryn/go-backend-101/internal/apperr"
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
 )
 
-// Middleware: tolak request jika melebihi limit.
-// Pasang header: Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining (approx).
+// Middleware: tolak request jika melebihi limit (in-process, per replica).
+// Header rate limit (lihat setHeaders) dipasang di setiap response; 429
+// lewat error envelope standar.
 func Middleware(store *Store, keyFn func(*gin.Context) string, rps rate.Limit, burst int) gin.HandlerFunc {
+	window := micros(Limit{Rate: float64(rps), Burst: burst}.window())
 	return func(c *gin.Context) {
 		key := keyFn(c)
 		lim := store.Get(key, rps, burst)
 
-		// ✅ konsumsi 1 token kalau boleh sekarang
-		if lim.Allow() {
-			// (opsional) expose policy di response sukses juga:
-			c.Header("X-RateLimit-Policy", fmt.Sprintf("rps=%.2f; burst=%d", float64(rps), burst))
-			c.Next()
-			return
+		now := time.Now()
+		res := Result{Limit: burst, Window: window, Allowed: lim.AllowN(now, 1)}
+		if !res.Allowed {
+			// hitung delay berikutnya tanpa mengkonsumsi token
+			if r := lim.ReserveN(now, 1); r.OK() {
+				res.RetryAfter = r.DelayFrom(now)
+				r.CancelAt(now)
+			}
 		}
+		tokens := lim.TokensAt(now)
+		res.Remaining = max(0, int(math.Floor(tokens+1e-9)))
+		res.ResetAfter = time.Duration(math.Ceil((float64(burst) - tokens) / float64(rps) * float64(time.Second)))
+		setHeaders(c, namedResult{"default", res})
 
-		// ❌ tidak boleh sekarang → hitung delay berikutnya tanpa mengkonsumsi token
-		res := lim.ReserveN(time.Now(), 1)
-		if !res.OK() {
-			c.Header("Retry-After", "1")
-			c.Status(http.StatusTooManyRequests)
-			_ = c.Error(errors.New("rate limit exceeded"))
-			c.Abort()
+		if !res.Allowed {
+			httpx.AbortError(c, "ratelimit", apperr.E(apperr.RateLimited, "rate limit exceeded", nil))
 			return
 		}
-		delay := res.DelayFrom(time.Now())
-		res.CancelAt(time.Now()) // jangan konsumsi—kita hanya mau tau delay
-
-		sec := int(delay / time.Second)
-		if sec < 1 {
-			sec = 1
-		}
-		c.Header("X-RateLimit-Policy", fmt.Sprintf("rps=%.2f; burst=%d", float64(rps), burst)) // <--- dan di sini
-		c.Header("Retry-After", itoa(sec))
-		c.Status(http.StatusTooManyRequests)
-		_ = c.Error(errors.New("rate limit exceeded"))
-		c.Abort()
+		c.Next()
 	}
 }
 
 func formatRetryAfter(d time.Duration) string {
-	// detik bulat
-	sec := int(d.Round(time.Second) / time.Second)
-	if sec < 1 {
-		sec = 1
-	}
-	return itoa(sec)
+	return itoa(max(1, ceilSeconds(d)))
 }
 
 func itoa(i int) string {
@@ -0,0 +1,276 @@
+package ratelimit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PolicySet: isi file policy rate limit (rate_limit.policy_file). Policy
+// dicocokkan berurutan; yang pertama cocok dengan method + route dipakai.
+type PolicySet struct {
+	// Skip: route yang tidak pernah dibatasi (probe, metrics)
+	Skip []RouteMatch `yaml:"skip"`
+	// AllowCIDRs: client dari jaringan ini (mis. internal) tidak dibatasi
+	AllowCIDRs []string `yaml:"allow_cidrs"`
+	Policies   []Policy `yaml:"policies"`
+}
+
+// RouteMatch: Path dicocokkan ke template route gin (/v1/users/:id), atau
+// path request bila tidak ada route yang cocok. Akhiran "*" = prefix
+// ("*" saja = semua). Methods kosong = semua method.
+type RouteMatch struct {
+	Methods []string `yaml:"methods,omitempty"`
+	Path    string   `yaml:"path"`
+}
+
+// KeyKind: identitas yang kuotanya dihitung bersama.
+type KeyKind string
+
+const (
+	ByIP     KeyKind = "ip"
+	ByUser   KeyKind = "user"    // user_id dari token; anonim → per IP
+	ByAPIKey KeyKind = "api_key" // header API key (di-hash); tanpa header → per IP
+	ByTenant KeyKind = "tenant"  // satu kuota per tenant
+	ByLogin  KeyKind = "login"   // IP + email dari body JSON (endpoint login)
+)
+
+var keyKinds = []KeyKind{ByIP, ByUser, ByAPIKey, ByTenant, ByLogin}
+
+// AnonymousPlan: plan untuk request tanpa identitas (lihat Policy.Plans).
+const AnonymousPlan = "anonymous"
+
+type Policy struct {
+	Name   string       `yaml:"name"`
+	Routes []RouteMatch `yaml:"routes"`
+	Key    KeyKind      `yaml:"key"`
+	// PerRoute: kuota terpisah per route (bukan satu kuota untuk semua
+	// route yang cocok)
+	PerRoute  bool      `yaml:"per_route,omitempty"`
+	Algorithm Algorithm `yaml:"algorithm,omitempty"` // default token_bucket
+	// Limits ditumpuk: request harus lolos semuanya (mis. per detik dan per
+	// hari). Limit yang sudah lolos tetap terpakai walau limit berikutnya
+	// menolak.
+	Limits []LimitSpec `yaml:"limits"`
+	// Plans: Limits pengganti per role token (atau AnonymousPlan); role yang
+	// tidak terdaftar memakai Limits.
+	Plans map[string][]LimitSpec `yaml:"plans,omitempty"`
+}
+
+// LimitSpec: rps + burst, atau count per durasi (count request per window,
+// mis. 10000 per 24h).
+type LimitSpec struct {
+	Name      string        `yaml:"name,omitempty"` // nama di header; default nama policy
+	RPS       float64       `yaml:"rps,omitempty"`
+	Burst     int           `yaml:"burst,omitempty"`
+	Count     int           `yaml:"count,omitempty"`
+	Per       time.Duration `yaml:"per,omitempty"`
+	Algorithm Algorithm     `yaml:"algorithm,omitempty"` // default algoritma policy
+}
+
+// Limit: kuota efektif; count/per → Rate = count/per, Burst = count.
+func (s LimitSpec) Limit() Limit {
+	if s.Count > 0 {
+		return Limit{Rate: float64(s.Count) / s.Per.Seconds(), Burst: s.Count}
+	}
+	return Limit{Rate: s.RPS, Burst: s.Burst}
+}
+
+// DefaultSkip: probe & metrics tidak dibatasi.
+var DefaultSkip = []RouteMatch{{Path: "/metrics"}, {Path: "/health"}, {Path: "/livez"}, {Path: "/readyz"}}
+
+// DefaultPolicies: padanan bawaan tanpa file policy — login per IP + email,
+// route lain per IP per route.
+func DefaultPolicies(def Limit, defAlg Algorithm, login Limit, loginAlg Algorithm) PolicySet {
+	return PolicySet{
+		Skip: DefaultSkip,
+		Policies: []Policy{
+			{
+				Name:      "login",
+				Routes:    []RouteMatch{{Methods: []string{"POST"}, Path: "/v1/auth/login"}},
+				Key:       ByLogin,
+				Algorithm: loginAlg,
+				Limits:    []LimitSpec{{RPS: login.Rate, Burst: login.Burst}},
+			},
+			{
+				Name:      "default",
+				Routes:    []RouteMatch{{Path: "*"}},
+				Key:       ByIP,
+				PerRoute:  true,
+				Algorithm: defAlg,
+				Limits:    []LimitSpec{{RPS: def.Rate, Burst: def.Burst}},
+			},
+		},
+	}
+}
+
+// LoadPolicyFile membaca & memvalidasi file policy (YAML).
+func LoadPolicyFile(path string) (PolicySet, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return PolicySet{}, err
+	}
+	set, err := ParsePolicies(b)
+	if err != nil {
+		return PolicySet{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return set, nil
+}
+
+// ParsePolicies: decode + Validate. Field yang tidak dikenal ditolak supaya
+// salah ketik tidak diam-diam mematikan limit.
+func ParsePolicies(b []byte) (PolicySet, error) {
+	var set PolicySet
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&set); err != nil {
+		return PolicySet{}, err
+	}
+	if err := set.Validate(); err != nil {
+		return PolicySet{}, err
+	}
+	return set, nil
+}
+
+// Validate mengembalikan SEMUA pelanggaran (errors.Join).
+func (s PolicySet) Validate() error {
+	var errs []error
+	add := func(path, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+	}
+	routes := func(path string, rs []RouteMatch) {
+		for i, r := range rs {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			if r.Path != "*" && !strings.HasPrefix(r.Path, "/") {
+				add(p+".path", "must start with / or be * (got %q)", r.Path)
+			}
+			for _, m := range r.Methods {
+				if m != strings.ToUpper(m) || m == "" {
+					add(p+".methods", "must be upper case (got %q)", m)
+				}
+			}
+		}
+	}
+	limits := func(path string, ls []LimitSpec) {
+		if len(ls) == 0 {
+			add(path, "must not be empty")
+		}
+		names := map[string]bool{}
+		for i, l := range ls {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case l.Count > 0 && (l.RPS != 0 || l.Burst != 0):
+				add(p, "set either rps+burst or count+per, not both")
+			case l.Count > 0:
+				if l.Per <= 0 {
+					add(p+".per", "must be > 0 (got %s)", l.Per)
+				}
+			default:
+				if l.RPS <= 0 {
+					add(p+".rps", "must be > 0 (got %v)", l.RPS)
+				}
+				if l.Burst < 1 {
+					add(p+".burst", "must be >= 1 (got %d)", l.Burst)
+				}
+			}
+			if l.Algorithm != "" {
+				if _, err := ParseAlgorithm(string(l.Algorithm)); err != nil {
+					add(p+".algorithm", "unknown algorithm %q", l.Algorithm)
+				}
+			}
+			if len(ls) > 1 && l.Name == "" {
+				add(p+".name", "required when stacking limits")
+			}
+			if names[l.Name] {
+				add(p+".name", "duplicate %q", l.Name)
+			}
+			names[l.Name] = true
+		}
+	}
+
+	routes("skip", s.Skip)
+	for i, c := range s.AllowCIDRs {
+		if _, err := parsePrefix(c); err != nil {
+			add(fmt.Sprintf("allow_cidrs[%d]", i), "invalid CIDR %q", c)
+		}
+	}
+	if len(s.Policies) == 0 {
+		add("policies", "must not be empty")
+	}
+	names := map[string]bool{}
+	for i, p := range s.Policies {
+		path := fmt.Sprintf("policies[%d]", i)
+		if p.Name == "" {
+			add(path+".name", "must not be empty")
+		} else if names[p.Name] {
+			add(path+".name", "duplicate %q", p.Name)
+		}
+		names[p.Name] = true
+		if len(p.Routes) == 0 {
+			add(path+".routes", "must not be empty")
+		}
+		routes(path+".routes", p.Routes)
+		if !validKey(p.Key) {
+			add(path+".key", "must be ip, user, api_key, tenant or login (got %q)", p.Key)
+		}
+		if p.Algorithm != "" {
+			if _, err := ParseAlgorithm(string(p.Algorithm)); err != nil {
+				add(path+".algorithm", "unknown algorithm %q", p.Algorithm)
+			}
+		}
+		limits(path+".limits", p.Limits)
+		for _, plan := range slices.Sorted(maps.Keys(p.Plans)) {
+			limits(path+".plans."+plan, p.Plans[plan])
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validKey(k KeyKind) bool {
+	for _, v := range keyKinds {
+		if k == v {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePrefix: CIDR atau IP tunggal.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		return p.Masked(), err
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(a, a.BitLen()), nil
+}
+
+func (r RouteMatch) matches(method, route string) bool {
+	if len(r.Methods) > 0 {
+		ok := false
+		for _, m := range r.Methods {
+			if m == method {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if prefix, ok := strings.CutSuffix(r.Path, "*"); ok {
+		return strings.HasPrefix(route, prefix)
+	}
+	return route == r.Path
+}
@@ -0,0 +1,299 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/netip"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/Quineeryn/go-backend-101/internal/apperr"
+	"github.com/Quineeryn/go-backend-101/internal/cache"
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+	"github.com/Quineeryn/go-backend-101/internal/logger"
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
+)
+
+// Identity: pemilik request untuk key "user" dan pemilihan plan.
+type Identity struct {
+	UserID string
+	Role   string
+}
+
+type PolicyOptions struct {
+	FailMode cache.FailMode // saat Redis error; default FailOpen
+	// Identify: identitas request. Middleware global berjalan sebelum
+	// RequireAuth, jadi biasanya membaca token sendiri. Default: "user_id"
+	// & "role" di gin context (bila dipasang setelah auth).
+	Identify func(*gin.Context) (Identity, bool)
+	// APIKeyHeader untuk key "api_key" (default X-API-Key)
+	APIKeyHeader string
+}
+
+// Policies: middleware rate limit berbasis PolicySet; set bisa diganti
+// saat runtime (Apply) tanpa memasang ulang middleware.
+type Policies struct {
+	rdb  redis.UniversalClient
+	opts PolicyOptions
+	cur  atomic.Pointer[compiledSet]
+}
+
+type compiledSet struct {
+	skip     []RouteMatch
+	allow    []netip.Prefix
+	policies []*compiledPolicy
+}
+
+type compiledPolicy struct {
+	Policy
+	limits []*compiledLimit
+	plans  map[string][]*compiledLimit
+}
+
+type compiledLimit struct {
+	key string // segmen key Redis
+	rl  *RedisLimiter
+}
+
+func NewPolicies(rdb redis.UniversalClient, opts PolicyOptions) *Policies {
+	if opts.Identify == nil {
+		opts.Identify = identityFromContext
+	}
+	if opts.APIKeyHeader == "" {
+		opts.APIKeyHeader = "X-API-Key"
+	}
+	return &Policies{rdb: rdb, opts: opts}
+}
+
+// Apply memvalidasi lalu menukar set secara atomik; set invalid ditolak
+// utuh dan set lama tetap berlaku. State kuota di Redis tidak hilang
+// selama nama policy/limit tidak berubah.
+func (p *Policies) Apply(set PolicySet) error {
+	if err := set.Validate(); err != nil {
+		return err
+	}
+	cs := &compiledSet{skip: set.Skip}
+	for _, c := range set.AllowCIDRs {
+		pfx, _ := parsePrefix(c) // sudah divalidasi
+		cs.allow = append(cs.allow, pfx)
+	}
+	for _, pol := range set.Policies {
+		cp := &compiledPolicy{Policy: pol, limits: p.compile(pol, "", pol.Limits)}
+		if len(pol.Plans) > 0 {
+			cp.plans = map[string][]*compiledLimit{}
+			for plan, ls := range pol.Plans {
+				cp.plans[plan] = p.compile(pol, plan, ls)
+			}
+		}
+		cs.policies = append(cs.policies, cp)
+	}
+	p.cur.Store(cs)
+	return nil
+}
+
+func (p *Policies) compile(pol Policy, plan string, specs []LimitSpec) []*compiledLimit {
+	out := make([]*compiledLimit, len(specs))
+	for i, s := range specs {
+		l := s.Limit()
+		name, key := pol.Name, pol.Name
+		if s.Name != "" {
+			name, key = s.Name, pol.Name+"."+s.Name
+		}
+		if plan != "" {
+			key += "@" + plan
+		}
+		alg := s.Algorithm
+		if alg == "" {
+			alg = pol.Algorithm
+		}
+		rl := NewRedisLimiter(p.rdb, l.Rate, l.Burst).WithFailMode(p.opts.FailMode).WithPolicy(name)
+		if alg != "" {
+			_ = rl.SetAlgorithm(alg) // sudah divalidasi
+		}
+		out[i] = &compiledLimit{key: key, rl: rl}
+	}
+	return out
+}
+
+// Middleware: cocokkan route ke policy pertama, lalu periksa semua limit
+// (plan) policy itu. Header rate limit di setiap response; 429/503 lewat
+// error envelope standar. Tanpa set (belum Apply) request tidak dibatasi.
+func (p *Policies) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cs := p.cur.Load()
+		if cs == nil {
+			c.Next()
+			return
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		method := c.Request.Method
+		if cs.skipped(method, route) || cs.allowed(c.ClientIP()) {
+			c.Next()
+			return
+		}
+		pol := cs.match(method, route)
+		if pol == nil {
+			c.Next()
+			return
+		}
+
+		id, authed := p.opts.Identify(c)
+		limits := pol.limits
+		plan := AnonymousPlan
+		if authed {
+			plan = id.Role
+		}
+		if ls, ok := pol.plans[plan]; ok {
+			limits = ls
+		}
+		subject := p.subject(c, pol.Key, id, authed)
+		if pol.PerRoute {
+			subject += ":r:" + route
+		}
+
+		results := make([]namedResult, 0, len(limits))
+		denied := false
+		for _, l := range limits {
+			key := "rl:t:" + tenant.ID(c) + ":" + l.key + ":" + subject
+			res, err := l.rl.Allow(c, key)
+			if err != nil && !res.Allowed {
+				c.Header("Retry-After", "1")
+				httpx.AbortError(c, "ratelimit", apperr.E(apperr.Unavailable, "rate limiter unavailable", err))
+				return
+			}
+			if err == nil {
+				results = append(results, namedResult{l.rl.Policy(), res})
+			}
+			if !res.Allowed {
+				denied = true
+				break
+			}
+		}
+		setHeaders(c, results...)
+		if denied {
+			httpx.RateLimitExceeded.WithLabelValues(c.FullPath()).Inc()
+			httpx.AbortError(c, "ratelimit", apperr.E(apperr.RateLimited, "rate limit exceeded", nil))
+			return
+		}
+		c.Next()
+	}
+}
+
+// subject: bagian key yang mengidentifikasi pemilik kuota.
+func (p *Policies) subject(c *gin.Context, k KeyKind, id Identity, authed bool) string {
+	switch k {
+	case ByUser:
+		if authed && id.UserID != "" {
+			return "u:" + id.UserID
+		}
+	case ByAPIKey:
+		if v := c.GetHeader(p.opts.APIKeyHeader); v != "" {
+			// jangan simpan API key mentah di Redis
+			sum := sha256.Sum256([]byte(v))
+			return "k:" + hex.EncodeToString(sum[:8])
+		}
+	case ByTenant:
+		return "all" // tenant sudah jadi bagian key
+	case ByLogin:
+		return "ip:" + c.ClientIP() + ":e:" + loginEmail(c)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func (cs *compiledSet) skipped(method, route string) bool {
+	for _, r := range cs.skip {
+		if r.matches(method, route) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cs *compiledSet) allowed(ip string) bool {
+	if len(cs.allow) == 0 {
+		return false
+	}
+	a, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	a = a.Unmap()
+	for _, pfx := range cs.allow {
+		if pfx.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cs *compiledSet) match(method, route string) *compiledPolicy {
+	for _, pol := range cs.policies {
+		for _, r := range pol.Routes {
+			if r.matches(method, route) {
+				return pol
+			}
+		}
+	}
+	return nil
+}
+
+func identityFromContext(c *gin.Context) (Identity, bool) {
+	uid := c.GetString(httpx.CtxKeyUserID)
+	if uid == "" {
+		return Identity{}, false
+	}
+	return Identity{UserID: uid, Role: c.GetString("role")}, true
+}
+
+// WatchFile mem-poll file policy (path() dibaca tiap tick, jadi ikut bila
+// path diganti lewat hot reload) dan Apply saat isinya berubah. File
+// invalid dicatat; set lama tetap berlaku. Berhenti saat ctx selesai.
+func (p *Policies) WatchFile(ctx context.Context, path func() string, every time.Duration) {
+	var last string
+	var sum [sha256.Size]byte
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		f := path()
+		if f == "" {
+			last = ""
+			continue
+		}
+		b, err := os.ReadFile(f)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				logger.L.Warn("ratelimit.policy.watch", zap.String("file", f), zap.Error(err))
+			}
+			continue
+		}
+		next := sha256.Sum256(b)
+		if f == last && next == sum {
+			continue
+		}
+		last, sum = f, next
+		set, err := ParsePolicies(b)
+		if err == nil {
+			err = p.Apply(set)
+		}
+		if err != nil {
+			logger.L.Error("ratelimit.policy.reload_failed", zap.String("file", f), zap.Error(err))
+			continue
+		}
+		logger.L.Info("ratelimit.policy.reloaded", zap.String("file", f), zap.Int("policies", len(set.Policies)))
+	}
+}
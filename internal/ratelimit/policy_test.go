@@ -0,0 +1,247 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Quineeryn/go-backend-101/internal/middleware"
+)
+
+func TestLoadPolicyFile_Example(t *testing.T) {
+	set, err := LoadPolicyFile("../../configs/ratelimit.example.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := set.Policies[3]
+	if len(set.Policies) != 5 || api.Name != "api" || len(api.Limits) != 2 || len(api.Plans["anonymous"]) != 2 {
+		t.Fatalf("got %+v", set)
+	}
+	if l := api.Limits[1].Limit(); l.Burst != 10000 || l.window() != int64(24*time.Hour/time.Microsecond) {
+		t.Fatalf("per_day limit = %+v", l)
+	}
+}
+
+func TestParsePolicies_Invalid(t *testing.T) {
+	_, err := ParsePolicies([]byte(`
+allow_cidrs: [10.0.0.0/33]
+policies:
+  - name: api
+    routes: [{path: v1/*, methods: [get]}]
+    key: session
+    algorithm: leaky
+    limits:
+      - {rps: 1, burst: 1}
+      - {count: 5}
+  - name: api
+    routes: [{path: "*"}]
+    key: ip
+    limits: []
+`))
+	if err == nil {
+		t.Fatal("want error")
+	}
+	for _, want := range []string{
+		"allow_cidrs[0]:", "policies[0].routes[0].path:", "policies[0].routes[0].methods:", "policies[0].key:",
+		"policies[0].algorithm:", "policies[0].limits[0].name:", "policies[0].limits[1].per:",
+		"policies[1].name: duplicate", "policies[1].limits: must not be empty",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error list missing %q\n%s", want, err)
+		}
+	}
+	if _, err := ParsePolicies([]byte("policies:\n  - name: x\n    limit: []\n")); err == nil || !strings.Contains(err.Error(), "limit") {
+		t.Errorf("unknown field should be rejected, got %v", err)
+	}
+}
+
+type policyServer struct {
+	p  *Policies
+	r  *gin.Engine
+	mr *miniredis.Miniredis
+}
+
+func newPolicyServer(t *testing.T, set PolicySet) *policyServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	p := NewPolicies(rdb, PolicyOptions{
+		Identify: func(c *gin.Context) (Identity, bool) {
+			uid := c.GetHeader("X-Test-User")
+			return Identity{UserID: uid, Role: c.GetHeader("X-Test-Role")}, uid != ""
+		},
+	})
+	if err := p.Apply(set); err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.Use(middleware.ErrorEnvelope(), p.Middleware())
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	r.GET("/metrics", ok)
+	r.GET("/v1/items/:id", ok)
+	r.POST("/v1/auth/login", ok)
+	r.GET("/v1/admin/jobs", ok)
+	return &policyServer{p: p, r: r, mr: mr}
+}
+
+func (s *policyServer) do(method, path, ip string, hdr ...string) *httptest.ResponseRecorder {
+	var body *strings.Reader
+	if method == http.MethodPost {
+		body = strings.NewReader(`{"email":"` + hdr[0] + `"}`)
+		hdr = hdr[1:]
+	} else {
+		body = strings.NewReader("")
+	}
+	req := httptest.NewRequest(method, path, body)
+	req.RemoteAddr = ip + ":5000"
+	for i := 0; i+1 < len(hdr); i += 2 {
+		req.Header.Set(hdr[i], hdr[i+1])
+	}
+	w := httptest.NewRecorder()
+	s.r.ServeHTTP(w, req)
+	return w
+}
+
+// count: jumlah request lolos dari n percobaan
+func (s *policyServer) count(n int, method, path, ip string, hdr ...string) int {
+	ok := 0
+	for i := 0; i < n; i++ {
+		if s.do(method, path, ip, hdr...).Code != http.StatusTooManyRequests {
+			ok++
+		}
+	}
+	return ok
+}
+
+var testPolicies = PolicySet{
+	Skip:       []RouteMatch{{Path: "/metrics"}},
+	AllowCIDRs: []string{"10.0.0.0/8"},
+	Policies: []Policy{
+		{
+			Name:   "login",
+			Routes: []RouteMatch{{Methods: []string{"POST"}, Path: "/v1/auth/login"}},
+			Key:    ByLogin,
+			Limits: []LimitSpec{{RPS: 0.001, Burst: 1}},
+		},
+		{
+			Name:   "admin_api",
+			Routes: []RouteMatch{{Path: "/v1/admin/*"}},
+			Key:    ByAPIKey,
+			Limits: []LimitSpec{{RPS: 0.001, Burst: 2}},
+		},
+		{
+			Name:   "api",
+			Routes: []RouteMatch{{Path: "/v1/*"}},
+			Key:    ByUser,
+			Limits: []LimitSpec{
+				{Name: "per_second", RPS: 1000, Burst: 100},
+				{Name: "per_day", Count: 4, Per: 24 * time.Hour, Algorithm: SlidingWindow},
+			},
+			Plans: map[string][]LimitSpec{
+				"admin":       {{Name: "per_second", RPS: 0.001, Burst: 10}},
+				AnonymousPlan: {{Name: "per_second", RPS: 0.001, Burst: 2}},
+			},
+		},
+	},
+}
+
+func TestPolicies_SkipAndAllowlist(t *testing.T) {
+	s := newPolicyServer(t, testPolicies)
+	w := s.do(http.MethodGet, "/metrics", "192.0.2.1")
+	if n := s.count(20, http.MethodGet, "/metrics", "192.0.2.1"); n != 20 || w.Header().Get("RateLimit") != "" {
+		t.Fatalf("skipped route limited: %d allowed, headers %v", n, w.Header())
+	}
+	if n := s.count(20, http.MethodGet, "/v1/items/1", "10.1.2.3"); n != 20 {
+		t.Fatalf("allowlisted CIDR limited: %d allowed", n)
+	}
+}
+
+func TestPolicies_StackedLimitsPerUser(t *testing.T) {
+	s := newPolicyServer(t, testPolicies)
+	ip := "192.0.2.1"
+	w := s.do(http.MethodGet, "/v1/items/1", ip, "X-Test-User", "bob")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("first request: %d", w.Code)
+	}
+	checkHeaders(t, w, map[string]string{
+		"RateLimit-Policy":      `"per_second";q=100;w=1, "per_day";q=4;w=86400`,
+		"X-RateLimit-Limit":     "4", // yang paling ketat
+		"X-RateLimit-Remaining": "3",
+	})
+	// per_day (4) habis lebih dulu; route lain di policy yang sama ikut terhitung
+	if n := s.count(5, http.MethodGet, "/v1/items/2", ip, "X-Test-User", "bob"); n != 3 {
+		t.Fatalf("bob allowed %d more, want 3", n)
+	}
+	w = s.do(http.MethodGet, "/v1/items/1", ip, "X-Test-User", "bob")
+	if ra, _ := strconv.Atoi(w.Header().Get("Retry-After")); w.Code != http.StatusTooManyRequests || ra < 3600 {
+		t.Fatalf("per_day exhausted: %d Retry-After=%q", w.Code, w.Header().Get("Retry-After"))
+	}
+	checkRateLimitedBody(t, w)
+	// user lain dari IP yang sama punya kuota sendiri
+	if n := s.count(4, http.MethodGet, "/v1/items/1", ip, "X-Test-User", "carol"); n != 4 {
+		t.Fatalf("carol allowed %d, want 4", n)
+	}
+}
+
+func TestPolicies_Plans(t *testing.T) {
+	s := newPolicyServer(t, testPolicies)
+	// admin: limit plan menggantikan limits (tanpa per_day)
+	if n := s.count(12, http.MethodGet, "/v1/items/1", "192.0.2.1", "X-Test-User", "root", "X-Test-Role", "admin"); n != 10 {
+		t.Fatalf("admin allowed %d, want 10", n)
+	}
+	// anonim: plan anonymous, per IP
+	if n := s.count(4, http.MethodGet, "/v1/items/1", "192.0.2.7"); n != 2 {
+		t.Fatalf("anonymous allowed %d, want 2", n)
+	}
+	if n := s.count(4, http.MethodGet, "/v1/items/1", "192.0.2.8"); n != 2 {
+		t.Fatalf("anonymous from another IP allowed %d, want 2", n)
+	}
+}
+
+func TestPolicies_LoginAndAPIKey(t *testing.T) {
+	s := newPolicyServer(t, testPolicies)
+	ip := "192.0.2.1"
+	if n := s.count(3, http.MethodPost, "/v1/auth/login", ip, "a@example.com"); n != 1 {
+		t.Fatalf("login a allowed %d, want 1", n)
+	}
+	if n := s.count(3, http.MethodPost, "/v1/auth/login", ip, "b@example.com"); n != 1 {
+		t.Fatalf("login b allowed %d, want 1", n)
+	}
+	if n := s.count(3, http.MethodGet, "/v1/admin/jobs", ip, "X-API-Key", "secret-one"); n != 2 {
+		t.Fatalf("api key one allowed %d, want 2", n)
+	}
+	if n := s.count(3, http.MethodGet, "/v1/admin/jobs", ip, "X-API-Key", "secret-two"); n != 2 {
+		t.Fatalf("api key two allowed %d, want 2", n)
+	}
+	for _, k := range s.mr.Keys() {
+		if strings.Contains(k, "secret") {
+			t.Fatalf("raw API key stored in Redis key %q", k)
+		}
+	}
+}
+
+func TestPolicies_ApplyInvalidKeepsCurrent(t *testing.T) {
+	s := newPolicyServer(t, testPolicies)
+	if err := s.p.Apply(PolicySet{}); err == nil {
+		t.Fatal("empty set should be rejected")
+	}
+	if n := s.count(3, http.MethodGet, "/v1/items/1", "192.0.2.9"); n != 2 {
+		t.Fatalf("old policies should stay active, allowed %d", n)
+	}
+	// set baru berlaku tanpa memasang ulang middleware
+	if err := s.p.Apply(PolicySet{Policies: []Policy{{Name: "none", Routes: []RouteMatch{{Path: "/nothing"}}, Key: ByIP, Limits: []LimitSpec{{RPS: 1, Burst: 1}}}}}); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.count(3, http.MethodGet, "/v1/items/1", "192.0.2.9"); n != 3 {
+		t.Fatalf("unmatched route limited, allowed %d", n)
+	}
+}
@@ -2,26 +2,91 @@ package ratelimit
 
 import (
 	"context"
-	"strconv"
-	"time"
+	"sync/atomic"
 
+	"github.com/Quineeryn/go-backend-101/internal/apperr"
+	"github.com/Quineeryn/go-backend-101/internal/cache"
 	"github.com/Quineeryn/go-backend-101/internal/httpx"
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
 
+// RedisLimiter: limit per route di Redis dengan algoritma yang bisa diganti
+// saat runtime; saat Redis error mengikuti fail mode.
 type RedisLimiter struct {
-	rdb   *redis.Client
-	rps   float64
-	burst int64
-	// TTL cadangan untuk key baru/idle
-	ttl time.Duration
+	rdb   redis.UniversalClient
+	limit atomic.Pointer[Limit]
+	algo  atomic.Pointer[algoPair]
+
+	fail   cache.FailMode // saat Redis error; default FailOpen
+	policy string         // nama di header RateLimit-Policy
+}
+
+// algoPair: varian Redis + memory (fallback FailLocal) dari algoritma yang
+// sama, supaya perilaku saat fallback tetap konsisten.
+type algoPair struct {
+	alg           Algorithm
+	remote, local Limiter
+}
+
+// NewRedisLimiter: default token bucket; ganti lewat SetAlgorithm.
+func NewRedisLimiter(rdb redis.UniversalClient, rps float64, burst int) *RedisLimiter {
+	l := &RedisLimiter{rdb: rdb, policy: "default"}
+	l.SetLimits(rps, burst)
+	_ = l.SetAlgorithm(TokenBucket)
+	return l
+}
+
+// WithFailMode mengatur perilaku saat Redis error: FailOpen meloloskan
+// request, FailClosed menolak (503), FailLocal membatasi per replica
+// dengan varian memory algoritma yang sama (limit sama, jadi total cluster
+// bisa N× lebih longgar).
+func (l *RedisLimiter) WithFailMode(m cache.FailMode) *RedisLimiter {
+	l.fail = m
+	return l
+}
+
+// WithPolicy mengganti nama policy di header RateLimit-Policy/RateLimit
+// (default "default").
+func (l *RedisLimiter) WithPolicy(name string) *RedisLimiter {
+	l.policy = name
+	return l
+}
+
+// Policy: nama policy untuk header.
+func (l *RedisLimiter) Policy() string { return l.policy }
+
+// SetLimits mengganti rate & burst saat runtime (hot reload). State yang
+// sudah ada di Redis ikut memakai nilai baru pada request berikutnya.
+func (l *RedisLimiter) SetLimits(rps float64, burst int) {
+	l.limit.Store(&Limit{Rate: rps, Burst: burst})
 }
 
-func NewRedisLimiter(rdb *redis.Client, rps float64, burst int, ttl time.Duration) *RedisLimiter {
-	return &RedisLimiter{rdb: rdb, rps: rps, burst: int64(burst), ttl: ttl}
+// Limits mengembalikan rate & burst yang sedang berlaku.
+func (l *RedisLimiter) Limits() (rps float64, burst int) {
+	cur := l.limit.Load()
+	return cur.Rate, cur.Burst
+}
+
+// SetAlgorithm mengganti algoritma saat runtime. Tiap algoritma memakai key
+// Redis sendiri, jadi kuota mulai dari penuh setelah ganti.
+func (l *RedisLimiter) SetAlgorithm(a Algorithm) error {
+	if cur := l.algo.Load(); cur != nil && cur.alg == a {
+		return nil
+	}
+	remote, err := NewRedis(l.rdb, a, nil)
+	if err != nil {
+		return err
+	}
+	local, _ := NewMemory(a, nil)
+	l.algo.Store(&algoPair{alg: a, remote: remote, local: local})
+	return nil
 }
 
+// Algorithm yang sedang berlaku.
+func (l *RedisLimiter) Algorithm() Algorithm { return l.algo.Load().alg }
+
 // Key builder (bisa disesuaikan)
 func KeyPerIPRoute(c *gin.Context) string {
 	ip := c.ClientIP()
@@ -29,120 +94,52 @@ func KeyPerIPRoute(c *gin.Context) string {
 	if route == "" {
 		route = c.Request.URL.Path
 	}
-	return "rl:ip:" + ip + ":" + route
+	return "rl:t:" + tenant.ID(c) + ":ip:" + ip + ":" + route
 }
 
-// Lua script: token-bucket
-// KEYS[1]=bucket key
-// ARGV[1]=now(ms) ARGV[2]=rate(tokens/sec) ARGV[3]=burst ARGV[4]=ttl(sec)
-var lua = redis.NewScript(`
-local key = KEYS[1]
-local now = tonumber(ARGV[1])
-local rate = tonumber(ARGV[2])
-local burst = tonumber(ARGV[3])
-local ttl = tonumber(ARGV[4])
-
--- fields: tokens, last (ms)
-local data = redis.call("HMGET", key, "tokens", "last")
-local tokens = tonumber(data[1])
-local last = tonumber(data[2])
-
-if not tokens or not last then
-  tokens = burst
-  last = now
-else
-  local delta = math.max(0, now - last) / 1000.0
-  local filled = tokens + (delta * rate)
-  if filled > burst then filled = burst end
-  tokens = filled
-  last = now
-end
-
-local allowed = 0
-if tokens >= 1.0 then
-  tokens = tokens - 1.0
-  allowed = 1
-end
-
-redis.call("HMSET", key, "tokens", tokens, "last", last)
-redis.call("EXPIRE", key, ttl)
-return {allowed, tokens}
-`)
-
-func (l *RedisLimiter) Allow(ctx context.Context, key string) (bool, float64, error) {
-	now := time.Now().UnixMilli()
-	res, err := lua.Run(ctx, l.rdb, []string{key},
-		now, l.rps, l.burst, int(l.ttl.Seconds())).Result()
-	if err != nil {
-		// fail-open kalau Redis/Lua error
-		return true, 0, err
-	}
-
-	arr, ok := res.([]interface{})
-	if !ok || len(arr) < 2 {
-		// format tak terduga -> fail-open
-		return true, 0, nil
-	}
-
-	// arr[0] = allowed (1/0), bisa int64 atau string
-	allowed := false
-	switch v := arr[0].(type) {
-	case int64:
-		allowed = v == 1
-	case float64:
-		allowed = int64(v) == 1
-	case string:
-		allowed = v == "1"
-	default:
-		// unknown -> assume allowed (fail-open)
-		allowed = true
+// Allow: keputusan untuk key. Error (Redis) dikembalikan bersama Result
+// sesuai fail mode: FailOpen → Allowed, FailClosed → ditolak, FailLocal →
+// keputusan limiter lokal tanpa error.
+func (l *RedisLimiter) Allow(ctx context.Context, key string) (Result, error) {
+	lim := *l.limit.Load()
+	a := l.algo.Load()
+	res, err := a.remote.Allow(ctx, key, lim)
+	if err == nil {
+		return res, nil
 	}
-
-	// arr[1] = tokens sisa, bisa int64 atau float64 atau string
-	var remain float64
-	switch v := arr[1].(type) {
-	case float64:
-		remain = v
-	case int64:
-		remain = float64(v)
-	case string:
-		if f, err := strconv.ParseFloat(v, 64); err == nil {
-			remain = f
-		}
-	default:
-		remain = 0
+	switch l.fail {
+	case cache.FailLocal:
+		httpx.RedisFallback.WithLabelValues("rate_limit", string(cache.FailLocal)).Inc()
+		return a.local.Allow(ctx, key, lim)
+	case cache.FailClosed:
+		httpx.RedisFallback.WithLabelValues("rate_limit", string(cache.FailClosed)).Inc()
+		return Result{Limit: lim.Burst}, err
 	}
-
-	return allowed, remain, nil
+	// fail-open kalau Redis/Lua error
+	httpx.RedisFallback.WithLabelValues("rate_limit", string(cache.FailOpen)).Inc()
+	return Result{Allowed: true, Limit: lim.Burst}, err
 }
 
-// Gin middleware
+// MiddlewareRedis: satu limiter untuk semua request yang melewatinya (route
+// mana yang dibatasi ditentukan tempat middleware dipasang; untuk aturan
+// per route/user/plan lihat Policies). Header rate limit dipasang di setiap
+// response (kecuali saat Redis error dan fail-open: kuota tidak diketahui);
+// 429/503 lewat error envelope standar.
 func MiddlewareRedis(l *RedisLimiter, keyFn func(*gin.Context) string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// lewati /metrics biar Prometheus gak kena limit
-		switch c.FullPath() {
-		case "/metrics", "/health":
-			c.Next()
-			return
-		}
 		key := keyFn(c)
-		ok, _, err := l.Allow(c, key)
-		if err != nil {
-			// gagal konek redis => fail-open
-			c.Next()
+		res, err := l.Allow(c, key)
+		if err != nil && !res.Allowed {
+			c.Header("Retry-After", "1")
+			httpx.AbortError(c, "ratelimit", apperr.E(apperr.Unavailable, "rate limiter unavailable", err))
 			return
 		}
-		if !ok {
-
+		if err == nil {
+			setHeaders(c, namedResult{l.Policy(), res})
+		}
+		if !res.Allowed {
 			httpx.RateLimitExceeded.WithLabelValues(c.FullPath()).Inc()
-			c.Header("Retry-After", "1")
-			c.JSON(429, gin.H{
-				"code":    429,
-				"error":   "Too Many Requests",
-				"message": "rate limit exceeded",
-				"time":    time.Now().UTC().Format(time.RFC3339),
-			})
-			c.Abort()
+			httpx.AbortError(c, "ratelimit", apperr.E(apperr.RateLimited, "rate limit exceeded", nil))
 			return
 		}
 		c.Next()
@@ -0,0 +1,178 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Quineeryn/go-backend-101/internal/cache"
+	"github.com/Quineeryn/go-backend-101/internal/middleware"
+)
+
+func newTestRedisLimiter(t *testing.T, rps float64, burst int) (*RedisLimiter, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return NewRedisLimiter(rdb, rps, burst), mr
+}
+
+func TestRedisLimiter_SetAlgorithm(t *testing.T) {
+	l, mr := newTestRedisLimiter(t, 0.01, 2)
+	if l.Algorithm() != TokenBucket {
+		t.Fatalf("default algorithm = %s", l.Algorithm())
+	}
+	if err := l.SetAlgorithm("leaky"); err == nil || l.Algorithm() != TokenBucket {
+		t.Fatal("unknown algorithm should be rejected and keep the current one")
+	}
+	ctx := context.Background()
+	for _, alg := range Algorithms {
+		if err := l.SetAlgorithm(alg); err != nil {
+			t.Fatal(err)
+		}
+		var allowed int
+		for i := 0; i < 4; i++ {
+			if res, err := l.Allow(ctx, "k"); err != nil {
+				t.Fatal(err)
+			} else if res.Allowed {
+				allowed++
+			}
+		}
+		if allowed != 2 {
+			t.Errorf("%s: allowed %d, want 2", alg, allowed)
+		}
+	}
+	if n := len(mr.Keys()); n != len(Algorithms) {
+		t.Errorf("want one key per algorithm, got %v", mr.Keys())
+	}
+}
+
+func TestRedisLimiter_RedisDown_FailModes(t *testing.T) {
+	ctx := context.Background()
+	for _, m := range []cache.FailMode{cache.FailOpen, cache.FailClosed, cache.FailLocal} {
+		l, mr := newTestRedisLimiter(t, 0.01, 2)
+		l.WithFailMode(m)
+		_ = l.SetAlgorithm(GCRA)
+		mr.Close()
+
+		var allowed, errs int
+		for i := 0; i < 4; i++ {
+			res, err := l.Allow(ctx, "k")
+			if err != nil {
+				errs++
+			}
+			if res.Allowed {
+				allowed++
+			}
+		}
+		want := map[cache.FailMode][2]int{
+			cache.FailOpen:   {4, 4},
+			cache.FailClosed: {0, 4},
+			cache.FailLocal:  {2, 0}, // limit tetap berlaku per replica
+		}[m]
+		if allowed != want[0] || errs != want[1] {
+			t.Errorf("%s: allowed=%d errors=%d, want %v", m, allowed, errs, want)
+		}
+	}
+}
+
+func serveLimited(t *testing.T, mw gin.HandlerFunc) func() *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorEnvelope(), mw)
+	r.GET("/x", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/x", nil))
+		return w
+	}
+}
+
+func checkHeaders(t *testing.T, w *httptest.ResponseRecorder, want map[string]string) {
+	t.Helper()
+	for k, v := range want {
+		if got := w.Header().Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func checkRateLimitedBody(t *testing.T, w *httptest.ResponseRecorder) {
+	t.Helper()
+	var body struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != http.StatusTooManyRequests || body.Message != "rate limit exceeded" {
+		t.Fatalf("body = %s (%v)", w.Body, err)
+	}
+}
+
+func TestMiddlewareRedis_Headers(t *testing.T) {
+	l, _ := newTestRedisLimiter(t, 0.4, 2) // window 5s, token berikutnya 2.5s
+	l.WithPolicy("login")
+	do := serveLimited(t, MiddlewareRedis(l, func(*gin.Context) string { return "k" }))
+
+	w := do()
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("first request: %d", w.Code)
+	}
+	checkHeaders(t, w, map[string]string{
+		"RateLimit-Policy":      `"login";q=2;w=5`,
+		"RateLimit":             `"login";r=1;t=3`,
+		"X-RateLimit-Limit":     "2",
+		"X-RateLimit-Remaining": "1",
+		"X-RateLimit-Reset":     "3",
+		"Retry-After":           "",
+	})
+	do()
+	w = do()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request: %d", w.Code)
+	}
+	checkHeaders(t, w, map[string]string{
+		"RateLimit":             `"login";r=0;t=5`,
+		"X-RateLimit-Remaining": "0",
+		"Retry-After":           "3",
+	})
+	checkRateLimitedBody(t, w)
+}
+
+func TestMiddlewareRedis_FailClosedEnvelope(t *testing.T) {
+	l, mr := newTestRedisLimiter(t, 1, 1)
+	l.WithFailMode(cache.FailClosed)
+	mr.Close()
+	w := serveLimited(t, MiddlewareRedis(l, func(*gin.Context) string { return "k" }))()
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "1" || w.Header().Get("RateLimit") != "" {
+		t.Fatalf("got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestMiddleware_InMemoryHeaders(t *testing.T) {
+	store := NewStore(time.Minute)
+	t.Cleanup(store.Close)
+	do := serveLimited(t, Middleware(store, func(*gin.Context) string { return "k" }, 0.4, 1))
+
+	w := do()
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("first request: %d", w.Code)
+	}
+	checkHeaders(t, w, map[string]string{
+		"RateLimit-Policy": `"default";q=1;w=3`,
+		"RateLimit":        `"default";r=0;t=3`,
+	})
+	w = do()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: %d", w.Code)
+	}
+	checkHeaders(t, w, map[string]string{"Retry-After": "3", "X-RateLimit-Remaining": "0"})
+	checkRateLimitedBody(t, w)
+}
@@ -0,0 +1,182 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Script Lua per algoritma; padanan algorithms.go (urutan operasi float
+// sama). Semua menerima ARGV: now(µs) rate burst window(µs) [member] dan
+// mengembalikan {allowed, remaining, retry_us, reset_us}. Waktu datang dari
+// Clock pemanggil (bukan TIME Redis) supaya bisa disimulasikan di test.
+// Nilai float disimpan dengan %.17g: konversi angka default Lua (%.14g)
+// membulatkan.
+
+var tokenBucketScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+
+local data = redis.call("HMGET", key, "tokens", "last")
+local tokens = tonumber(data[1])
+local last = tonumber(data[2])
+if not tokens or not last then
+  tokens = burst
+  last = now
+elseif now > last then
+  tokens = math.min(burst, tokens + ((now - last) / 1e6) * rate)
+  last = now
+end
+
+local allowed, retry = 0, 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) / rate * 1e6)
+end
+local reset = math.ceil((burst - tokens) / rate * 1e6)
+
+redis.call("HSET", key, "tokens", string.format("%.17g", tokens), "last", string.format("%.17g", last))
+redis.call("PEXPIRE", key, math.ceil(reset / 1000) + 1000)
+return {allowed, math.floor(tokens + 1e-9), retry, reset}
+`)
+
+var slidingLogScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local burst = tonumber(ARGV[3])
+local w = tonumber(ARGV[4])
+
+redis.call("ZREMRANGEBYSCORE", key, "-inf", string.format("%.17g", now - w))
+local n = redis.call("ZCARD", key)
+local allowed, retry = 0, 0
+if n < burst then
+  redis.call("ZADD", key, ARGV[1], ARGV[5])
+  n = n + 1
+  allowed = 1
+else
+  local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+  retry = tonumber(oldest[2]) + w - now
+end
+local reset = 0
+if n > 0 then
+  local newest = redis.call("ZRANGE", key, -1, -1, "WITHSCORES")
+  reset = tonumber(newest[2]) + w - now
+end
+redis.call("PEXPIRE", key, math.ceil(reset / 1000) + 1000)
+return {allowed, burst - n, retry, reset}
+`)
+
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local burst = tonumber(ARGV[3])
+local w = tonumber(ARGV[4])
+
+local idx = math.floor(now / w)
+local elapsed = now - idx * w
+local data = redis.call("HMGET", key, "idx", "cur", "prev")
+local sidx = tonumber(data[1])
+local cur = tonumber(data[2]) or 0
+local prev = tonumber(data[3]) or 0
+if sidx ~= idx then
+  if sidx and idx == sidx + 1 then prev = cur else prev = 0 end
+  cur = 0
+end
+
+local est = prev * ((w - elapsed) / w) + cur
+local allowed, retry = 0, 0
+if est + 1 <= burst then
+  cur = cur + 1
+  est = est + 1
+  allowed = 1
+elseif cur + 1 > burst then
+  retry = (w - elapsed) + math.ceil(math.max(0, w * (1 - (burst - 1) / cur)))
+else
+  retry = math.ceil((w - elapsed) - w * (burst - 1 - cur) / prev)
+end
+local reset = 0
+if cur > 0 then
+  reset = 2 * w - elapsed
+elseif prev > 0 then
+  reset = w - elapsed
+end
+
+redis.call("HSET", key, "idx", string.format("%.17g", idx), "cur", cur, "prev", prev)
+redis.call("PEXPIRE", key, math.ceil(reset / 1000) + 1000)
+return {allowed, math.max(0, math.floor(burst - est + 1e-9)), retry, reset}
+`)
+
+var gcraScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+
+local t = 1e6 / rate
+local tau = t * burst
+local tat = math.max(tonumber(redis.call("GET", key)) or 0, now)
+local newTat = tat + t
+local allowAt = newTat - tau
+if now < allowAt then
+  return {0, 0, math.ceil(allowAt - now), math.ceil(tat - now)}
+end
+local reset = math.ceil(newTat - now)
+redis.call("SET", key, string.format("%.17g", newTat), "PX", math.ceil(reset / 1000) + 1000)
+return {1, math.max(0, math.floor((tau - (newTat - now)) / t + 1e-9)), 0, reset}
+`)
+
+// suffix key per algoritma: tipe data Redis-nya berbeda, jadi mengganti
+// algoritma tidak boleh membaca key algoritma lain. Token bucket tanpa
+// suffix (kompatibel dengan key lama).
+var redisAlgos = map[Algorithm]struct {
+	script *redis.Script
+	suffix string
+}{
+	TokenBucket:   {tokenBucketScript, ""},
+	SlidingLog:    {slidingLogScript, ":slog"},
+	SlidingWindow: {slidingWindowScript, ":swin"},
+	GCRA:          {gcraScript, ":gcra"},
+}
+
+type redisAlgo struct {
+	rdb    redis.UniversalClient
+	clock  Clock
+	alg    Algorithm
+	script *redis.Script
+	suffix string
+}
+
+// NewRedis: limiter di Redis (shared antar replica), satu round trip per
+// request.
+func NewRedis(rdb redis.UniversalClient, alg Algorithm, clock Clock) (Limiter, error) {
+	a, ok := redisAlgos[alg]
+	if !ok {
+		return nil, fmt.Errorf("ratelimit: unknown algorithm %q", alg)
+	}
+	if clock == nil {
+		clock = time.Now
+	}
+	return &redisAlgo{rdb: rdb, clock: clock, alg: alg, script: a.script, suffix: a.suffix}, nil
+}
+
+func (r *redisAlgo) Allow(ctx context.Context, key string, l Limit) (Result, error) {
+	args := []any{r.clock().UnixMicro(), l.Rate, l.Burst, l.window()}
+	if r.alg == SlidingLog {
+		args = append(args, uuid.NewString()) // member unik antar replica
+	}
+	v, err := r.script.Run(ctx, r.rdb, []string{key + r.suffix}, args...).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(v) < 4 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected script reply %v", v)
+	}
+	return outcome{allowed: v[0] == 1, remaining: v[1], retry: v[2], reset: v[3]}.result(l), nil
+}
@@ -0,0 +1,45 @@
+package settings
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type view struct {
+	Version         int            `json:"version"`
+	ChangedAt       time.Time      `json:"changed_at"`
+	Source          string         `json:"source"`
+	Changed         []string       `json:"changed,omitempty"`
+	Settings        map[string]any `json:"settings"`
+	RestartRequired []string       `json:"restart_required,omitempty"`
+	LastReload      *attemptView   `json:"last_reload,omitempty"`
+}
+
+type attemptView struct {
+	At     time.Time `json:"at"`
+	Source string    `json:"source"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// Handler: GET settings hot yang berlaku + kapan terakhir berubah.
+func (m *Manager) Handler(c *gin.Context) {
+	snap := m.Current()
+	v := view{
+		Version:         snap.Version,
+		ChangedAt:       snap.ChangedAt,
+		Source:          snap.Source,
+		Changed:         snap.Changed,
+		Settings:        snap.Config.Values(Hot...),
+		RestartRequired: snap.Pending,
+	}
+	if a := m.LastAttempt(); a != nil {
+		v.LastReload = &attemptView{At: a.At, Source: a.Source}
+		if a.Err != nil {
+			v.LastReload.Error = a.Err.Error()
+		}
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, v)
+}
@@ -0,0 +1,198 @@
+// Package settings: subset konfigurasi yang bisa di-reload tanpa restart
+// (rate limit, TTL cache, log level). Sumber perubahan: file --config yang
+// di-poll, SIGHUP, atau Reload manual. Sisanya tetap butuh restart.
+package settings
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Quineeryn/go-backend-101/internal/config"
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+	"github.com/Quineeryn/go-backend-101/internal/logger"
+)
+
+// Hot = path config yang boleh berubah saat runtime. Harus sinkron dengan
+// applyHot.
+var Hot = []string{"rate_limit", "cache.users_ttl", "cache.me_ttl", "log.level",
+	"cache.users_stale_ttl", "cache.users_negative_ttl", "cache.users_early_refresh_beta", "cache.users_lock", "cache.users_list_ttl",
+	"cache.codec", "cache.compression", "cache.compress_min_bytes"}
+
+func applyHot(dst *config.Config, src config.Config) {
+	dst.RateLimit = src.RateLimit
+	dst.Cache.UsersTTL = src.Cache.UsersTTL
+	dst.Cache.MeTTL = src.Cache.MeTTL
+	dst.Cache.UsersStaleTTL = src.Cache.UsersStaleTTL
+	dst.Cache.UsersNegativeTTL = src.Cache.UsersNegativeTTL
+	dst.Cache.UsersEarlyBeta = src.Cache.UsersEarlyBeta
+	dst.Cache.UsersLock = src.Cache.UsersLock
+	dst.Cache.UsersListTTL = src.Cache.UsersListTTL
+	dst.Cache.Codec = src.Cache.Codec
+	dst.Cache.Compression = src.Cache.Compression
+	dst.Cache.CompressMinBytes = src.Cache.CompressMinBytes
+	dst.Log.Level = src.Log.Level
+}
+
+// LoadFunc membaca ulang konfigurasi lengkap (biasanya config.Load dengan
+// args yang sama seperti saat boot) termasuk validasi.
+type LoadFunc func() (config.Config, error)
+
+// Snapshot = settings efektif yang sedang berlaku. Immutable.
+type Snapshot struct {
+	Config    config.Config
+	Version   int
+	ChangedAt time.Time
+	Source    string   // startup | file | sighup | ...
+	Changed   []string // path hot yang berubah di versi ini
+	Pending   []string // berubah di sumber tapi baru berlaku setelah restart
+}
+
+// Attempt = hasil reload terakhir (berhasil atau tidak).
+type Attempt struct {
+	At     time.Time
+	Source string
+	Err    error
+}
+
+type Manager struct {
+	load LoadFunc
+
+	mu   sync.Mutex // serialisasi Reload & OnChange
+	subs []func(config.Config)
+
+	cur  atomic.Pointer[Snapshot]
+	last atomic.Pointer[Attempt]
+}
+
+func New(initial config.Config, load LoadFunc) *Manager {
+	m := &Manager{load: load}
+	m.cur.Store(&Snapshot{Config: initial, Version: 1, ChangedAt: time.Now().UTC(), Source: "startup"})
+	httpx.SettingsVersion.Set(1)
+	return m
+}
+
+// Current: snapshot saat ini; aman dipanggil dari hot path.
+func (m *Manager) Current() *Snapshot { return m.cur.Load() }
+
+// Config: shortcut Current().Config.
+func (m *Manager) Config() config.Config { return m.cur.Load().Config }
+
+// LastAttempt: hasil reload terakhir; nil bila belum pernah reload.
+func (m *Manager) LastAttempt() *Attempt { return m.last.Load() }
+
+// OnChange mendaftarkan fn yang dipanggil (berurutan, sinkron) setiap ada
+// perubahan settings hot. fn tidak dipanggil untuk nilai awal.
+func (m *Manager) OnChange(fn func(config.Config)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subs = append(m.subs, fn)
+}
+
+// Reload membaca ulang konfigurasi, lalu menukar nilai hot secara atomik
+// bila valid. Konfigurasi invalid ditolak utuh; nilai lama tetap berlaku.
+func (m *Manager) Reload(source string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next, err := m.load()
+	m.last.Store(&Attempt{At: time.Now().UTC(), Source: source, Err: err})
+	if err != nil {
+		httpx.SettingsReloads.WithLabelValues(source, "error").Inc()
+		logger.L.Error("settings.reload.failed", zap.String("source", source), zap.Error(err))
+		return err
+	}
+
+	old := m.cur.Load()
+	applied := old.Config
+	applyHot(&applied, next)
+	changed := config.Diff(old.Config, applied)
+	pending := config.Diff(applied, next)
+	if len(pending) > 0 {
+		logger.L.Warn("settings.restart_required", zap.String("source", source), zap.Strings("paths", pending))
+	}
+	if len(changed) == 0 {
+		if !equal(pending, old.Pending) {
+			snap := *old
+			snap.Pending = pending
+			m.cur.Store(&snap)
+		}
+		httpx.SettingsReloads.WithLabelValues(source, "unchanged").Inc()
+		return nil
+	}
+
+	snap := &Snapshot{
+		Config:    applied,
+		Version:   old.Version + 1,
+		ChangedAt: time.Now().UTC(),
+		Source:    source,
+		Changed:   changed,
+		Pending:   pending,
+	}
+	m.cur.Store(snap)
+	for _, fn := range m.subs {
+		fn(applied)
+	}
+	httpx.SettingsReloads.WithLabelValues(source, "applied").Inc()
+	httpx.SettingsVersion.Set(float64(snap.Version))
+	// setelah subscriber: ikut log level yang baru
+	logger.L.Info("settings.reloaded", zap.String("source", source), zap.Int("version", snap.Version), zap.Strings("changed", changed))
+	return nil
+}
+
+// WatchFile mem-poll path tiap interval dan memanggil Reload("file") saat
+// isinya berubah. Polling (bukan inotify) supaya tetap jalan untuk
+// ConfigMap k8s yang di-swap lewat symlink. Berhenti saat ctx selesai.
+func (m *Manager) WatchFile(ctx context.Context, path string, every time.Duration) {
+	// hash awal kosong: tick pertama selalu reload, jadi perubahan antara
+	// boot dan watcher mulai tidak terlewat
+	var sum [sha256.Size]byte
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		next, err := fileSum(path)
+		if err != nil {
+			// file sedang diganti / sementara hilang: coba lagi tick berikutnya
+			if !errors.Is(err, os.ErrNotExist) {
+				logger.L.Warn("settings.watch", zap.String("file", path), zap.Error(err))
+			}
+			continue
+		}
+		if next == sum {
+			continue
+		}
+		sum = next
+		_ = m.Reload("file")
+	}
+}
+
+func fileSum(path string) ([sha256.Size]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(b), nil
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
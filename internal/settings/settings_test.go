@@ -0,0 +1,166 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Quineeryn/go-backend-101/internal/config"
+)
+
+func defaults(t *testing.T) config.Config {
+	t.Helper()
+	cfg, _, err := config.Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestReload_AppliesHotAndReportsRestartRequired(t *testing.T) {
+	base := defaults(t)
+	next := base
+	next.RateLimit.DefaultRPS = 50
+	next.Cache.UsersTTL = time.Minute
+	next.Server.Port = "9999" // bukan hot
+
+	m := New(base, func() (config.Config, error) { return next, nil })
+	var got []config.Config
+	m.OnChange(func(c config.Config) { got = append(got, c) })
+
+	if err := m.Reload("test"); err != nil {
+		t.Fatal(err)
+	}
+	snap := m.Current()
+	if snap.Version != 2 || snap.Source != "test" {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+	if len(got) != 1 || got[0].RateLimit.DefaultRPS != 50 || got[0].Cache.UsersTTL != time.Minute {
+		t.Fatalf("subscriber not called with new values: %+v", got)
+	}
+	if got[0].Server.Port != base.Server.Port {
+		t.Fatal("non-hot field must not be applied")
+	}
+	if want := []string{"rate_limit.default_rps", "cache.users_ttl"}; !equal(snap.Changed, want) {
+		t.Fatalf("changed = %v, want %v", snap.Changed, want)
+	}
+	if want := []string{"server.port"}; !equal(snap.Pending, want) {
+		t.Fatalf("pending = %v, want %v", snap.Pending, want)
+	}
+
+	// reload tanpa perubahan: versi & subscriber tetap
+	if err := m.Reload("test"); err != nil {
+		t.Fatal(err)
+	}
+	if m.Current().Version != 2 || len(got) != 1 {
+		t.Fatalf("unchanged reload bumped version or notified: v=%d calls=%d", m.Current().Version, len(got))
+	}
+}
+
+func TestReload_InvalidKeepsOldValues(t *testing.T) {
+	base := defaults(t)
+	m := New(base, func() (config.Config, error) { return config.Config{}, errors.New("boom") })
+	called := false
+	m.OnChange(func(config.Config) { called = true })
+
+	if err := m.Reload("sighup"); err == nil {
+		t.Fatal("want error")
+	}
+	if called || m.Current().Version != 1 || m.Config().RateLimit != base.RateLimit {
+		t.Fatal("failed reload must not change settings")
+	}
+	if a := m.LastAttempt(); a == nil || a.Err == nil || a.Source != "sighup" {
+		t.Fatalf("last attempt = %+v", a)
+	}
+}
+
+func TestApplyHot_CoversHotPaths(t *testing.T) {
+	base := defaults(t)
+	src := base
+	src.RateLimit = config.RateLimit{DefaultRPS: 9, DefaultBurst: 9, AuthRPS: 9, AuthBurst: 9}
+	src.Cache.UsersTTL, src.Cache.MeTTL = time.Hour, time.Hour
+	src.Log.Level = "debug"
+
+	dst := base
+	applyHot(&dst, src)
+	for _, p := range config.Diff(dst, src) {
+		if config.HasPrefix(p, Hot...) {
+			t.Errorf("hot path %s not copied by applyHot", p)
+		}
+	}
+	for _, p := range config.Diff(base, dst) {
+		if !config.HasPrefix(p, Hot...) {
+			t.Errorf("applyHot copied non-hot path %s", p)
+		}
+	}
+}
+
+func TestWatchFile_ReloadsOnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	write := func(body string) {
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("rate_limit:\n  default_rps: 2\n")
+	load := func() (config.Config, error) {
+		c, _, err := config.Load([]string{"--config", path})
+		return c, err
+	}
+	base, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New(base, load)
+	changed := make(chan config.Config, 1)
+	m.OnChange(func(c config.Config) { changed <- c })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.WatchFile(ctx, path, 10*time.Millisecond)
+
+	write("rate_limit:\n  default_rps: 7\nlog:\n  level: warn\n")
+	select {
+	case c := <-changed:
+		if c.RateLimit.DefaultRPS != 7 || c.Log.Level != "warn" {
+			t.Fatalf("got %+v", c.RateLimit)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("watcher did not reload")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	base := defaults(t)
+	m := New(base, func() (config.Config, error) { return base, nil })
+
+	r := gin.New()
+	r.GET("/settings", m.Handler)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/settings", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	var body struct {
+		Version  int            `json:"version"`
+		Settings map[string]any `json:"settings"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Version != 1 || body.Settings["cache.users_ttl"] != "5m0s" {
+		t.Fatalf("body = %s", w.Body.String())
+	}
+	if _, ok := body.Settings["jwt.secret"]; ok {
+		t.Fatal("non-hot settings must not be exposed")
+	}
+}
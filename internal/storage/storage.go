@@ -0,0 +1,38 @@
+// Package storage: penyimpanan blob (avatar, dll.) di filesystem lokal atau
+// object storage S3-compatible (AWS S3, MinIO, R2).
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrNotFound: object tidak ada.
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore menyimpan object berdasarkan key ("avatars/t/u/v/md.jpg").
+// Key selalu relatif, dipisah "/", tanpa "." / "..".
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete tidak error bila object sudah tidak ada.
+	Delete(ctx context.Context, key string) error
+	// URL publik untuk key (tanpa cek keberadaan).
+	URL(key string) string
+}
+
+// CheckKey menolak key yang bisa keluar dari root/bucket.
+func CheckKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") ||
+		path.Clean(key) != key || key == "." || strings.HasPrefix(key, "../") || key == ".." {
+		return errors.New("storage: invalid key " + key)
+	}
+	return nil
+}
+
+func joinURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + key
+}
@@ -0,0 +1,74 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// key gin (string) supaya *gin.Context tanpa ContextWithFallback tetap
+// membawa tenant, sama seperti "user_id".
+const (
+	CtxKeyTenantID = "tenant_id"
+	ctxKeySource   = "tenant_source"
+)
+
+// Sumber tenant pada request.
+const (
+	SourceHeader    = "header"
+	SourceSubdomain = "subdomain"
+	SourceDefault   = "default"
+	SourceToken     = "token"
+)
+
+type ctxKey struct{}
+
+// ErrMismatch: token milik tenant lain dari tenant yang diminta request.
+var ErrMismatch = errors.New("token belongs to a different tenant")
+
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext: tenant yang sudah di-resolve untuk ctx ini.
+func FromContext(ctx context.Context) (string, bool) {
+	if id, ok := ctx.Value(ctxKey{}).(string); ok && id != "" {
+		return id, true
+	}
+	if id, ok := ctx.Value(CtxKeyTenantID).(string); ok && id != "" {
+		return id, true
+	}
+	return "", false
+}
+
+// ID: tenant dari ctx, atau DefaultID (job/background tanpa request).
+// Query tidak pernah jalan tanpa filter tenant.
+func ID(ctx context.Context) string {
+	if id, ok := FromContext(ctx); ok {
+		return id
+	}
+	return DefaultID
+}
+
+func set(c *gin.Context, id, source string) {
+	c.Set(CtxKeyTenantID, id)
+	c.Set(ctxKeySource, source)
+	c.Request = c.Request.WithContext(WithID(c.Request.Context(), id))
+}
+
+// Bind menempelkan tenant dari token (claim tid) ke request. Bila request
+// sudah menyebut tenant secara eksplisit (header/subdomain) dan beda,
+// ErrMismatch: token tenant A tidak bisa dipakai di tenant B.
+func Bind(c *gin.Context, tokenTenant string) error {
+	if tokenTenant == "" {
+		tokenTenant = DefaultID // token lama sebelum ada claim tid
+	}
+	cur, ok := FromContext(c)
+	src := c.GetString(ctxKeySource)
+	if ok && cur != tokenTenant && (src == SourceHeader || src == SourceSubdomain || src == SourceToken) {
+		return ErrMismatch
+	}
+	set(c, tokenTenant, SourceToken)
+	return nil
+}
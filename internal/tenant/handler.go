@@ -0,0 +1,93 @@
+package tenant
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Quineeryn/go-backend-101/internal/apperr"
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+)
+
+type Handler struct {
+	store   *Store
+	schemas *Schemas
+}
+
+func NewHandler(store *Store, schemas *Schemas) *Handler {
+	return &Handler{store: store, schemas: schemas}
+}
+
+type CreateRequest struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// POST /v1/admin/tenants
+func (h *Handler) Create(c *gin.Context) {
+	var req CreateRequest
+	if err := httpx.DecodeJSON(c.Request, &req); err != nil {
+		httpx.AbortError(c, "tenants.create", apperr.E(apperr.Validation, "invalid request body", err))
+		return
+	}
+	req.Slug = strings.ToLower(strings.TrimSpace(req.Slug))
+	req.Name = strings.TrimSpace(req.Name)
+	if !ValidSlug(req.Slug) || req.Name == "" {
+		httpx.AbortError(c, "tenants.create", apperr.E(apperr.Validation, "slug must be a DNS label (a-z, 0-9, -) and name is required", nil))
+		return
+	}
+	t, err := h.store.Create(c.Request.Context(), Tenant{ID: uuid.NewString(), Slug: req.Slug, Name: req.Name})
+	if err != nil {
+		if errors.Is(err, ErrDuplicate) {
+			httpx.AbortError(c, "tenants.create", apperr.E(apperr.Conflict, "tenant slug already exists", err))
+			return
+		}
+		httpx.AbortError(c, "tenants.create", apperr.E(apperr.Internal, "failed to create tenant", err))
+		return
+	}
+	c.JSON(http.StatusCreated, t)
+}
+
+// GET /v1/admin/tenants
+func (h *Handler) List(c *gin.Context) {
+	ts, err := h.store.List(c.Request.Context())
+	if err != nil {
+		httpx.AbortError(c, "tenants.list", apperr.E(apperr.Internal, "failed to list tenants", err))
+		return
+	}
+	c.JSON(http.StatusOK, ts)
+}
+
+// PUT /v1/admin/tenants/:id/attributes-schema
+// Body = JSON Schema untuk attributes user; `null` menghapus schema.
+// User lama tidak divalidasi ulang, schema berlaku untuk create/update berikutnya.
+func (h *Handler) SetAttributesSchema(c *gin.Context) {
+	var schema map[string]any
+	if err := httpx.DecodeJSON(c.Request, &schema); err != nil {
+		httpx.AbortError(c, "tenants.schema", apperr.E(apperr.Validation, "body must be a JSON Schema object or null", err))
+		return
+	}
+	if schema != nil {
+		if _, err := CompileSchema(schema); err != nil {
+			httpx.AbortError(c, "tenants.schema", apperr.E(apperr.Validation, "invalid JSON Schema: "+err.Error(), err))
+			return
+		}
+	}
+	id := c.Param("id")
+	t, err := h.store.SetAttributesSchema(c.Request.Context(), id, schema)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			httpx.AbortError(c, "tenants.schema", apperr.E(apperr.NotFound, "tenant not found", err))
+			return
+		}
+		httpx.AbortError(c, "tenants.schema", apperr.E(apperr.Internal, "failed to update schema", err))
+		return
+	}
+	if h.schemas != nil {
+		h.schemas.Invalidate(id)
+	}
+	c.JSON(http.StatusOK, t)
+}
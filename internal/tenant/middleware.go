@@ -0,0 +1,132 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Quineeryn/go-backend-101/internal/apperr"
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+)
+
+type Options struct {
+	Header     string        // mis. "X-Tenant-ID" (slug atau ID)
+	BaseDomain string        // "api.example.com" → acme.api.example.com = tenant "acme"; kosong = mati
+	Default    string        // slug bila request tidak menyebut tenant; kosong = wajib
+	CacheTTL   time.Duration // cache slug → ID (default 1m)
+}
+
+// endpoint infrastruktur tidak butuh tenant
+var bypass = map[string]bool{
+	"/metrics": true, "/health": true, "/livez": true, "/readyz": true,
+	"/openapi.yaml": true, "/docs": true, "/media/*filepath": true,
+}
+
+// Middleware me-resolve tenant dari header, lalu subdomain, lalu default,
+// dan menyimpannya di context. Token (claim tid) diperiksa belakangan di
+// auth.RequireAuth lewat Bind.
+func Middleware(store *Store, opt Options) gin.HandlerFunc {
+	if opt.CacheTTL <= 0 {
+		opt.CacheTTL = time.Minute
+	}
+	r := &resolver{store: store, ttl: opt.CacheTTL, cache: map[string]cached{}}
+	return func(c *gin.Context) {
+		if bypass[c.FullPath()] {
+			c.Next()
+			return
+		}
+		ref, source := "", ""
+		if opt.Header != "" {
+			if v := strings.TrimSpace(c.GetHeader(opt.Header)); v != "" {
+				ref, source = v, SourceHeader
+			}
+		}
+		if ref == "" {
+			if sub := subdomain(c.Request.Host, opt.BaseDomain); sub != "" {
+				ref, source = sub, SourceSubdomain
+			}
+		}
+		if ref == "" && opt.Default != "" {
+			ref, source = opt.Default, SourceDefault
+		}
+		if ref == "" {
+			httpx.AbortError(c, "tenant.resolve", apperr.E(apperr.Validation, "tenant is required (header "+opt.Header+" or subdomain)", nil))
+			return
+		}
+		id, err := r.lookup(c.Request.Context(), ref)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				httpx.AbortError(c, "tenant.resolve", apperr.E(apperr.NotFound, "unknown tenant", err))
+				return
+			}
+			httpx.AbortError(c, "tenant.resolve", apperr.E(apperr.Internal, "failed to resolve tenant", err))
+			return
+		}
+		set(c, id, source)
+		c.Next()
+	}
+}
+
+// RequirePlatform: hanya request di tenant default (admin platform),
+// untuk endpoint yang melihat data lintas tenant.
+func RequirePlatform() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ID(c) != DefaultID {
+			httpx.AbortError(c, "tenant.platform", apperr.E(apperr.Forbidden, "platform admin only", nil))
+			return
+		}
+		c.Next()
+	}
+}
+
+func subdomain(host, base string) string {
+	if base == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	label, ok := strings.CutSuffix(host, "."+strings.ToLower(base))
+	if !ok || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+type cached struct {
+	id  string
+	exp time.Time
+}
+
+type resolver struct {
+	store *Store
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cached
+}
+
+func (r *resolver) lookup(ctx context.Context, ref string) (string, error) {
+	now := time.Now()
+	r.mu.Lock()
+	if e, ok := r.cache[ref]; ok && now.Before(e.exp) {
+		r.mu.Unlock()
+		return e.id, nil
+	}
+	r.mu.Unlock()
+
+	t, err := r.store.Lookup(ctx, ref)
+	if err != nil {
+		return "", err // tidak di-cache: slug baru langsung bisa dipakai
+	}
+	r.mu.Lock()
+	r.cache[ref] = cached{id: t.ID, exp: now.Add(r.ttl)}
+	r.mu.Unlock()
+	return t.ID, nil
+}
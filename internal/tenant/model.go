@@ -0,0 +1,25 @@
+package tenant
+
+import (
+	"regexp"
+	"time"
+)
+
+// DefaultID: tenant bawaan. Data sebelum multi-tenancy (dan deployment
+// single-tenant) ada di sini; juga "platform" untuk endpoint admin global.
+const DefaultID = "default"
+
+type Tenant struct {
+	ID   string `json:"id" gorm:"primaryKey"`
+	Slug string `json:"slug" gorm:"not null;uniqueIndex:tenants_slug_key"`
+	Name string `json:"name" gorm:"not null"`
+	// JSON Schema untuk users.User.Attributes; nil = bebas
+	AttributesSchema map[string]any `json:"attributes_schema,omitempty" gorm:"column:attributes_schema;serializer:json"`
+	CreatedAt        time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// slug dipakai sebagai label subdomain → aturan label DNS
+var slugRe = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+func ValidSlug(s string) bool { return slugRe.MatchString(s) }
@@ -0,0 +1,131 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// ErrInvalidAttributes: attributes user tidak lolos JSON Schema tenant.
+var ErrInvalidAttributes = errors.New("attributes do not match tenant schema")
+
+// URL internal schema (bukan path file: relatif ke cwd akan bocor di pesan error)
+const schemaURL = "urn:tenant:attributes"
+
+// noLoader: $ref ke URL/file luar ditolak, schema harus self-contained.
+type noLoader struct{}
+
+func (noLoader) Load(url string) (any, error) {
+	return nil, fmt.Errorf("external $ref not allowed: %s", url)
+}
+
+// CompileSchema meng-compile JSON Schema (draft 2020-12 bila $schema kosong).
+func CompileSchema(schema map[string]any) (*jsonschema.Schema, error) {
+	// round-trip lewat UnmarshalJSON supaya angka jadi json.Number (presisi)
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(string(b)))
+	if err != nil {
+		return nil, err
+	}
+	c := jsonschema.NewCompiler()
+	c.UseLoader(noLoader{})
+	if err := c.AddResource(schemaURL, doc); err != nil {
+		return nil, err
+	}
+	return c.Compile(schemaURL)
+}
+
+// Schemas memvalidasi attributes user terhadap schema tenant di ctx.
+// Schema yang sudah di-compile di-cache per tenant selama ttl.
+type Schemas struct {
+	store *Store
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	cache map[string]compiledSchema
+}
+
+type compiledSchema struct {
+	sch *jsonschema.Schema // nil = tenant tanpa schema (bebas)
+	exp time.Time
+}
+
+func NewSchemas(store *Store, ttl time.Duration) *Schemas {
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	return &Schemas{store: store, ttl: ttl, cache: map[string]compiledSchema{}}
+}
+
+// Validate: nil bila tenant tidak punya schema atau attrs valid; selain itu
+// error yang membungkus ErrInvalidAttributes.
+func (s *Schemas) Validate(ctx context.Context, attrs map[string]any) error {
+	sch, err := s.schema(ctx, ID(ctx))
+	if err != nil || sch == nil {
+		return err
+	}
+	var doc any = map[string]any{}
+	if attrs != nil {
+		b, err := json.Marshal(attrs)
+		if err != nil {
+			return err
+		}
+		if doc, err = jsonschema.UnmarshalJSON(strings.NewReader(string(b))); err != nil {
+			return err
+		}
+	}
+	if err := sch.Validate(doc); err != nil {
+		// baris pertama hanya "validation failed with <url>", sisanya detail per path
+		lines := strings.Split(err.Error(), "\n")
+		if len(lines) > 1 {
+			lines = lines[1:]
+		}
+		for i := range lines {
+			lines[i] = strings.TrimPrefix(strings.TrimSpace(lines[i]), "- ")
+		}
+		return fmt.Errorf("%w: %s", ErrInvalidAttributes, strings.Join(lines, "; "))
+	}
+	return nil
+}
+
+// Invalidate membuang cache satu tenant (setelah schema diganti).
+func (s *Schemas) Invalidate(id string) {
+	s.mu.Lock()
+	delete(s.cache, id)
+	s.mu.Unlock()
+}
+
+func (s *Schemas) schema(ctx context.Context, id string) (*jsonschema.Schema, error) {
+	now := time.Now()
+	s.mu.Lock()
+	if e, ok := s.cache[id]; ok && now.Before(e.exp) {
+		s.mu.Unlock()
+		return e.sch, nil
+	}
+	s.mu.Unlock()
+
+	t, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var sch *jsonschema.Schema
+	if t.AttributesSchema != nil {
+		// schema disimpan hanya setelah lolos compile; gagal di sini = data rusak
+		if sch, err = CompileSchema(t.AttributesSchema); err != nil {
+			return nil, fmt.Errorf("tenant %s: stored attributes schema: %w", id, err)
+		}
+	}
+	s.mu.Lock()
+	s.cache[id] = compiledSchema{sch: sch, exp: now.Add(s.ttl)}
+	s.mu.Unlock()
+	return sch, nil
+}
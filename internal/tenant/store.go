@@ -0,0 +1,69 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound  = errors.New("tenant not found")
+	ErrDuplicate = errors.New("tenant slug already exists")
+)
+
+type Store struct{ db *gorm.DB }
+
+func NewStore(db *gorm.DB) *Store { return &Store{db: db} }
+
+func (s *Store) Create(ctx context.Context, t Tenant) (Tenant, error) {
+	if err := s.db.WithContext(ctx).Create(&t).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(strings.ToLower(err.Error()), "unique") ||
+			strings.Contains(err.Error(), "23505") {
+			return Tenant{}, ErrDuplicate
+		}
+		return Tenant{}, err
+	}
+	return t, nil
+}
+
+func (s *Store) List(ctx context.Context) ([]Tenant, error) {
+	var out []Tenant
+	err := s.db.WithContext(ctx).Order("created_at ASC").Find(&out).Error
+	return out, err
+}
+
+func (s *Store) Get(ctx context.Context, id string) (Tenant, error) {
+	var t Tenant
+	err := s.db.WithContext(ctx).First(&t, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Tenant{}, ErrNotFound
+	}
+	return t, err
+}
+
+// SetAttributesSchema mengganti schema attributes user; nil = hapus schema.
+func (s *Store) SetAttributesSchema(ctx context.Context, id string, schema map[string]any) (Tenant, error) {
+	res := s.db.WithContext(ctx).Model(&Tenant{ID: id}).
+		Select("attributes_schema", "updated_at").
+		Updates(&Tenant{AttributesSchema: schema, UpdatedAt: time.Now().UTC()})
+	if res.Error != nil {
+		return Tenant{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return Tenant{}, ErrNotFound
+	}
+	return s.Get(ctx, id)
+}
+
+// Lookup mencari tenant berdasarkan slug atau ID.
+func (s *Store) Lookup(ctx context.Context, ref string) (Tenant, error) {
+	var t Tenant
+	err := s.db.WithContext(ctx).Where("slug = ? OR id = ?", ref, ref).First(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Tenant{}, ErrNotFound
+	}
+	return t, err
+}
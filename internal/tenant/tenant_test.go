@@ -0,0 +1,191 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Quineeryn/go-backend-101/internal/middleware"
+	"github.com/Quineeryn/go-backend-101/internal/testdb"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	db := testdb.Open(t)
+	if err := db.AutoMigrate(&Tenant{}); err != nil {
+		t.Fatal(err)
+	}
+	s := NewStore(db)
+	for _, tn := range []Tenant{
+		{ID: DefaultID, Slug: "default", Name: "Default"},
+		{ID: "t-acme", Slug: "acme", Name: "Acme"},
+		{ID: "t-globex", Slug: "globex", Name: "Globex"},
+	} {
+		if _, err := s.Create(context.Background(), tn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+// router: middleware tenant + endpoint yang mengembalikan tenant ter-resolve.
+// ?token=<tid> mensimulasikan RequireAuth (Bind dengan claim tid).
+func newRouter(s *Store, opt Options) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorEnvelope(), Middleware(s, opt))
+	r.GET("/whoami", func(c *gin.Context) {
+		if tok, ok := c.GetQuery("token"); ok {
+			if err := Bind(c, tok); err != nil {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+		}
+		c.String(http.StatusOK, ID(c.Request.Context()))
+	})
+	r.GET("/health", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	return r
+}
+
+func do(r http.Handler, host, header, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Host = host
+	if header != "" {
+		req.Header.Set("X-Tenant-ID", header)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_Resolve(t *testing.T) {
+	r := newRouter(newTestStore(t), Options{Header: "X-Tenant-ID", BaseDomain: "api.example.com", Default: "default"})
+
+	cases := []struct {
+		name, host, header string
+		code               int
+		want               string
+	}{
+		{"header slug", "localhost", "acme", 200, "t-acme"},
+		{"header id", "localhost", "t-globex", 200, "t-globex"},
+		{"subdomain", "acme.api.example.com:8080", "", 200, "t-acme"},
+		{"header beats subdomain", "acme.api.example.com", "globex", 200, "t-globex"},
+		{"nested subdomain ignored", "x.acme.api.example.com", "", 200, DefaultID},
+		{"default", "localhost", "", 200, DefaultID},
+		{"unknown header", "localhost", "nope", 404, ""},
+		{"unknown subdomain", "nope.api.example.com", "", 404, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := do(r, tc.host, tc.header, "/whoami")
+			if w.Code != tc.code {
+				t.Fatalf("want %d, got %d (%s)", tc.code, w.Code, w.Body.String())
+			}
+			if tc.code == 200 && w.Body.String() != tc.want {
+				t.Fatalf("want tenant %q, got %q", tc.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMiddleware_RequiredWithoutDefault(t *testing.T) {
+	r := newRouter(newTestStore(t), Options{Header: "X-Tenant-ID"})
+	if w := do(r, "localhost", "", "/whoami"); w.Code != http.StatusBadRequest {
+		t.Fatalf("want 400 without tenant, got %d", w.Code)
+	}
+	// endpoint infrastruktur tidak butuh tenant
+	if w := do(r, "localhost", "", "/health"); w.Code != http.StatusOK {
+		t.Fatalf("/health: want 200, got %d", w.Code)
+	}
+}
+
+func TestBind_CrossTenantToken(t *testing.T) {
+	r := newRouter(newTestStore(t), Options{Header: "X-Tenant-ID", BaseDomain: "api.example.com", Default: "default"})
+
+	// token acme di tenant acme: ok
+	if w := do(r, "localhost", "acme", "/whoami?token=t-acme"); w.Code != 200 || w.Body.String() != "t-acme" {
+		t.Fatalf("same tenant: got %d %q", w.Code, w.Body.String())
+	}
+	// token acme dipakai ke globex (header maupun subdomain): ditolak
+	if w := do(r, "localhost", "globex", "/whoami?token=t-acme"); w.Code != http.StatusForbidden {
+		t.Fatalf("header mismatch: want 403, got %d", w.Code)
+	}
+	if w := do(r, "globex.api.example.com", "", "/whoami?token=t-acme"); w.Code != http.StatusForbidden {
+		t.Fatalf("subdomain mismatch: want 403, got %d", w.Code)
+	}
+	// tanpa tenant eksplisit: tenant ikut token
+	if w := do(r, "localhost", "", "/whoami?token=t-acme"); w.Code != 200 || w.Body.String() != "t-acme" {
+		t.Fatalf("token tenant: got %d %q", w.Code, w.Body.String())
+	}
+	// token lama tanpa tid = default; tidak bisa dipakai di tenant lain
+	if w := do(r, "localhost", "acme", "/whoami?token="); w.Code != http.StatusForbidden {
+		t.Fatalf("legacy token in acme: want 403, got %d", w.Code)
+	}
+}
+
+func TestRequirePlatform(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorEnvelope(), Middleware(newTestStore(t), Options{Header: "X-Tenant-ID", Default: "default"}))
+	r.GET("/admin", RequirePlatform(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	if w := do(r, "localhost", "", "/admin"); w.Code != http.StatusNoContent {
+		t.Fatalf("default tenant: want 204, got %d", w.Code)
+	}
+	if w := do(r, "localhost", "acme", "/admin"); w.Code != http.StatusForbidden {
+		t.Fatalf("acme: want 403, got %d", w.Code)
+	}
+}
+
+func TestSchemas_Validate(t *testing.T) {
+	s := newTestStore(t)
+	schemas := NewSchemas(s, time.Minute)
+	ctx := WithID(context.Background(), "t-acme")
+
+	// tanpa schema: bebas
+	if err := schemas.Validate(ctx, map[string]any{"anything": []any{1, "x"}}); err != nil {
+		t.Fatalf("no schema: %v", err)
+	}
+
+	schema := map[string]any{
+		"type":                 "object",
+		"required":             []any{"plan"},
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"plan":  map[string]any{"enum": []any{"free", "pro"}},
+			"seats": map[string]any{"type": "integer", "minimum": 1},
+		},
+	}
+	if _, err := s.SetAttributesSchema(context.Background(), "t-acme", schema); err != nil {
+		t.Fatal(err)
+	}
+	schemas.Invalidate("t-acme")
+
+	if err := schemas.Validate(ctx, map[string]any{"plan": "pro", "seats": float64(3)}); err != nil {
+		t.Fatalf("valid attrs: %v", err)
+	}
+	for _, bad := range []map[string]any{
+		nil,
+		{"plan": "gold"},
+		{"plan": "pro", "seats": float64(0)},
+		{"plan": "pro", "extra": true},
+	} {
+		if err := schemas.Validate(ctx, bad); !errors.Is(err, ErrInvalidAttributes) {
+			t.Fatalf("%v: want ErrInvalidAttributes, got %v", bad, err)
+		}
+	}
+	// schema tenant lain tidak berlaku
+	if err := schemas.Validate(WithID(context.Background(), "t-globex"), map[string]any{"plan": "gold"}); err != nil {
+		t.Fatalf("other tenant: %v", err)
+	}
+
+	// $ref ke luar ditolak saat compile
+	if _, err := CompileSchema(map[string]any{"$ref": "https://example.com/s.json"}); err == nil {
+		t.Fatal("external $ref must be rejected")
+	}
+}
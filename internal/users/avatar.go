@@ -0,0 +1,170 @@
+package users
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // decoder
+	"image/jpeg"
+	_ "image/png" // decoder
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // decoder
+
+	"github.com/Quineeryn/go-backend-101/internal/logger"
+	"github.com/Quineeryn/go-backend-101/internal/storage"
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
+)
+
+// AvatarSizes: nama varian → sisi (px). Gambar di-crop persegi di tengah,
+// di-resize, lalu di-encode ulang ke JPEG (metadata/EXIF ikut terbuang).
+var AvatarSizes = map[string]int{"sm": 64, "md": 256, "lg": 512}
+
+// AvatarOptions: batas upload.
+type AvatarOptions struct {
+	MaxBytes  int64 // ukuran file upload
+	MaxPixels int   // lebar×tinggi sebelum decode (cegah decompression bomb)
+}
+
+var (
+	errAvatarTooLarge    = errors.New("avatar file too large")
+	errAvatarUnsupported = errors.New("avatar must be a JPEG, PNG, GIF or WebP image")
+	errAvatarDimensions  = errors.New("avatar image dimensions too large")
+)
+
+var avatarTypes = map[string]bool{"image/jpeg": true, "image/png": true, "image/gif": true, "image/webp": true}
+
+// readAvatar mengambil byte gambar dari multipart (field "file" atau
+// "avatar") atau body mentah; lebih dari max → errAvatarTooLarge.
+func readAvatar(r *http.Request, max int64) ([]byte, error) {
+	src := io.Reader(r.Body)
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mt == "multipart/form-data" {
+		// part lain ikut dibaca; beri ruang untuk header multipart saja
+		r.Body = http.MaxBytesReader(nil, r.Body, max+64<<10)
+		mr, err := r.MultipartReader()
+		if err != nil {
+			return nil, err
+		}
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return nil, errors.New(`multipart body needs a "file" or "avatar" part`)
+			}
+			var mbe *http.MaxBytesError
+			if errors.As(err, &mbe) {
+				return nil, errAvatarTooLarge
+			}
+			if err != nil {
+				return nil, err
+			}
+			if name := part.FormName(); name == "file" || name == "avatar" {
+				src = part
+				break
+			}
+		}
+	}
+	b, err := io.ReadAll(io.LimitReader(src, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > max {
+		return nil, errAvatarTooLarge
+	}
+	return b, nil
+}
+
+// processAvatar: sniff isi (bukan percaya Content-Type), cek dimensi,
+// decode, lalu hasilkan JPEG untuk tiap AvatarSizes.
+func processAvatar(data []byte, maxPixels int) (map[string][]byte, error) {
+	if !avatarTypes[http.DetectContentType(data)] {
+		return nil, errAvatarUnsupported
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errAvatarUnsupported
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, errAvatarDimensions
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errAvatarUnsupported
+	}
+
+	// crop persegi di tengah
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+	crop := image.Rect(x0, y0, x0+side, y0+side)
+
+	out := make(map[string][]byte, len(AvatarSizes))
+	for name, size := range AvatarSizes {
+		dst := image.NewRGBA(image.Rect(0, 0, size, size))
+		// JPEG tanpa alpha: transparan jadi putih
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Over, nil)
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, err
+		}
+		out[name] = buf.Bytes()
+	}
+	return out, nil
+}
+
+// avatarBlobKey: prefix "avatars/<tenant>/<user>/<versi>"; versi baru tiap
+// upload → URL berubah, cache CDN/browser tidak perlu di-purge.
+func avatarBlobKey(ctx context.Context, userID string) string {
+	return "avatars/" + tenant.ID(ctx) + "/" + userID + "/" + uuid.NewString()
+}
+
+func avatarObject(prefix, size string) string { return prefix + "/" + size + ".jpg" }
+
+// AvatarURLs: URL tiap ukuran; nil bila user belum upload.
+func AvatarURLs(blobs storage.BlobStore, prefix string) map[string]string {
+	if blobs == nil || prefix == "" {
+		return nil
+	}
+	out := make(map[string]string, len(AvatarSizes))
+	for name := range AvatarSizes {
+		out[name] = blobs.URL(avatarObject(prefix, name))
+	}
+	return out
+}
+
+func putAvatar(ctx context.Context, blobs storage.BlobStore, prefix string, images map[string][]byte) error {
+	for name, b := range images {
+		if err := blobs.Put(ctx, avatarObject(prefix, name), bytes.NewReader(b), int64(len(b)), "image/jpeg"); err != nil {
+			return fmt.Errorf("put avatar %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// deleteAvatar best effort: blob yatim hanya makan tempat, tidak merusak data.
+func deleteAvatar(ctx context.Context, blobs storage.BlobStore, prefix string) {
+	if blobs == nil || prefix == "" {
+		return
+	}
+	for name := range AvatarSizes {
+		if err := blobs.Delete(ctx, avatarObject(prefix, name)); err != nil {
+			logger.L.Warn("avatar.delete_failed", zap.String("key", avatarObject(prefix, name)), zap.Error(err))
+		}
+	}
+}
+
+func isMultipartOrImage(ct string) bool {
+	mt, _, _ := mime.ParseMediaType(ct)
+	return mt == "multipart/form-data" || strings.HasPrefix(mt, "image/") || mt == "application/octet-stream"
+}
@@ -0,0 +1,263 @@
+package users
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+	"github.com/Quineeryn/go-backend-101/internal/logger"
+	"github.com/Quineeryn/go-backend-101/internal/storage"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newAvatarHTTP(t *testing.T, opt AvatarOptions) (*gin.Engine, string, string) {
+	t.Helper()
+	r, store := newHTTP(t)
+	dir := t.TempDir()
+	blobs, err := storage.NewLocal(dir, "/media")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(store).WithAvatars(blobs, opt)
+	r.PUT("/v1/users/:id/avatar", testIdentity, h.PutAvatar)
+	r.DELETE("/v1/users/:id/avatar", testIdentity, h.DeleteAvatar)
+	// handler bawaan newHTTP tanpa blob store
+	r.GET("/v2/users/:id", h.Get)
+	r.DELETE("/v2/users/:id", h.Delete)
+
+	w := doJSON(r, http.MethodPost, "/v1/users", map[string]any{"name": "Ana", "email": "ana@example.com"})
+	var u UserResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &u)
+	return r, u.ID, dir
+}
+
+// putAvatarRaw: upload sebagai user itu sendiri.
+func putAvatarRaw(r *gin.Engine, id, ct string, body []byte) *httptest.ResponseRecorder {
+	return avatarAs(r, http.MethodPut, id, ct, body, id, "user")
+}
+
+func avatarAs(r *gin.Engine, method, id, ct string, body []byte, user, role string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/v1/users/"+id+"/avatar", bytes.NewReader(body))
+	if ct != "" {
+		req.Header.Set("Content-Type", ct)
+	}
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+		req.Header.Set("X-Test-Role", role)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// file blob (relatif ke dir) yang tersisa
+func blobFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var out []string
+	_ = filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, _ := filepath.Rel(dir, p)
+			out = append(out, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return out
+}
+
+func TestAvatar_UploadReplaceDelete(t *testing.T) {
+	r, id, dir := newAvatarHTTP(t, AvatarOptions{MaxBytes: 1 << 20, MaxPixels: 1 << 20})
+
+	// raw body, gambar 300x200 → crop persegi, tiap ukuran JPEG
+	w := putAvatarRaw(r, id, "image/png", testPNG(t, 300, 200))
+	if w.Code != http.StatusOK {
+		t.Fatalf("raw upload: %d %s", w.Code, w.Body.String())
+	}
+	var got UserResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &got)
+	if len(got.Avatars) != len(AvatarSizes) || !strings.HasPrefix(got.Avatars["md"], "/media/avatars/default/"+id+"/") {
+		t.Fatalf("avatars = %v", got.Avatars)
+	}
+	first := blobFiles(t, dir)
+	if len(first) != len(AvatarSizes) {
+		t.Fatalf("blobs after upload = %v", first)
+	}
+	for name, size := range AvatarSizes {
+		b, err := os.ReadFile(filepath.Join(dir, strings.TrimPrefix(got.Avatars[name], "/media/")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := jpeg.DecodeConfig(bytes.NewReader(b))
+		if err != nil || img.Width != size || img.Height != size {
+			t.Fatalf("%s: %+v %v", name, img, err)
+		}
+	}
+
+	// multipart menggantikan; blob lama dihapus
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("note", "ignored")
+	fw, _ := mw.CreateFormFile("file", "me.png")
+	_, _ = fw.Write(testPNG(t, 64, 64))
+	_ = mw.Close()
+	w = putAvatarRaw(r, id, mw.FormDataContentType(), body.Bytes())
+	if w.Code != http.StatusOK {
+		t.Fatalf("multipart upload: %d %s", w.Code, w.Body.String())
+	}
+	var again UserResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &again)
+	if again.Avatars["md"] == got.Avatars["md"] {
+		t.Fatal("replaced avatar should get a new URL")
+	}
+	second := blobFiles(t, dir)
+	if len(second) != len(AvatarSizes) || second[0] == first[0] {
+		t.Fatalf("old blobs not removed: %v", second)
+	}
+
+	// GET ikut mengembalikan URL
+	w = doJSON(r, http.MethodGet, "/v2/users/"+id, nil)
+	var fetched UserResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &fetched)
+	if fetched.Avatars["sm"] != again.Avatars["sm"] {
+		t.Fatalf("get avatars = %v", fetched.Avatars)
+	}
+
+	// DELETE avatar → blob bersih, URL hilang
+	if w := avatarAs(r, http.MethodDelete, id, "", nil, id, "user"); w.Code != http.StatusNoContent {
+		t.Fatalf("delete avatar: %d", w.Code)
+	}
+	if left := blobFiles(t, dir); len(left) != 0 {
+		t.Fatalf("blobs left: %v", left)
+	}
+	w = doJSON(r, http.MethodGet, "/v2/users/"+id, nil)
+	fetched = UserResponse{}
+	_ = json.Unmarshal(w.Body.Bytes(), &fetched)
+	if fetched.Avatars != nil {
+		t.Fatalf("avatars after delete = %v", fetched.Avatars)
+	}
+
+	// hapus user juga membersihkan avatar
+	putAvatarRaw(r, id, "image/png", testPNG(t, 32, 32))
+	if w := doJSON(r, http.MethodDelete, "/v2/users/"+id, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("delete user: %d", w.Code)
+	}
+	if left := blobFiles(t, dir); len(left) != 0 {
+		t.Fatalf("blobs left after user delete: %v", left)
+	}
+}
+
+func TestAvatar_Rejects(t *testing.T) {
+	r, id, dir := newAvatarHTTP(t, AvatarOptions{MaxBytes: 4 << 10, MaxPixels: 100 * 100})
+
+	cases := []struct {
+		name string
+		ct   string
+		body []byte
+		want int
+	}{
+		{"too large", "image/png", bytes.Repeat([]byte{0}, 5<<10), http.StatusRequestEntityTooLarge},
+		{"not an image", "image/png", []byte("<html>hi</html>"), http.StatusUnsupportedMediaType},
+		{"json content type", "application/json", testPNG(t, 8, 8), http.StatusUnsupportedMediaType},
+		{"too many pixels", "image/png", testPNG(t, 101, 100), http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		if w := putAvatarRaw(r, id, tc.ct, tc.body); w.Code != tc.want {
+			t.Fatalf("%s: want %d, got %d %s", tc.name, tc.want, w.Code, w.Body.String())
+		}
+	}
+	if w := putAvatarRaw(r, "missing", "image/png", testPNG(t, 8, 8)); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown user: want 404, got %d", w.Code)
+	}
+	if left := blobFiles(t, dir); len(left) != 0 {
+		t.Fatalf("rejected uploads left blobs: %v", left)
+	}
+}
+
+func TestAvatar_OnlySelfOrAdmin(t *testing.T) {
+	r, id, dir := newAvatarHTTP(t, AvatarOptions{MaxBytes: 1 << 20, MaxPixels: 1 << 20})
+	img := testPNG(t, 16, 16)
+
+	if w := avatarAs(r, http.MethodPut, id, "image/png", img, "", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous upload: want 401, got %d", w.Code)
+	}
+	if w := avatarAs(r, http.MethodPut, id, "image/png", img, "mallory", "user"); w.Code != http.StatusForbidden {
+		t.Fatalf("other user upload: want 403, got %d", w.Code)
+	}
+	if left := blobFiles(t, dir); len(left) != 0 {
+		t.Fatalf("rejected uploads left blobs: %v", left)
+	}
+	if w := avatarAs(r, http.MethodPut, id, "image/png", img, "root", "admin"); w.Code != http.StatusOK {
+		t.Fatalf("admin upload: want 200, got %d %s", w.Code, w.Body.String())
+	}
+	if w := avatarAs(r, http.MethodDelete, id, "", nil, "mallory", "user"); w.Code != http.StatusForbidden {
+		t.Fatalf("other user delete: want 403, got %d", w.Code)
+	}
+	if len(blobFiles(t, dir)) == 0 {
+		t.Fatal("forbidden delete removed blobs")
+	}
+	if w := avatarAs(r, http.MethodDelete, id, "", nil, id, "user"); w.Code != http.StatusNoContent {
+		t.Fatalf("self delete: want 204, got %d", w.Code)
+	}
+}
+
+func TestAvatar_DeleteIsAudited(t *testing.T) {
+	r, id, _ := newAvatarHTTP(t, AvatarOptions{MaxBytes: 1 << 20, MaxPixels: 1 << 20})
+	if w := putAvatarRaw(r, id, "image/png", testPNG(t, 16, 16)); w.Code != http.StatusOK {
+		t.Fatalf("upload: want 200, got %d", w.Code)
+	}
+
+	core, logs := observer.New(zap.InfoLevel)
+	prev := logger.L
+	logger.L = zap.New(core)
+	t.Cleanup(func() { logger.L = prev })
+
+	avatarAs(r, http.MethodDelete, id, "", nil, "mallory", "user")
+	if w := avatarAs(r, http.MethodDelete, id, "", nil, "root", "admin"); w.Code != http.StatusNoContent {
+		t.Fatalf("admin delete: want 204, got %d", w.Code)
+	}
+
+	var got []map[string]any
+	for _, e := range logs.FilterMessage("audit").All() {
+		got = append(got, e.ContextMap())
+	}
+	if len(got) != 2 {
+		t.Fatalf("want 2 audit entries, got %d: %v", len(got), got)
+	}
+	for i, want := range []struct {
+		user    string
+		success bool
+	}{{"mallory", false}, {"root", true}} {
+		ev := got[i]
+		if ev["action"] != httpx.ActionUserAvatarDelete || ev["resource"] != id || ev["user_id"] != want.user || ev["success"] != want.success {
+			t.Errorf("audit[%d] = %v", i, ev)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package users
+
+import (
+	"encoding/json"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// cacheSchema: versi bentuk value cache user (cacheEntry, cachedUserList).
+// Naikkan setiap field berubah arti/tipe: entry lama jadi miss, bukan salah
+// baca. Menambah field baru di akhir tidak wajib menaikkan.
+const cacheSchema = 1
+
+// cachedUser: DTO yang disimpan di Redis. Hanya field yang boleh keluar dari
+// DB — PasswordHash dan field internal sengaja tidak ada, dan field baru di
+// User tidak otomatis ikut ter-cache.
+//
+// Wire protobuf (codec "protobuf"):
+//
+//	message CachedUser {
+//	  string id = 1; string tenant_id = 2; string name = 3; string email = 4;
+//	  string role = 5; string display_name = 6; string avatar_url = 7;
+//	  string locale = 8; string timezone = 9; string phone = 10;
+//	  string avatar_key = 11;
+//	  string created_at = 12;  // RFC 3339, offset dipertahankan
+//	  string updated_at = 13;
+//	  bytes attributes = 14;   // JSON, sama dengan kolom DB
+//	}
+type cachedUser struct {
+	ID          string          `json:"id"`
+	TenantID    string          `json:"tenant_id"`
+	Name        string          `json:"name"`
+	Email       string          `json:"email"`
+	Role        string          `json:"role"`
+	DisplayName string          `json:"display_name,omitempty"`
+	AvatarURL   string          `json:"avatar_url,omitempty"`
+	Locale      string          `json:"locale,omitempty"`
+	Timezone    string          `json:"timezone,omitempty"`
+	Phone       string          `json:"phone,omitempty"`
+	AvatarKey   string          `json:"avatar_key,omitempty"`
+	Attributes  json.RawMessage `json:"attributes,omitempty"`
+	CreatedAt   string          `json:"created_at"`
+	UpdatedAt   string          `json:"updated_at"`
+}
+
+func toCached(u User) *cachedUser {
+	c := &cachedUser{
+		ID: u.ID, TenantID: u.TenantID, Name: u.Name, Email: u.Email, Role: u.Role,
+		DisplayName: u.DisplayName, AvatarURL: u.AvatarURL, Locale: u.Locale,
+		Timezone: u.Timezone, Phone: u.Phone, AvatarKey: u.AvatarKey,
+		CreatedAt: formatTime(u.CreatedAt), UpdatedAt: formatTime(u.UpdatedAt),
+	}
+	if u.Attributes != nil {
+		c.Attributes, _ = json.Marshal(u.Attributes)
+	}
+	return c
+}
+
+func (c *cachedUser) user() User {
+	u := User{
+		ID: c.ID, TenantID: c.TenantID, Name: c.Name, Email: c.Email, Role: c.Role,
+		Profile: Profile{
+			DisplayName: c.DisplayName, AvatarURL: c.AvatarURL, Locale: c.Locale,
+			Timezone: c.Timezone, Phone: c.Phone,
+		},
+		AvatarKey: c.AvatarKey,
+		CreatedAt: parseTime(c.CreatedAt), UpdatedAt: parseTime(c.UpdatedAt),
+	}
+	if len(c.Attributes) > 0 {
+		_ = json.Unmarshal(c.Attributes, &u.Attributes)
+	}
+	return u
+}
+
+// waktu sebagai RFC 3339 (seperti encoding/json): offset dari DB ikut
+// tersimpan sehingga nilai hasil cache sama dengan hasil query.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
+
+// stringFields: field protobuf 1..13 berurutan.
+func (c *cachedUser) stringFields() []*string {
+	return []*string{&c.ID, &c.TenantID, &c.Name, &c.Email, &c.Role, &c.DisplayName,
+		&c.AvatarURL, &c.Locale, &c.Timezone, &c.Phone, &c.AvatarKey, &c.CreatedAt, &c.UpdatedAt}
+}
+
+func (c cachedUser) MarshalProto() ([]byte, error) {
+	var b []byte
+	for i, p := range c.stringFields() {
+		b = appendBytesField(b, protowire.Number(i+1), []byte(*p))
+	}
+	b = appendBytesField(b, 14, c.Attributes)
+	return b, nil
+}
+
+func (c *cachedUser) UnmarshalProto(b []byte) error {
+	*c = cachedUser{}
+	strs := c.stringFields()
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch {
+		case typ == protowire.BytesType && int(num) >= 1 && int(num) <= len(strs):
+			v, n := protowire.ConsumeBytes(b)
+			*strs[num-1] = string(v)
+			return n
+		case typ == protowire.BytesType && num == 14:
+			v, n := protowire.ConsumeBytes(b)
+			c.Attributes = append(json.RawMessage(nil), v...)
+			return n
+		}
+		return -1
+	})
+}
+
+// cachedUserList: value cache List.
+//
+//	message CachedUserList { repeated CachedUser users = 1; }
+type cachedUserList []cachedUser
+
+func toCachedList(us []User) cachedUserList {
+	out := make(cachedUserList, len(us))
+	for i, u := range us {
+		out[i] = *toCached(u)
+	}
+	return out
+}
+
+func (l cachedUserList) users() []User {
+	out := make([]User, len(l))
+	for i := range l {
+		out[i] = l[i].user()
+	}
+	return out
+}
+
+func (l cachedUserList) MarshalProto() ([]byte, error) {
+	var b []byte
+	for _, u := range l {
+		m, _ := u.MarshalProto()
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, m)
+	}
+	return b, nil
+}
+
+func (l *cachedUserList) UnmarshalProto(b []byte) error {
+	*l = cachedUserList{}
+	var err error
+	perr := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		if num != 1 || typ != protowire.BytesType {
+			return -1
+		}
+		v, n := protowire.ConsumeBytes(b)
+		var u cachedUser
+		if e := u.UnmarshalProto(v); e != nil && err == nil {
+			err = e
+		}
+		*l = append(*l, u)
+		return n
+	})
+	if perr != nil {
+		return perr
+	}
+	return err
+}
+
+// Wire cacheEntry:
+//
+//	message CacheEntry {
+//	  CachedUser user = 1; bool not_found = 2;
+//	  int64 fresh_until = 3;  // unix ms
+//	  int64 delta_us = 4;
+//	}
+func (e cacheEntry) MarshalProto() ([]byte, error) {
+	var b []byte
+	if e.User != nil {
+		m, _ := e.User.MarshalProto()
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, m)
+	}
+	if e.NotFound {
+		b = appendVarintField(b, 2, 1)
+	}
+	b = appendVarintField(b, 3, e.FreshUntil)
+	b = appendVarintField(b, 4, e.Delta)
+	return b, nil
+}
+
+func (e *cacheEntry) UnmarshalProto(b []byte) error {
+	*e = cacheEntry{}
+	var err error
+	perr := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			e.User = &cachedUser{}
+			err = e.User.UnmarshalProto(v)
+			return n
+		case num >= 2 && num <= 4 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			switch num {
+			case 2:
+				e.NotFound = v != 0
+			case 3:
+				e.FreshUntil = int64(v)
+			case 4:
+				e.Delta = int64(v)
+			}
+			return n
+		}
+		return -1
+	})
+	if perr != nil {
+		return perr
+	}
+	return err
+}
+
+// nilai kosong tidak ditulis (semantik proto3)
+func appendBytesField(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendVarintField(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+// consumeFields memanggil fn untuk setiap field; fn mengembalikan panjang
+// value yang dibaca, atau -1 untuk field yang tidak dikenal (dilewati, agar
+// field baru dari versi lain tidak membuat decode gagal).
+func consumeFields(b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) int) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		m := fn(num, typ, b)
+		if m < 0 {
+			if m = protowire.ConsumeFieldValue(num, typ, b); m < 0 {
+				return protowire.ParseError(m)
+			}
+		}
+		b = b[m:]
+	}
+	return nil
+}
@@ -1,25 +1,63 @@
 package users
 
-import "strings"
+import (
+	"strings"
+	"time"
+)
 
 type CreateUserRequest struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
+	Profile
 }
 
+// UpdateUserRequest: PUT mengganti profil utuh; field yang tidak dikirim jadi kosong.
 type UpdateUserRequest struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
+	Profile
 }
 
 type UserResponse struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Profile
+	Avatars   map[string]string `json:"avatars,omitempty"` // sm/md/lg hasil upload
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// SearchHit: satu hasil GET /v1/users/search. Highlights berisi fragmen
+// yang cocok per field, bagian cocok dibungkus <mark> (sisanya HTML-escaped).
+type SearchHit struct {
+	UserResponse
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
 }
 
+type SearchResponse struct {
+	Items      []SearchHit `json:"items"`
+	Limit      int         `json:"limit"`
+	Offset     int         `json:"offset"`
+	NextOffset *int        `json:"next_offset,omitempty"` // nil = halaman terakhir
+}
+
+// EventPayload: payload user.created / user.updated. Semua jalur publish
+// (EventedStore, register di auth) memakai ini supaya subscriber webhook
+// selalu menerima bentuk yang sama.
+func EventPayload(u User) UserResponse { return toResponse(u) }
+
 func toResponse(u User) UserResponse {
-	return UserResponse{ID: u.ID, Name: u.Name, Email: u.Email}
+	p := u.Profile
+	if p.Attributes == nil {
+		p.Attributes = Attributes{}
+	}
+	return UserResponse{
+		ID: u.ID, TenantID: u.TenantID, Name: u.Name, Email: u.Email,
+		Profile: p, CreatedAt: u.CreatedAt, UpdatedAt: u.UpdatedAt,
+	}
 }
 
 func (r *CreateUserRequest) Normalize() {
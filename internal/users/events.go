@@ -0,0 +1,111 @@
+package users
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Quineeryn/go-backend-101/internal/cache"
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
+)
+
+const (
+	EventUserCreated = "user.created"
+	EventUserUpdated = "user.updated"
+	EventUserDeleted = "user.deleted"
+)
+
+// Events: daftar event user yang bisa di-subscribe (mis. oleh webhooks).
+var Events = []string{EventUserCreated, EventUserUpdated, EventUserDeleted}
+
+// Publisher menerima event domain. Implementasi WAJIB non-blocking
+// karena dipanggil langsung dari request path.
+type Publisher interface {
+	Publish(ctx context.Context, event string, data any)
+}
+
+// Publishers meneruskan event ke beberapa Publisher berurutan.
+type Publishers []Publisher
+
+func (ps Publishers) Publish(ctx context.Context, event string, data any) {
+	for _, p := range ps {
+		p.Publish(ctx, event, data)
+	}
+}
+
+// CacheEvictor: Publisher yang membuang response cache (cache.Middleware,
+// mis. /v1/users/me) milik user yang berubah/dihapus. Dengan cache.Tiered
+// invalidasi ikut sampai ke L1 replica lain.
+type CacheEvictor struct {
+	Store cache.Store
+}
+
+func (e CacheEvictor) Publish(_ context.Context, event string, data any) {
+	var tid, id string
+	switch v := data.(type) {
+	case UserResponse:
+		tid, id = v.TenantID, v.ID
+	case map[string]string:
+		tid, id = v["tenant_id"], v["id"]
+	}
+	if event == EventUserCreated || id == "" {
+		return
+	}
+	e.Store.DeleteByPrefix(cache.HTTPUserPrefix(tid, id))
+}
+
+// ListInvalidator: Publisher yang membatalkan cache List CachedStore untuk
+// write user yang tidak lewat CachedStore (mis. register di auth).
+type ListInvalidator struct {
+	Store *CachedStore
+}
+
+func (l ListInvalidator) Publish(ctx context.Context, event string, _ any) {
+	if slices.Contains(Events, event) {
+		l.Store.InvalidateLists(ctx)
+	}
+}
+
+// EventedStore membungkus Repo dan publish event setelah write sukses.
+type EventedStore struct {
+	Repo
+	pub Publisher
+}
+
+func NewEventedStore(inner Repo, pub Publisher) *EventedStore {
+	return &EventedStore{Repo: inner, pub: pub}
+}
+
+func (s *EventedStore) Create(ctx context.Context, u User) (User, error) {
+	created, err := s.Repo.Create(ctx, u)
+	if err != nil {
+		return created, err
+	}
+	s.pub.Publish(ctx, EventUserCreated, EventPayload(created))
+	return created, nil
+}
+
+func (s *EventedStore) Update(ctx context.Context, id string, data User) (User, error) {
+	updated, err := s.Repo.Update(ctx, id, data)
+	if err != nil {
+		return updated, err
+	}
+	s.pub.Publish(ctx, EventUserUpdated, EventPayload(updated))
+	return updated, nil
+}
+
+func (s *EventedStore) SetAvatar(ctx context.Context, id, key string) (User, string, error) {
+	updated, old, err := s.Repo.SetAvatar(ctx, id, key)
+	if err != nil {
+		return updated, old, err
+	}
+	s.pub.Publish(ctx, EventUserUpdated, EventPayload(updated))
+	return updated, old, nil
+}
+
+func (s *EventedStore) Delete(ctx context.Context, id string) error {
+	if err := s.Repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.pub.Publish(ctx, EventUserDeleted, map[string]string{"id": id, "tenant_id": tenant.ID(ctx)})
+	return nil
+}
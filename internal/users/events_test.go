@@ -0,0 +1,46 @@
+package users
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Quineeryn/go-backend-101/internal/cache"
+)
+
+type recordPub struct{ events []string }
+
+func (r *recordPub) Publish(_ context.Context, event string, _ any) {
+	r.events = append(r.events, event)
+}
+
+func TestCacheEvictor(t *testing.T) {
+	mem := cache.NewMemory(time.Minute)
+	defer mem.Close()
+	rec := &recordPub{}
+	pub := Publishers{rec, CacheEvictor{Store: mem}}
+
+	set := func() {
+		mem.Set(cache.HTTPUserPrefix("default", "u1")+"/v1/users/me:x", []byte("1"), time.Minute)
+		mem.Set(cache.HTTPUserPrefix("default", "u2")+"/v1/users/me:x", []byte("2"), time.Minute)
+		mem.Set(cache.HTTPUserPrefix("acme", "u1")+"/v1/users/me:x", []byte("3"), time.Minute)
+	}
+	has := func(tid, uid string) bool {
+		_, ok := mem.Get(cache.HTTPUserPrefix(tid, uid) + "/v1/users/me:x")
+		return ok
+	}
+
+	set()
+	pub.Publish(context.Background(), EventUserUpdated, UserResponse{ID: "u1", TenantID: "default"})
+	if has("default", "u1") || !has("default", "u2") || !has("acme", "u1") {
+		t.Fatal("update should evict only that user in that tenant")
+	}
+	set()
+	pub.Publish(context.Background(), EventUserDeleted, map[string]string{"id": "u2", "tenant_id": "default"})
+	if has("default", "u2") || !has("default", "u1") {
+		t.Fatal("delete should evict the user")
+	}
+	if len(rec.events) != 2 {
+		t.Fatalf("fan-out: %v", rec.events)
+	}
+}
@@ -2,31 +2,89 @@ package users
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
 	"github.com/Quineeryn/go-backend-101/internal/apperr"
 	httpx "github.com/Quineeryn/go-backend-101/internal/httpx"
+	"github.com/Quineeryn/go-backend-101/internal/storage"
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
 )
 
 // Repo diimplement oleh Store biasa dan CachedStore.
 type Repo interface {
 	Create(ctx context.Context, u User) (User, error)
-	List(ctx context.Context) ([]User, error)
+	List(ctx context.Context, f ListFilter) ([]User, error)
 	Get(ctx context.Context, id string) (User, error)
 	Update(ctx context.Context, id string, data User) (User, error)
 	Delete(ctx context.Context, id string) error
+	// SetAvatar: prefix avatar baru; mengembalikan user & prefix lama
+	SetAvatar(ctx context.Context, id, key string) (User, string, error)
+	Search(ctx context.Context, q SearchQuery) ([]SearchResult, error)
+}
+
+// AttributeValidator memeriksa attributes terhadap schema tenant di ctx
+// (lihat tenant.Schemas).
+type AttributeValidator interface {
+	Validate(ctx context.Context, attrs map[string]any) error
 }
 
 type Handler struct {
-	store Repo
+	store  Repo
+	attrs  AttributeValidator // opsional
+	blobs  storage.BlobStore  // opsional; nil = upload avatar mati
+	avatar AvatarOptions
 }
 
 func NewHandler(s Repo) *Handler { return &Handler{store: s} }
 
+// WithAttributeValidator memasang validasi attributes per tenant.
+func (h *Handler) WithAttributeValidator(v AttributeValidator) *Handler {
+	h.attrs = v
+	return h
+}
+
+// WithAvatars mengaktifkan upload avatar ke blobs.
+func (h *Handler) WithAvatars(blobs storage.BlobStore, opt AvatarOptions) *Handler {
+	h.blobs, h.avatar = blobs, opt
+	return h
+}
+
+func (h *Handler) response(u User) UserResponse {
+	r := toResponse(u)
+	r.Avatars = AvatarURLs(h.blobs, u.AvatarKey)
+	return r
+}
+
+// checkProfile: normalisasi + validasi profil; false = response error sudah ditulis.
+func (h *Handler) checkProfile(c *gin.Context, op string, p *Profile) bool {
+	if msg := p.normalize(); msg != "" {
+		httpx.AbortError(c, op, apperr.E(apperr.Validation, msg, nil))
+		return false
+	}
+	if h.attrs == nil {
+		return true
+	}
+	if err := h.attrs.Validate(c.Request.Context(), p.Attributes); err != nil {
+		if errors.Is(err, tenant.ErrInvalidAttributes) {
+			httpx.AbortError(c, op, apperr.E(apperr.Unprocessable, err.Error(), err))
+			return false
+		}
+		httpx.AbortError(c, op, apperr.E(apperr.Internal, "failed to validate attributes", err))
+		return false
+	}
+	return true
+}
+
 // (Opsional) Masih dipertahankan kalau suatu saat mau dipakai untuk non-AppError path.
 // Tapi pada versi ini kita tidak memanggilnya lagi.
 type errorResponse struct {
@@ -75,11 +133,15 @@ func (h *Handler) Create(c *gin.Context) {
 		httpx.AbortError(c, "users.create", apperr.E(apperr.Validation, "name and email are required", nil))
 		return
 	}
+	if !h.checkProfile(c, "users.create", &req.Profile) {
+		return
+	}
 
 	u := User{
-		ID:    uuid.NewString(),
-		Name:  req.Name,
-		Email: req.Email,
+		ID:      uuid.NewString(),
+		Name:    req.Name,
+		Email:   req.Email,
+		Profile: req.Profile,
 	}
 	created, err := h.store.Create(c.Request.Context(), u)
 	if err != nil {
@@ -94,23 +156,79 @@ func (h *Handler) Create(c *gin.Context) {
 	success = true
 	msg = "ok"
 	resource = created.ID
-	c.JSON(http.StatusCreated, toResponse(created))
+	c.JSON(http.StatusCreated, h.response(created))
 }
 
-// GET /v1/users
+// GET /v1/users?attr.<key>=<value>
 func (h *Handler) List(c *gin.Context) {
-	usersList, err := h.store.List(c.Request.Context())
+	attrs, err := ParseAttrQuery(c.Request.URL.Query())
+	if err != nil {
+		httpx.AbortError(c, "users.list", apperr.E(apperr.Validation, err.Error(), err))
+		return
+	}
+	usersList, err := h.store.List(c.Request.Context(), ListFilter{Attributes: attrs})
 	if err != nil {
 		httpx.AbortError(c, "users.list", apperr.E(apperr.Internal, "failed to list users", err))
 		return
 	}
 	out := make([]UserResponse, 0, len(usersList))
 	for _, u := range usersList {
-		out = append(out, toResponse(u))
+		out = append(out, h.response(u))
 	}
 	c.JSON(http.StatusOK, out)
 }
 
+// searchRoles: pencarian (enumerasi nama/email) hanya untuk admin & support.
+var searchRoles = []string{"admin", "support"}
+
+// GET /v1/users/search?q=<teks>&limit=20&offset=0
+func (h *Handler) Search(c *gin.Context) {
+	if !requireRole(c, "users.search", searchRoles...) {
+		return
+	}
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" || utf8.RuneCountInString(q) > searchMaxQuery {
+		httpx.AbortError(c, "users.search", apperr.E(apperr.Validation, fmt.Sprintf("q is required (max %d characters)", searchMaxQuery), nil))
+		return
+	}
+	terms := searchTerms(q)
+	if len(terms) == 0 {
+		httpx.AbortError(c, "users.search", apperr.E(apperr.Validation, "q must contain letters or digits", nil))
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		httpx.AbortError(c, "users.search", apperr.E(apperr.Validation, "limit must be between 1 and 100", err))
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 || offset > 10000 {
+		httpx.AbortError(c, "users.search", apperr.E(apperr.Validation, "offset must be between 0 and 10000", err))
+		return
+	}
+
+	// ambil satu lebih untuk tahu ada halaman berikutnya
+	results, err := h.store.Search(c.Request.Context(), SearchQuery{Q: q, Limit: limit + 1, Offset: offset})
+	if err != nil {
+		httpx.AbortError(c, "users.search", apperr.E(apperr.Internal, "failed to search users", err))
+		return
+	}
+	resp := SearchResponse{Items: make([]SearchHit, 0, min(len(results), limit)), Limit: limit, Offset: offset}
+	if len(results) > limit {
+		results = results[:limit]
+		next := offset + limit
+		resp.NextOffset = &next
+	}
+	for _, r := range results {
+		resp.Items = append(resp.Items, SearchHit{
+			UserResponse: h.response(r.User),
+			Score:        r.Score,
+			Highlights:   searchHighlights(r.User, terms),
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
 // GET /v1/users/:id
 func (h *Handler) Get(c *gin.Context) {
 	uid := httpx.CurrentUserID(c)
@@ -142,7 +260,7 @@ func (h *Handler) Get(c *gin.Context) {
 
 	success = true
 	msg = "ok"
-	c.JSON(http.StatusOK, toResponse(u))
+	c.JSON(http.StatusOK, h.response(u))
 }
 
 // PUT /v1/users/:id
@@ -174,8 +292,11 @@ func (h *Handler) Update(c *gin.Context) {
 		httpx.AbortError(c, "users.update", apperr.E(apperr.Validation, "name and email are required", nil))
 		return
 	}
+	if !h.checkProfile(c, "users.update", &req.Profile) {
+		return
+	}
 
-	data := User{Name: req.Name, Email: req.Email}
+	data := User{Name: req.Name, Email: req.Email, Profile: req.Profile}
 	updated, err := h.store.Update(c.Request.Context(), id, data)
 	if err != nil {
 		switch err {
@@ -193,7 +314,7 @@ func (h *Handler) Update(c *gin.Context) {
 
 	success = true
 	msg = "ok"
-	c.JSON(http.StatusOK, toResponse(updated))
+	c.JSON(http.StatusOK, h.response(updated))
 }
 
 // DELETE /v1/users/:id
@@ -215,7 +336,14 @@ func (h *Handler) Delete(c *gin.Context) {
 		})
 	}()
 
-	if err := h.store.Delete(c.Request.Context(), id); err != nil {
+	ctx := c.Request.Context()
+	var avatar string
+	if h.blobs != nil {
+		if u, err := h.store.Get(ctx, id); err == nil {
+			avatar = u.AvatarKey
+		}
+	}
+	if err := h.store.Delete(ctx, id); err != nil {
 		if err == ErrNotFound {
 			httpx.AbortError(c, "users.delete", apperr.E(apperr.NotFound, "user not found", err))
 			return
@@ -223,8 +351,165 @@ func (h *Handler) Delete(c *gin.Context) {
 		httpx.AbortError(c, "users.delete", apperr.E(apperr.Internal, "failed to delete user", err))
 		return
 	}
+	deleteAvatar(ctx, h.blobs, avatar)
 
 	success = true
 	msg = "ok"
 	c.Status(http.StatusNoContent)
 }
+
+// PUT /v1/users/:id/avatar
+// Body: multipart/form-data (part "file"/"avatar") atau gambar mentah.
+func (h *Handler) PutAvatar(c *gin.Context) {
+	uid := httpx.CurrentUserID(c)
+	success := false
+	msg := ""
+	id := c.Param("id")
+
+	defer func() {
+		httpx.Audit(c, httpx.AuditEvent{
+			UserID:   uid,
+			Action:   httpx.ActionUserUpdate,
+			Resource: id,
+			Success:  success,
+			Message:  msg,
+		})
+	}()
+
+	if !h.avatarAccess(c, id) {
+		msg = "forbidden"
+		return
+	}
+	if h.blobs == nil {
+		httpx.AbortError(c, "users.avatar", apperr.E(apperr.Unavailable, "avatar storage is not configured", nil))
+		return
+	}
+	if !isMultipartOrImage(c.GetHeader("Content-Type")) {
+		httpx.AbortError(c, "users.avatar", apperr.E(apperr.Unsupported, "send multipart/form-data or an image/* body", nil))
+		return
+	}
+	data, err := readAvatar(c.Request, h.avatar.MaxBytes)
+	if err != nil {
+		if errors.Is(err, errAvatarTooLarge) {
+			httpx.AbortError(c, "users.avatar", apperr.E(apperr.TooLarge, fmt.Sprintf("avatar must be at most %d bytes", h.avatar.MaxBytes), err))
+			return
+		}
+		httpx.AbortError(c, "users.avatar", apperr.E(apperr.Validation, "invalid upload body", err))
+		return
+	}
+	images, err := processAvatar(data, h.avatar.MaxPixels)
+	if err != nil {
+		switch {
+		case errors.Is(err, errAvatarUnsupported):
+			httpx.AbortError(c, "users.avatar", apperr.E(apperr.Unsupported, err.Error(), err))
+		case errors.Is(err, errAvatarDimensions):
+			httpx.AbortError(c, "users.avatar", apperr.E(apperr.Validation, fmt.Sprintf("avatar must be at most %d pixels", h.avatar.MaxPixels), err))
+		default:
+			httpx.AbortError(c, "users.avatar", apperr.E(apperr.Internal, "failed to process avatar", err))
+		}
+		return
+	}
+
+	ctx := c.Request.Context()
+	// pastikan user ada (di tenant ini) sebelum menulis blob
+	if _, err := h.store.Get(ctx, id); err != nil {
+		if err == ErrNotFound {
+			httpx.AbortError(c, "users.avatar", apperr.E(apperr.NotFound, "user not found", err))
+			return
+		}
+		httpx.AbortError(c, "users.avatar", apperr.E(apperr.Internal, "failed to get user", err))
+		return
+	}
+	prefix := avatarBlobKey(ctx, id)
+	if err := putAvatar(ctx, h.blobs, prefix, images); err != nil {
+		deleteAvatar(ctx, h.blobs, prefix)
+		httpx.AbortError(c, "users.avatar", apperr.E(apperr.Internal, "failed to store avatar", err))
+		return
+	}
+	updated, old, err := h.store.SetAvatar(ctx, id, prefix)
+	if err != nil {
+		deleteAvatar(ctx, h.blobs, prefix)
+		if err == ErrNotFound {
+			httpx.AbortError(c, "users.avatar", apperr.E(apperr.NotFound, "user not found", err))
+			return
+		}
+		httpx.AbortError(c, "users.avatar", apperr.E(apperr.Internal, "failed to save avatar", err))
+		return
+	}
+	deleteAvatar(ctx, h.blobs, old)
+
+	success = true
+	msg = "ok"
+	c.JSON(http.StatusOK, h.response(updated))
+}
+
+// DELETE /v1/users/:id/avatar
+func (h *Handler) DeleteAvatar(c *gin.Context) {
+	uid := httpx.CurrentUserID(c)
+	success := false
+	msg := ""
+	id := c.Param("id")
+
+	defer func() {
+		httpx.Audit(c, httpx.AuditEvent{
+			UserID:   uid,
+			Action:   httpx.ActionUserAvatarDelete,
+			Resource: id,
+			Success:  success,
+			Message:  msg,
+		})
+	}()
+
+	if !h.avatarAccess(c, id) {
+		msg = "forbidden"
+		return
+	}
+	if h.blobs == nil {
+		httpx.AbortError(c, "users.avatar", apperr.E(apperr.Unavailable, "avatar storage is not configured", nil))
+		return
+	}
+	ctx := c.Request.Context()
+	_, old, err := h.store.SetAvatar(ctx, id, "")
+	if err != nil {
+		if err == ErrNotFound {
+			httpx.AbortError(c, "users.avatar", apperr.E(apperr.NotFound, "user not found", err))
+			return
+		}
+		httpx.AbortError(c, "users.avatar", apperr.E(apperr.Internal, "failed to remove avatar", err))
+		return
+	}
+	deleteAvatar(ctx, h.blobs, old)
+
+	success = true
+	msg = "ok"
+	c.Status(http.StatusNoContent)
+}
+
+// avatarAccess: avatar hanya boleh diubah user itu sendiri atau admin
+// (identitas dari RequireAuth). Ditolak → error sudah ditulis.
+func (h *Handler) avatarAccess(c *gin.Context, id string) bool {
+	uid := c.GetString(httpx.CtxKeyUserID)
+	switch {
+	case uid == "":
+		httpx.AbortError(c, "users.avatar", apperr.E(apperr.Unauthorized, "authentication required", nil))
+		return false
+	case uid != id && c.GetString("role") != "admin":
+		httpx.AbortError(c, "users.avatar", apperr.E(apperr.Forbidden, "only the user or an admin can change this avatar", nil))
+		return false
+	}
+	return true
+}
+
+// requireRole: padanan auth.RequireRole di dalam handler (package ini tidak
+// bisa import auth). Ditolak → error sudah ditulis.
+func requireRole(c *gin.Context, op string, allowed ...string) bool {
+	if c.GetString(httpx.CtxKeyUserID) == "" {
+		httpx.AbortError(c, op, apperr.E(apperr.Unauthorized, "authentication required", nil))
+		return false
+	}
+	if !slices.Contains(allowed, c.GetString("role")) {
+		httpx.AbortError(c, op, apperr.E(apperr.Forbidden, "forbidden for role: "+c.GetString("role"), nil))
+		return false
+	}
+	return true
+}
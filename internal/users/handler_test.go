@@ -46,6 +46,16 @@ func testErrorMiddleware() gin.HandlerFunc {
 					status = http.StatusConflict
 				case apperr.NotFound:
 					status = http.StatusNotFound
+				case apperr.Unprocessable:
+					status = http.StatusUnprocessableEntity
+				case apperr.TooLarge:
+					status = http.StatusRequestEntityTooLarge
+				case apperr.Unsupported:
+					status = http.StatusUnsupportedMediaType
+				case apperr.Unauthorized:
+					status = http.StatusUnauthorized
+				case apperr.Forbidden:
+					status = http.StatusForbidden
 				case apperr.Internal:
 					status = http.StatusInternalServerError
 				}
@@ -57,6 +67,15 @@ func testErrorMiddleware() gin.HandlerFunc {
 	}
 }
 
+// testIdentity: pengganti RequireAuth; identitas dari header X-Test-User /
+// X-Test-Role (tanpa header = anonim).
+func testIdentity(c *gin.Context) {
+	if v := c.GetHeader("X-Test-User"); v != "" {
+		c.Set("user_id", v)
+		c.Set("role", c.GetHeader("X-Test-Role"))
+	}
+}
+
 // DB helper tanpa CGO, 1 koneksi, dan unique index email
 func newHTTPTestDB(t *testing.T) *gorm.DB {
 	t.Helper()
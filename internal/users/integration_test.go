@@ -98,3 +98,40 @@ func TestIntegration_Get_Update_Delete(t *testing.T) {
 		t.Fatalf("get after delete: want 404, got %d (%s)", recG2.Code, recG2.Body.String())
 	}
 }
+
+func TestIntegration_Search(t *testing.T) {
+	r := newRouterIT(t)
+
+	for _, body := range []string{
+		`{"name":"Alea Kusuma","email":"alea@example.com"}`,
+		`{"name":"Budi","email":"budi@corp.io","display_name":"Pak Budi"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/users", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create: want 201, got %d (%s)", rec.Code, rec.Body.String())
+		}
+	}
+
+	// prefix (tsvector), email terpecah, typo (pg_trgm)
+	for q, want := range map[string]string{"kusu": "Alea Kusuma", "corp": "Budi", "kusumma": "Alea Kusuma"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/users/search?q="+q, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("search %s: want 200, got %d (%s)", q, rec.Code, rec.Body.String())
+		}
+		var got struct {
+			Items []struct {
+				Name string `json:"name"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("search %s: invalid json: %v", q, err)
+		}
+		if len(got.Items) == 0 || got.Items[0].Name != want {
+			t.Fatalf("search %s: want %s first, got %+v", q, want, got.Items)
+		}
+	}
+}
@@ -49,6 +49,10 @@ func newRouterIT(t *testing.T) *gin.Engine {
 
 	r := gin.New()
 	r.Use(gin.Recovery())
-	users.RegisterRoutes(r, h)
+	// auth tidak diuji di sini (lihat internal/auth); cukup identitas admin
+	users.RegisterRoutes(r, h, func(c *gin.Context) {
+		c.Set("user_id", "itest")
+		c.Set("role", "admin")
+	})
 	return r
 }
@@ -3,11 +3,15 @@ package users
 import "time"
 
 type User struct {
-	ID           string    `json:"id" gorm:"primaryKey"`
-	Name         string    `json:"name"`
-	Email        string    `json:"email" gorm:"column:email"`
-	Role         string    `json:"role" gorm:"default:user"`
-	PasswordHash *string   `json:"-" gorm:"column:password_hash"`
-	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID           string  `json:"id" gorm:"primaryKey"`
+	TenantID     string  `json:"tenant_id" gorm:"column:tenant_id;not null;default:default;index"`
+	Name         string  `json:"name"`
+	Email        string  `json:"email" gorm:"column:email"`
+	Role         string  `json:"role" gorm:"default:user"`
+	PasswordHash *string `json:"-" gorm:"column:password_hash"`
+	Profile
+	// prefix blob avatar hasil upload (lihat avatar.go); URL dihitung saat response
+	AvatarKey string    `json:"avatar_key,omitempty" gorm:"column:avatar_key;not null;default:''"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 }
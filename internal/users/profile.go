@@ -0,0 +1,122 @@
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	_ "time/tzdata" // validasi timezone tidak bergantung zoneinfo di image
+
+	"golang.org/x/text/language"
+)
+
+// Attributes: data bebas per user (JSON object). Bentuknya bisa dibatasi
+// per tenant lewat JSON Schema (tenant.Schemas).
+type Attributes map[string]any
+
+// Profile: field profil user, di-embed di User, request & response.
+// Kolom lihat migrasi 000008_user_profile.
+type Profile struct {
+	DisplayName string     `json:"display_name" gorm:"column:display_name;not null;default:''"`
+	AvatarURL   string     `json:"avatar_url" gorm:"column:avatar_url;not null;default:''"`
+	Locale      string     `json:"locale" gorm:"column:locale;not null;default:''"`     // BCP 47, mis. "id-ID"
+	Timezone    string     `json:"timezone" gorm:"column:timezone;not null;default:''"` // IANA, mis. "Asia/Jakarta"
+	Phone       string     `json:"phone" gorm:"column:phone;not null;default:''"`       // E.164, mis. "+6281234567890"
+	Attributes  Attributes `json:"attributes" gorm:"column:attributes;serializer:json"`
+}
+
+const (
+	maxDisplayName    = 100
+	maxAvatarURL      = 2048
+	maxAttributesSize = 16 << 10 // JSON ter-encode
+)
+
+var (
+	phoneRe   = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+	attrKeyRe = regexp.MustCompile(`^[A-Za-z0-9_]{1,64}$`)
+)
+
+// normalize merapikan input dan mengembalikan pesan validasi pertama
+// ("" = valid). Locale dikanonikkan (en_us → en-US).
+func (p *Profile) normalize() string {
+	p.DisplayName = strings.TrimSpace(p.DisplayName)
+	p.AvatarURL = strings.TrimSpace(p.AvatarURL)
+	p.Locale = strings.TrimSpace(p.Locale)
+	p.Timezone = strings.TrimSpace(p.Timezone)
+	p.Phone = strings.ReplaceAll(strings.TrimSpace(p.Phone), " ", "")
+
+	if len([]rune(p.DisplayName)) > maxDisplayName {
+		return fmt.Sprintf("display_name must be at most %d characters", maxDisplayName)
+	}
+	if p.AvatarURL != "" {
+		u, err := url.Parse(p.AvatarURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(p.AvatarURL) > maxAvatarURL {
+			return "avatar_url must be an absolute http(s) URL"
+		}
+	}
+	if p.Locale != "" {
+		tag, err := language.Parse(strings.ReplaceAll(p.Locale, "_", "-"))
+		if err != nil {
+			return "locale must be a BCP 47 language tag (e.g. en-US)"
+		}
+		p.Locale = tag.String()
+	}
+	if p.Timezone != "" {
+		if p.Timezone == "Local" {
+			return "timezone must be an IANA time zone (e.g. Asia/Jakarta)"
+		}
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			return "timezone must be an IANA time zone (e.g. Asia/Jakarta)"
+		}
+	}
+	if p.Phone != "" && !phoneRe.MatchString(p.Phone) {
+		return "phone must be in E.164 format (e.g. +6281234567890)"
+	}
+	if p.Attributes != nil {
+		b, err := json.Marshal(p.Attributes)
+		if err != nil {
+			return "attributes must be a JSON object"
+		}
+		if len(b) > maxAttributesSize {
+			return fmt.Sprintf("attributes must be at most %d bytes", maxAttributesSize)
+		}
+	}
+	return ""
+}
+
+// ListFilter untuk Store.List. Attributes: kecocokan persis per key
+// top-level (AND).
+type ListFilter struct {
+	Attributes map[string]any
+}
+
+// ParseAttrQuery membaca ?attr.<key>=<value>. Value yang valid sebagai
+// JSON skalar (30, true, null, "30") dipakai apa adanya, selain itu string.
+func ParseAttrQuery(q url.Values) (map[string]any, error) {
+	var out map[string]any
+	for k, vs := range q {
+		key, ok := strings.CutPrefix(k, "attr.")
+		if !ok {
+			continue
+		}
+		if !attrKeyRe.MatchString(key) {
+			return nil, fmt.Errorf("attribute key %q must match [A-Za-z0-9_]{1,64}", key)
+		}
+		raw := vs[len(vs)-1]
+		var v any
+		if err := json.Unmarshal([]byte(raw), &v); err != nil {
+			v = raw
+		}
+		switch v.(type) {
+		case map[string]any, []any:
+			return nil, fmt.Errorf("attribute %q: only scalar values can be searched", key)
+		}
+		if out == nil {
+			out = map[string]any{}
+		}
+		out[key] = v
+	}
+	return out, nil
+}
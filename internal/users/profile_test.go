@@ -0,0 +1,135 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
+)
+
+func TestProfile_Normalize(t *testing.T) {
+	ok := []Profile{
+		{},
+		{DisplayName: " Ana ", AvatarURL: "https://cdn.example.com/a.png", Locale: "en_us", Timezone: "Asia/Jakarta", Phone: "+62 812 3456 7890"},
+		{Attributes: Attributes{"plan": "pro", "seats": 5}},
+	}
+	for i := range ok {
+		if msg := ok[i].normalize(); msg != "" {
+			t.Fatalf("case %d: unexpected error %q", i, msg)
+		}
+	}
+	p := ok[1]
+	if p.DisplayName != "Ana" || p.Locale != "en-US" || p.Phone != "+6281234567890" {
+		t.Fatalf("not normalized: %+v", p)
+	}
+
+	bad := []Profile{
+		{AvatarURL: "javascript:alert(1)"},
+		{AvatarURL: "/relative.png"},
+		{Locale: "not a locale"},
+		{Timezone: "Mars/Olympus"},
+		{Timezone: "Local"},
+		{Phone: "0812345"},
+	}
+	for i, p := range bad {
+		if msg := p.normalize(); msg == "" {
+			t.Fatalf("case %d (%+v): want validation error", i, p)
+		}
+	}
+}
+
+type schemaFunc func(ctx context.Context, attrs map[string]any) error
+
+func (f schemaFunc) Validate(ctx context.Context, attrs map[string]any) error { return f(ctx, attrs) }
+
+func TestHandler_ProfileAndAttributeSearch(t *testing.T) {
+	r, _ := newHTTP(t)
+
+	w := doJSON(r, http.MethodPost, "/v1/users", map[string]any{
+		"name": "Ana", "email": "ana@example.com",
+		"display_name": "Ana K", "locale": "id-id", "timezone": "Asia/Jakarta", "phone": "+6281234567890",
+		"attributes": map[string]any{"plan": "pro", "seats": 5, "vip": true, "code": "30"},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	var got UserResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &got)
+	if got.DisplayName != "Ana K" || got.Locale != "id-ID" || got.Attributes["plan"] != "pro" || got.CreatedAt.IsZero() {
+		t.Fatalf("profile not returned: %+v", got)
+	}
+	doJSON(r, http.MethodPost, "/v1/users", map[string]any{
+		"name": "Budi", "email": "budi@example.com",
+		"attributes": map[string]any{"plan": "free", "seats": 30, "vip": 1, "code": 30},
+	})
+	doJSON(r, http.MethodPost, "/v1/users", map[string]any{"name": "Cici", "email": "cici@example.com"})
+
+	cases := map[string][]string{
+		"/v1/users":                            {"Ana", "Budi", "Cici"},
+		"/v1/users?attr.plan=pro":              {"Ana"},
+		"/v1/users?attr.seats=30":              {"Budi"},
+		"/v1/users?attr.vip=true":              {"Ana"}, // 1 bukan true
+		"/v1/users?attr.code=%2230%22":         {"Ana"}, // "30" string
+		"/v1/users?attr.code=30":               {"Budi"},
+		"/v1/users?attr.plan=pro&attr.seats=5": {"Ana"},
+		"/v1/users?attr.plan=enterprise":       {},
+	}
+	for path, want := range cases {
+		w := doJSON(r, http.MethodGet, path, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: %d %s", path, w.Code, w.Body.String())
+		}
+		var list []UserResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &list)
+		if len(list) != len(want) {
+			t.Fatalf("%s: want %v, got %d users", path, want, len(list))
+		}
+		for i := range want {
+			if list[i].Name != want[i] {
+				t.Fatalf("%s: want %v, got %+v", path, want, list)
+			}
+		}
+	}
+	if w := doJSON(r, http.MethodGet, "/v1/users?attr.bad-key=1", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid attr key: want 400, got %d", w.Code)
+	}
+
+	// PUT mengganti profil utuh
+	w = doJSON(r, http.MethodPut, "/v1/users/"+got.ID, map[string]any{
+		"name": "Ana", "email": "ana@example.com", "timezone": "Europe/Berlin",
+	})
+	var upd UserResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &upd)
+	if w.Code != http.StatusOK || upd.Timezone != "Europe/Berlin" || upd.DisplayName != "" || len(upd.Attributes) != 0 {
+		t.Fatalf("update: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := doJSON(r, http.MethodPost, "/v1/users", map[string]any{
+		"name": "X", "email": "x@example.com", "timezone": "Nowhere/City",
+	}); w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid timezone: want 400, got %d", w.Code)
+	}
+}
+
+func TestHandler_AttributeSchema(t *testing.T) {
+	r, store := newHTTP(t)
+	h := NewHandler(store).WithAttributeValidator(schemaFunc(func(_ context.Context, attrs map[string]any) error {
+		if _, ok := attrs["plan"].(string); !ok {
+			return errors.Join(tenant.ErrInvalidAttributes, errors.New("plan is required"))
+		}
+		return nil
+	}))
+	r.POST("/v2/users", h.Create)
+
+	if w := doJSON(r, http.MethodPost, "/v2/users", map[string]any{"name": "A", "email": "a@example.com"}); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("want 422, got %d %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(r, http.MethodPost, "/v2/users", map[string]any{
+		"name": "A", "email": "a@example.com", "attributes": map[string]any{"plan": "pro"},
+	}); w.Code != http.StatusCreated {
+		t.Fatalf("want 201, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 
 	"gorm.io/gorm"
+
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
 )
 
 type Repository interface {
@@ -21,6 +23,7 @@ func NewRepository(db *gorm.DB) Repository {
 }
 
 func (r *repository) Create(ctx context.Context, u *User) error {
+	u.TenantID = tenant.ID(ctx)
 	if err := r.db.WithContext(ctx).Create(u).Error; err != nil {
 		// GORM v1.25+ akan set gorm.ErrDuplicatedKey untuk unique violation pada driver Postgres/SQLite dkk
 		if errors.Is(err, gorm.ErrDuplicatedKey) {
@@ -33,7 +36,7 @@ func (r *repository) Create(ctx context.Context, u *User) error {
 
 func (r *repository) GetByEmail(ctx context.Context, email string) (*User, error) {
 	var u User
-	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&u).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("tenant_id = ? AND email = ?", tenant.ID(ctx), email).First(&u).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrNotFound
 		}
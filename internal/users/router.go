@@ -2,14 +2,22 @@ package users
 
 import "github.com/gin-gonic/gin"
 
-// Pendaftaran routes
-func RegisterRoutes(r *gin.Engine, h *Handler) {
-	g := r.Group("/v1/users")
+// Pendaftaran routes. authn wajib (auth.RequireAuth): tenant request diikat
+// ke claim tid token, jadi header X-Tenant-ID tenant lain ditolak 403.
+// Dipasang dari main karena auth meng-import users.
+func RegisterRoutes(r *gin.Engine, h *Handler, authn gin.HandlerFunc) {
+	if authn == nil {
+		panic("users.RegisterRoutes: authn middleware is required")
+	}
+	g := r.Group("/v1/users", authn)
 	{
 		g.POST("", h.Create)
 		g.GET("", h.List)
+		g.GET("/search", h.Search)
 		g.GET("/:id", h.Get)
 		g.PUT("/:id", h.Update)
 		g.DELETE("/:id", h.Delete)
+		g.PUT("/:id/avatar", h.PutAvatar)
+		g.DELETE("/:id/avatar", h.DeleteAvatar)
 	}
 }
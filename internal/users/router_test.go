@@ -47,8 +47,20 @@ func TestRegisterRoutes_PostUser(t *testing.T) {
 	store := NewStore(db)
 	h := NewHandler(store)
 
-	// panggil RegisterRoutes yang lagi kita cover
-	RegisterRoutes(r, h) // pastikan signature cocok: func RegisterRoutes(r *gin.Engine, h *Handler)
+	// panggil RegisterRoutes yang lagi kita cover; authn stub menggantikan
+	// auth.RequireAuth (package auth meng-import users)
+	RegisterRoutes(r, h, func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Set("user_id", "u-admin")
+		c.Set("role", "admin")
+	})
+
+	if w := doJSON(r, http.MethodGet, "/v1/users", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("without token: want 401, got %d", w.Code)
+	}
 
 	// hit POST /v1/users
 	var buf bytes.Buffer
@@ -59,6 +71,7 @@ func TestRegisterRoutes_PostUser(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "/v1/users", &buf)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer test")
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 
@@ -0,0 +1,160 @@
+package users
+
+import (
+	"context"
+	"html"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// SearchQuery: parameter GET /v1/users/search.
+type SearchQuery struct {
+	Q      string
+	Limit  int
+	Offset int
+}
+
+// SearchResult: user + skor relevansi (makin besar makin relevan; skala
+// beda antar dialect, hanya untuk urutan).
+type SearchResult struct {
+	User
+	Score float64 `gorm:"column:score"`
+}
+
+const (
+	searchMaxQuery = 100 // karakter
+	searchMaxTerms = 8
+)
+
+// searchTerms memecah q jadi kata huruf/angka (lowercase, unik). Email
+// ikut terpecah: "ana@example.com" → ana, example, com.
+func searchTerms(q string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, f := range strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !seen[f] && len(out) < searchMaxTerms {
+			seen[f] = true
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+var searchColumns = []string{"users.id", "users.tenant_id", "users.name", "users.email",
+	"users.created_at", "users.updated_at", "users.display_name", "users.avatar_url",
+	"users.locale", "users.timezone", "users.phone", "users.attributes", "users.avatar_key"}
+
+// Search mencari user tenant ini berdasarkan nama, display_name dan email.
+//   - Postgres: kolom generated tsvector (prefix per kata, ts_rank) ditambah
+//     pg_trgm word_similarity untuk typo; keduanya ber-index GIN.
+//   - SQLite: FTS5 tokenizer trigram (substring, min. 3 huruf; kata lebih
+//     pendek pakai LIKE), urut bm25. Tanpa toleransi typo: cukup untuk dev.
+func (s *Store) Search(ctx context.Context, q SearchQuery) ([]SearchResult, error) {
+	terms := searchTerms(q.Q)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	db := s.read(ctx).Table("users")
+	if db.Dialector.Name() == "postgres" {
+		db = searchPostgres(db, terms)
+	} else {
+		db = searchSQLite(db, terms)
+	}
+	var out []SearchResult
+	err := db.Order("score DESC").Order("users.created_at ASC").Order("users.id").
+		Limit(q.Limit).Offset(q.Offset).
+		Find(&out).Error
+	return out, err
+}
+
+func searchPostgres(db *gorm.DB, terms []string) *gorm.DB {
+	// term hanya huruf/angka → aman dirangkai jadi tsquery
+	prefix := make([]string, len(terms))
+	for i, t := range terms {
+		prefix[i] = t + ":*"
+	}
+	tsq := strings.Join(prefix, " & ")
+	text := strings.Join(terms, " ")
+	return db.
+		Select(strings.Join(searchColumns, ", ")+
+			", ts_rank(users.search, to_tsquery('simple', ?)) + word_similarity(?, users.search_text) AS score", tsq, text).
+		Where("(users.search @@ to_tsquery('simple', ?) OR ? <% users.search_text)", tsq, text)
+}
+
+func searchSQLite(db *gorm.DB, terms []string) *gorm.DB {
+	var match []string
+	for _, t := range terms {
+		if len([]rune(t)) >= 3 {
+			match = append(match, `"`+t+`"`) // phrase: trigram substring
+		} else {
+			like := "%" + t + "%"
+			db = db.Where("(lower(users.name) LIKE ? OR lower(users.display_name) LIKE ? OR lower(users.email) LIKE ?)", like, like, like)
+		}
+	}
+	if len(match) == 0 {
+		return db.Select(strings.Join(searchColumns, ", ") + ", 0.0 AS score")
+	}
+	// bm25 negatif (makin kecil makin relevan); bobot name, display_name, email
+	return db.
+		Select(strings.Join(searchColumns, ", ")+", -bm25(users_fts, 0.0, 2.0, 2.0, 1.0) AS score").
+		Joins("JOIN users_fts ON users_fts.id = users.id").
+		Where("users_fts MATCH ?", strings.Join(match, " AND "))
+}
+
+// highlight membungkus bagian text yang mengandung salah satu term dengan
+// <mark>…</mark>; sisanya di-escape HTML. "" bila tidak ada yang cocok.
+func highlight(text string, terms []string) string {
+	rs := []rune(text)
+	lower := make([]rune, len(rs))
+	for i, r := range rs {
+		lower[i] = unicode.ToLower(r)
+	}
+	marked := make([]bool, len(rs))
+	found := false
+	for _, t := range terms {
+		tr := []rune(t)
+		for i := 0; i+len(tr) <= len(lower); i++ {
+			if string(lower[i:i+len(tr)]) == t {
+				for j := i; j < i+len(tr); j++ {
+					marked[j] = true
+				}
+				found = true
+			}
+		}
+	}
+	if !found {
+		return ""
+	}
+	var b strings.Builder
+	for i := 0; i < len(rs); {
+		j := i
+		for j < len(rs) && marked[j] == marked[i] {
+			j++
+		}
+		seg := html.EscapeString(string(rs[i:j]))
+		if marked[i] {
+			seg = "<mark>" + seg + "</mark>"
+		}
+		b.WriteString(seg)
+		i = j
+	}
+	return b.String()
+}
+
+// searchHighlights: fragmen yang cocok per field (hanya field yang cocok).
+func searchHighlights(u User, terms []string) map[string]string {
+	out := map[string]string{}
+	for field, v := range map[string]string{"name": u.Name, "display_name": u.DisplayName, "email": u.Email} {
+		if h := highlight(v, terms); h != "" {
+			out[field] = h
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,167 @@
+package users
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Quineeryn/go-backend-101/db/migrations"
+)
+
+// newSearchHTTP: router users + index FTS5 dari migrasi SQLite yang sama
+// dengan produksi-dev (trigger ikut teruji lewat Create/Update/Delete).
+func newSearchHTTP(t *testing.T) (*gin.Engine, *Store) {
+	t.Helper()
+	r, store := newHTTP(t)
+	ddl, err := migrations.FS.ReadFile("sqlite/000010_user_search.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.dbs.Writer(t.Context()).Exec(string(ddl)).Error; err != nil {
+		t.Fatalf("search migration: %v", err)
+	}
+	h := NewHandler(store)
+	r.GET("/v1/users/search", testIdentity, h.Search)
+	return r, store
+}
+
+func searchNames(t *testing.T, r *gin.Engine, query string) SearchResponse {
+	t.Helper()
+	w := searchAs(r, query, "agent", "support")
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s: %d %s", query, w.Code, w.Body.String())
+	}
+	var resp SearchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func searchAs(r *gin.Engine, query, user, role string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/v1/users/search?"+query, nil)
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+		req.Header.Set("X-Test-Role", role)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func names(resp SearchResponse) []string {
+	out := []string{}
+	for _, it := range resp.Items {
+		out = append(out, it.Name)
+	}
+	return out
+}
+
+func TestSearch_FTS(t *testing.T) {
+	r, _ := newSearchHTTP(t)
+	for _, u := range []map[string]any{
+		{"name": "Alea Kusuma", "email": "alea@example.com"},
+		{"name": "Budi", "email": "budi.kusumawardani@corp.io", "display_name": "Pak Budi"},
+		{"name": "Cici", "email": "cici@example.com"},
+	} {
+		if w := doJSON(r, http.MethodPost, "/v1/users", u); w.Code != http.StatusCreated {
+			t.Fatalf("create: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	cases := map[string][]string{
+		"q=kusuma":         {"Alea Kusuma", "Budi"},
+		"q=KUSU":           {"Alea Kusuma", "Budi"},
+		"q=example":        {"Alea Kusuma", "Cici"},
+		"q=pak+budi":       {"Budi"},
+		"q=alea@example":   {"Alea Kusuma"},
+		"q=ci":             {"Cici"}, // < 3 huruf → LIKE
+		"q=nobody":         {},
+		"q=kusuma+example": {"Alea Kusuma"},
+	}
+	for q, want := range cases {
+		got := names(searchNames(t, r, q))
+		sort.Strings(got) // urutan dicek terpisah di bawah
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: got %v, want %v", q, got, want)
+		}
+	}
+
+	// nama (bobot lebih tinggi) di atas email
+	resp := searchNames(t, r, "q=kusuma")
+	hit := resp.Items[0]
+	if hit.Highlights["name"] != "Alea <mark>Kusuma</mark>" || hit.Highlights["email"] != "" || hit.Score <= resp.Items[1].Score {
+		t.Fatalf("hit = %+v", hit)
+	}
+
+	// pagination
+	page1 := searchNames(t, r, "q=example&limit=1")
+	if len(page1.Items) != 1 || page1.NextOffset == nil || *page1.NextOffset != 1 {
+		t.Fatalf("page 1 = %+v", page1)
+	}
+	page2 := searchNames(t, r, "q=example&limit=1&offset=1")
+	if len(page2.Items) != 1 || page2.Items[0].ID == page1.Items[0].ID || page2.NextOffset != nil {
+		t.Fatalf("page 2 = %+v", page2)
+	}
+
+	// index ikut Update & Delete (trigger)
+	id := searchNames(t, r, "q=cici").Items[0].ID
+	doJSON(r, http.MethodPut, "/v1/users/"+id, map[string]any{"name": "Citra", "email": "citra@example.com"})
+	if got := names(searchNames(t, r, "q=cici")); len(got) != 0 {
+		t.Fatalf("stale index after update: %v", got)
+	}
+	if got := names(searchNames(t, r, "q=citra")); !reflect.DeepEqual(got, []string{"Citra"}) {
+		t.Fatalf("after update: %v", got)
+	}
+	doJSON(r, http.MethodDelete, "/v1/users/"+id, nil)
+	if got := names(searchNames(t, r, "q=citra")); len(got) != 0 {
+		t.Fatalf("stale index after delete: %v", got)
+	}
+
+	for _, q := range []string{"", "q=", "q=%40%40", "q=a&limit=0", "q=a&limit=101", "q=a&offset=-1"} {
+		if w := searchAs(r, q, "agent", "support"); w.Code != http.StatusBadRequest {
+			t.Fatalf("%q: want 400, got %d", q, w.Code)
+		}
+	}
+}
+
+func TestSearch_RequiresSupportRole(t *testing.T) {
+	r, _ := newSearchHTTP(t)
+	doJSON(r, http.MethodPost, "/v1/users", map[string]any{"name": "Alea", "email": "alea@example.com"})
+
+	if w := searchAs(r, "q=alea", "", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous: want 401, got %d", w.Code)
+	}
+	if w := searchAs(r, "q=alea", "u1", "user"); w.Code != http.StatusForbidden {
+		t.Fatalf("role user: want 403, got %d", w.Code)
+	}
+	for _, role := range []string{"admin", "support"} {
+		if w := searchAs(r, "q=alea", "u1", role); w.Code != http.StatusOK {
+			t.Fatalf("role %s: want 200, got %d", role, w.Code)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	cases := []struct {
+		text  string
+		terms []string
+		want  string
+	}{
+		{"Alea Kusuma", []string{"kus"}, "Alea <mark>Kus</mark>uma"},
+		{"ana@ana.io", []string{"ana"}, "<mark>ana</mark>@<mark>ana</mark>.io"},
+		{"<b>Ana</b>", []string{"ana"}, "&lt;b&gt;<mark>Ana</mark>&lt;/b&gt;"},
+		{"Ünal Öz", []string{"ünal", "z"}, "<mark>Ünal</mark> Ö<mark>z</mark>"},
+		{"Budi", []string{"x"}, ""},
+	}
+	for _, tc := range cases {
+		if got := highlight(tc.text, tc.terms); got != tc.want {
+			t.Fatalf("highlight(%q, %v) = %q, want %q", tc.text, tc.terms, got, tc.want)
+		}
+	}
+}
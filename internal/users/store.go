@@ -2,18 +2,64 @@ package users
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgconn"
 	"gorm.io/gorm"
+
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
 )
 
 type Store struct {
-	db *gorm.DB
+	dbs Conns
+}
+
+// Conns memilih koneksi baca/tulis (lihat db.Cluster: tulis ke primary,
+// baca ke replica kecuali session sudah menulis).
+type Conns interface {
+	Reader(ctx context.Context) *gorm.DB
+	Writer(ctx context.Context) *gorm.DB
 }
 
-func NewStore(db *gorm.DB) *Store { return &Store{db: db} }
+// single: satu DB untuk baca & tulis
+type single struct{ db *gorm.DB }
+
+func (s single) Reader(context.Context) *gorm.DB { return s.db }
+func (s single) Writer(context.Context) *gorm.DB { return s.db }
+
+func NewStore(db *gorm.DB) *Store { return &Store{dbs: single{db}} }
+
+// NewReplicatedStore: List/Get/FindByEmail/FindByID lewat Reader, sisanya
+// (termasuk baca di dalam Update) lewat Writer.
+func NewReplicatedStore(dbs Conns) *Store { return &Store{dbs: dbs} }
+
+type primaryKey struct{}
+
+// fromPrimary: read di ctx ini lewat Writer. Dipakai untuk mengisi cache —
+// hasil replica yang tertinggal tidak boleh tersimpan sebagai data terbaru.
+func fromPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// read/write: satu-satunya jalan ke DB, selalu dengan filter tenant dari
+// ctx. Tidak ada query users tanpa tenant_id.
+func (s *Store) read(ctx context.Context) *gorm.DB {
+	conn := s.dbs.Reader(ctx)
+	if p, _ := ctx.Value(primaryKey{}).(bool); p {
+		conn = s.dbs.Writer(ctx)
+	}
+	return conn.WithContext(ctx).Where("tenant_id = ?", tenant.ID(ctx))
+}
+
+func (s *Store) write(ctx context.Context) *gorm.DB {
+	return s.dbs.Writer(ctx).WithContext(ctx).Where("tenant_id = ?", tenant.ID(ctx))
+}
 
 // isDuplicateErr tries to normalize unique-violation across drivers.
 func isDuplicateErr(err error) bool {
@@ -40,8 +86,9 @@ func (s *Store) Create(ctx context.Context, u User) (User, error) {
 	u.Email = strings.TrimSpace(u.Email)
 	u.Name = strings.TrimSpace(u.Name)
 	u.Email = strings.ToLower(strings.TrimSpace(u.Email))
+	u.TenantID = tenant.ID(ctx) // tenant dari request, bukan dari input
 
-	if err := s.db.WithContext(ctx).Create(&u).Error; err != nil {
+	if err := s.dbs.Writer(ctx).WithContext(ctx).Create(&u).Error; err != nil {
 		if isDuplicateErr(err) {
 			return User{}, ErrDuplicate
 		}
@@ -50,20 +97,61 @@ func (s *Store) Create(ctx context.Context, u User) (User, error) {
 	return u, nil
 }
 
-func (s *Store) List(ctx context.Context) ([]User, error) {
+// List user tenant ini, urut created_at. Filter attributes memakai JSONB
+// containment (@>, index GIN) di Postgres dan JSON1 di SQLite.
+func (s *Store) List(ctx context.Context, f ListFilter) ([]User, error) {
+	q := s.read(ctx).
+		Select("id", "tenant_id", "name", "email", "created_at", "updated_at",
+			"display_name", "avatar_url", "locale", "timezone", "phone", "attributes", "avatar_key")
+	if len(f.Attributes) > 0 {
+		var err error
+		if q, err = whereAttributes(q, f.Attributes); err != nil {
+			return nil, err
+		}
+	}
 	var users []User
-	if err := s.db.WithContext(ctx).
-		Select("id", "name", "email", "created_at").
-		Order("created_at ASC").
-		Find(&users).Error; err != nil {
+	if err := q.Order("created_at ASC").Find(&users).Error; err != nil {
 		return nil, err
 	}
 	return users, nil
 }
 
+func whereAttributes(q *gorm.DB, attrs map[string]any) (*gorm.DB, error) {
+	if q.Dialector.Name() == "postgres" {
+		b, err := json.Marshal(attrs)
+		if err != nil {
+			return nil, err
+		}
+		return q.Where("attributes @> ?::jsonb", string(b)), nil
+	}
+	// SQLite JSON1: json_type membedakan "30" (text) dari 30 (integer/real)
+	// dan true dari 1.
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		path := "$." + k // key sudah divalidasi ParseAttrQuery
+		switch v := attrs[k].(type) {
+		case nil:
+			q = q.Where("json_type(attributes, ?) = 'null'", path)
+		case bool:
+			q = q.Where("json_type(attributes, ?) = ?", path, strconv.FormatBool(v))
+		case float64, int, int64, json.Number:
+			q = q.Where("json_type(attributes, ?) IN ('integer', 'real') AND json_extract(attributes, ?) = ?", path, path, v)
+		case string:
+			q = q.Where("json_type(attributes, ?) = 'text' AND json_extract(attributes, ?) = ?", path, path, v)
+		default:
+			return nil, fmt.Errorf("attribute %q: unsupported filter value %T", k, v)
+		}
+	}
+	return q, nil
+}
+
 func (s *Store) Get(ctx context.Context, id string) (User, error) {
 	var u User
-	if err := s.db.WithContext(ctx).First(&u, "id = ?", id).Error; err != nil {
+	if err := s.read(ctx).First(&u, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return User{}, ErrNotFound
 		}
@@ -74,7 +162,7 @@ func (s *Store) Get(ctx context.Context, id string) (User, error) {
 
 func (s *Store) Update(ctx context.Context, id string, data User) (User, error) {
 	var u User
-	if err := s.db.WithContext(ctx).First(&u, "id = ?", id).Error; err != nil {
+	if err := s.write(ctx).First(&u, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return User{}, ErrNotFound
 		}
@@ -90,8 +178,9 @@ func (s *Store) Update(ctx context.Context, id string, data User) (User, error)
 
 	u.Name = data.Name
 	u.Email = data.Email
+	u.Profile = data.Profile // PUT: profil diganti utuh
 
-	if err := s.db.WithContext(ctx).Save(&u).Error; err != nil {
+	if err := s.write(ctx).Save(&u).Error; err != nil {
 		if isDuplicateErr(err) {
 			return User{}, ErrDuplicate
 		}
@@ -100,8 +189,36 @@ func (s *Store) Update(ctx context.Context, id string, data User) (User, error)
 	return u, nil
 }
 
+// SetAvatar mengganti prefix avatar dan mengembalikan prefix lama (untuk
+// dihapus). Compare-and-swap: dua upload bersamaan tidak saling menimpa
+// tanpa ketahuan, prefix lama selalu dikembalikan ke salah satunya.
+func (s *Store) SetAvatar(ctx context.Context, id, key string) (User, string, error) {
+	for attempt := 0; attempt < 3; attempt++ {
+		var u User
+		if err := s.write(ctx).First(&u, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return User{}, "", ErrNotFound
+			}
+			return User{}, "", err
+		}
+		old := u.AvatarKey
+		now := time.Now().UTC()
+		res := s.write(ctx).Model(&User{}).
+			Where("id = ? AND avatar_key = ?", id, old).
+			Updates(map[string]any{"avatar_key": key, "updated_at": now})
+		if res.Error != nil {
+			return User{}, "", res.Error
+		}
+		if res.RowsAffected == 1 {
+			u.AvatarKey, u.UpdatedAt = key, now
+			return u, old, nil
+		}
+	}
+	return User{}, "", errors.New("avatar changed concurrently, retry")
+}
+
 func (s *Store) Delete(ctx context.Context, id string) error {
-	res := s.db.WithContext(ctx).Delete(&User{}, "id = ?", id)
+	res := s.write(ctx).Delete(&User{}, "id = ?", id)
 	if res.Error != nil {
 		return res.Error
 	}
@@ -113,12 +230,12 @@ func (s *Store) Delete(ctx context.Context, id string) error {
 
 func (s *Store) FindByEmail(ctx context.Context, email string) (User, error) {
 	var u User
-	err := s.db.WithContext(ctx).Where("email = ?", email).First(&u).Error
+	err := s.read(ctx).Where("email = ?", email).First(&u).Error
 	return u, err
 }
 
 func (s *Store) FindByID(ctx context.Context, id string) (User, error) {
 	var u User
-	err := s.db.WithContext(ctx).Where("id = ?", id).First(&u).Error
+	err := s.read(ctx).Where("id = ?", id).First(&u).Error
 	return u, err
 }
@@ -2,71 +2,312 @@ package users
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 
+	"github.com/Quineeryn/go-backend-101/internal/cache"
 	"github.com/Quineeryn/go-backend-101/internal/httpx"
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
 )
 
+// CacheOptions mengatur perlindungan stampede di CachedStore.Get. Nilai 0
+// mematikan fitur terkait.
+type CacheOptions struct {
+	// StaleTTL: entry yang lewat TTL masih dilayani selama ini sambil satu
+	// request me-refresh di background (stale-while-revalidate).
+	StaleTTL time.Duration
+	// NegativeTTL: ErrNotFound ikut di-cache supaya banjir ID salah tidak
+	// sampai ke DB. Create/Update menimpa entry ini.
+	NegativeTTL time.Duration
+	// EarlyRefreshBeta: refresh probabilistik sebelum expired (XFetch);
+	// makin besar makin awal. 1 = nilai yang disarankan paper.
+	EarlyRefreshBeta float64
+	// LockTTL: lock Redis lintas replica saat miss; replica lain menunggu
+	// paling lama LockWait lalu membaca hasilnya (atau load sendiri).
+	LockTTL  time.Duration
+	LockWait time.Duration
+	// ListTTL: hasil List per bentuk query (filter); dibatalkan oleh setiap
+	// write lewat counter versi per tenant (cache.Namespace).
+	ListTTL time.Duration
+	// Serializer: codec + kompresi value di Redis (nil = JSON). Schema
+	// diisi CachedStore (cacheSchema).
+	Serializer *cache.Serializer
+}
+
+// DefaultCacheOptions dipakai NewCachedStore.
+func DefaultCacheOptions() CacheOptions {
+	return CacheOptions{
+		StaleTTL:         time.Minute,
+		NegativeTTL:      30 * time.Second,
+		EarlyRefreshBeta: 1,
+		LockTTL:          3 * time.Second,
+		LockWait:         200 * time.Millisecond,
+		ListTTL:          time.Minute,
+	}
+}
+
+// batas waktu load yang dipakai bersama (single-flight / refresh background):
+// tidak ikut ctx request pertama supaya pembatalannya tidak menggagalkan
+// request lain yang ikut menunggu.
+const cacheLoadTimeout = 5 * time.Second
+
 type CachedStore struct {
 	inner *Store
-	rdb   *redis.Client
-	ttl   time.Duration
+	rdb   redis.UniversalClient
+	lists *cache.Namespace
+	ttl   atomic.Int64 // time.Duration; bisa diganti saat runtime
+	opt   atomic.Pointer[CacheOptions]
+
+	group      singleflight.Group
+	refreshing sync.Map // key → struct{}: refresh background yang sedang jalan
 }
 
-func NewCachedStore(inner *Store, rdb *redis.Client, ttl time.Duration) *CachedStore {
-	return &CachedStore{inner: inner, rdb: rdb, ttl: ttl}
+func NewCachedStore(inner *Store, rdb redis.UniversalClient, ttl time.Duration) *CachedStore {
+	s := &CachedStore{inner: inner, rdb: rdb}
+	if rdb != nil {
+		s.lists = cache.NewNamespace(rdb, "user_list")
+	}
+	s.SetTTL(ttl)
+	s.SetOptions(DefaultCacheOptions())
+	return s
 }
 
-func keyUser(id string) string { return "app:users:" + id }
+// SetTTL mengganti TTL untuk entry yang ditulis setelahnya; entry lama
+// tetap memakai TTL saat ditulis.
+func (s *CachedStore) SetTTL(d time.Duration) { s.ttl.Store(int64(d)) }
 
-// Get: cache-aside
+// TTL yang sedang berlaku.
+func (s *CachedStore) TTL() time.Duration { return time.Duration(s.ttl.Load()) }
+
+// SetOptions: aman dipanggil saat runtime (hot reload). Entry yang ditulis
+// dengan codec lain tetap terbaca (codec ada di header envelope).
+func (s *CachedStore) SetOptions(o CacheOptions) {
+	if o.Serializer == nil {
+		o.Serializer = cache.DefaultSerializer
+	}
+	o.Serializer = o.Serializer.WithSchema(cacheSchema)
+	s.opt.Store(&o)
+}
+
+func (s *CachedStore) Options() CacheOptions { return *s.opt.Load() }
+
+// key per tenant: ID user yang sama di tenant lain tidak pernah kena cache ini
+func keyUser(ctx context.Context, id string) string {
+	return "app:users:" + tenant.ID(ctx) + ":" + id
+}
+
+// cacheEntry: value di Redis. Key hidup TTL + StaleTTL; FreshUntil menandai
+// batas segar.
+type cacheEntry struct {
+	User       *cachedUser `json:"user,omitempty"`
+	NotFound   bool        `json:"not_found,omitempty"`
+	FreshUntil int64       `json:"fresh_until"`        // unix ms
+	Delta      int64       `json:"delta_us,omitempty"` // µs, lama load dari DB (XFetch)
+}
+
+func (e cacheEntry) result() (User, error) {
+	if e.NotFound {
+		return User{}, ErrNotFound
+	}
+	return e.User.user(), nil
+}
+
+// Get: cache-aside dengan single-flight per key, lock Redis lintas replica,
+// stale-while-revalidate, refresh awal probabilistik dan negative cache.
 func (s *CachedStore) Get(ctx context.Context, id string) (User, error) {
-	k := keyUser(id)
-	if s.rdb != nil {
-		if b, err := s.rdb.Get(ctx, k).Bytes(); err == nil && len(b) > 0 {
-			var u User
-			if json.Unmarshal(b, &u) == nil {
-				httpx.CacheHit.WithLabelValues("user").Inc()
-				return u, nil
-			}
-		} else {
-			httpx.CacheMiss.WithLabelValues("user").Inc()
+	if s.rdb == nil {
+		return s.inner.Get(ctx, id)
+	}
+	k := keyUser(ctx, id)
+	if e, ok := s.lookup(ctx, k); ok {
+		httpx.CacheHit.WithLabelValues("user").Inc()
+		now := time.Now()
+		switch {
+		case e.NotFound:
+			httpx.CacheEvents.WithLabelValues("user", "negative_hit").Inc()
+		case now.UnixMilli() >= e.FreshUntil:
+			s.refreshAsync(ctx, id, k, "stale_served")
+		case earlyRefresh(e, now, s.Options().EarlyRefreshBeta):
+			s.refreshAsync(ctx, id, k, "early_refresh")
 		}
+		return e.result()
 	}
+	httpx.CacheMiss.WithLabelValues("user").Inc()
 
-	u, err := s.inner.Get(ctx, id)
+	v, err, shared := s.group.Do(k, func() (any, error) {
+		lctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cacheLoadTimeout)
+		defer cancel()
+		return s.fill(lctx, id, k, true)
+	})
+	if shared {
+		httpx.CacheEvents.WithLabelValues("user", "coalesced").Inc()
+	}
 	if err != nil {
-		return u, err
+		return User{}, err
 	}
+	return v.(User), nil
+}
 
-	if s.rdb != nil {
-		if b, err := json.Marshal(u); err == nil {
-			_ = s.rdb.Set(ctx, k, b, s.ttl).Err()
+// earlyRefresh: XFetch (Vattani dkk.) — refresh bila
+// now - delta·beta·ln(rand) >= expiry. Load yang lambat refresh lebih awal.
+func earlyRefresh(e cacheEntry, now time.Time, beta float64) bool {
+	if beta <= 0 || e.Delta <= 0 {
+		return false
+	}
+	gap := -float64(e.Delta) / 1000 * beta * math.Log(1-rand.Float64()) // ms; 1-x: hindari log(0)
+	return float64(now.UnixMilli())+gap >= float64(e.FreshUntil)
+}
+
+// refreshAsync me-load ulang di background; paling banyak satu per key per
+// proses (dan per cluster bila lock aktif).
+func (s *CachedStore) refreshAsync(ctx context.Context, id, k, event string) {
+	if _, busy := s.refreshing.LoadOrStore(k, struct{}{}); busy {
+		return
+	}
+	httpx.CacheEvents.WithLabelValues("user", event).Inc()
+	bg := context.WithoutCancel(ctx) // tenant tetap terbawa
+	go func() {
+		defer s.refreshing.Delete(k)
+		lctx, cancel := context.WithTimeout(bg, cacheLoadTimeout)
+		defer cancel()
+		// bukan lewat group: hasil kosong saat lock dipegang replica lain
+		// tidak boleh terbagi ke Get yang sedang miss
+		_, _ = s.fill(lctx, id, k, false)
+	}()
+}
+
+// unlockScript: DEL hanya bila lock masih milik kita (belum expired & diambil
+// replica lain).
+var unlockScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+
+// fill: load dari DB lalu tulis cache. Bila replica lain memegang lock:
+// wait=true menunggu hasilnya (miss), wait=false menyerah (refresh).
+func (s *CachedStore) fill(ctx context.Context, id, k string, wait bool) (User, error) {
+	opt := s.Options()
+	if opt.LockTTL > 0 {
+		lock, token := k+":lock", uuid.NewString()
+		ok, err := s.rdb.SetNX(ctx, lock, token, opt.LockTTL).Result()
+		switch {
+		case err != nil:
+			// Redis bermasalah: load langsung, single-flight lokal tetap berlaku
+		case ok:
+			defer unlockScript.Run(context.WithoutCancel(ctx), s.rdb, []string{lock}, token)
+		case !wait:
+			return User{}, nil
+		default:
+			httpx.CacheEvents.WithLabelValues("user", "lock_wait").Inc()
+			if e, ok := s.waitFor(ctx, k, opt.LockWait); ok {
+				return e.result()
+			}
+		}
+	}
+
+	start := time.Now()
+	u, err := s.inner.Get(fromPrimary(ctx), id)
+	if errors.Is(err, ErrNotFound) {
+		if opt.NegativeTTL > 0 {
+			s.put(ctx, k, cacheEntry{NotFound: true}, opt.NegativeTTL, 0)
 		}
+		return User{}, ErrNotFound
 	}
+	if err != nil {
+		return User{}, err
+	}
+	s.put(ctx, k, cacheEntry{User: toCached(u), Delta: time.Since(start).Microseconds()}, s.TTL(), opt.StaleTTL)
 	return u, nil
 }
 
-// List: (opsional) tidak di-cache dulu
-func (s *CachedStore) List(ctx context.Context) ([]User, error) {
-	return s.inner.List(ctx)
+// waitFor polling key sampai ada (ditulis pemegang lock) atau d habis.
+func (s *CachedStore) waitFor(ctx context.Context, k string, d time.Duration) (cacheEntry, bool) {
+	deadline := time.Now().Add(d)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return cacheEntry{}, false
+		case <-time.After(10 * time.Millisecond):
+		}
+		if e, ok := s.lookup(ctx, k); ok {
+			return e, true
+		}
+	}
+	return cacheEntry{}, false
+}
+
+// lookup: entry yang rusak / format atau schema lain dianggap miss.
+func (s *CachedStore) lookup(ctx context.Context, k string) (cacheEntry, bool) {
+	b, err := s.rdb.Get(ctx, k).Bytes()
+	if err != nil || len(b) == 0 {
+		return cacheEntry{}, false
+	}
+	var e cacheEntry
+	if s.Options().Serializer.Decode(b, &e) != nil || (e.User == nil && !e.NotFound) {
+		return cacheEntry{}, false
+	}
+	return e, true
+}
+
+// put: key hidup ttl + stale; segar sampai ttl.
+func (s *CachedStore) put(ctx context.Context, k string, e cacheEntry, ttl, stale time.Duration) {
+	e.FreshUntil = time.Now().Add(ttl).UnixMilli()
+	if b, err := s.Options().Serializer.Encode(e); err == nil {
+		_ = s.rdb.Set(ctx, k, b, ttl+stale).Err()
+	}
+}
+
+// setUser: dipakai setelah tulis DB (Create/Update/SetAvatar).
+func (s *CachedStore) setUser(ctx context.Context, id string, u User) {
+	if s.rdb != nil {
+		s.put(ctx, keyUser(ctx, id), cacheEntry{User: toCached(u)}, s.TTL(), s.Options().StaleTTL)
+	}
+	s.InvalidateLists(ctx)
+}
+
+// List: di-cache per tenant + filter selama ListTTL; versi list tenant
+// naik setiap write sehingga tidak ada baca basi setelah tulis.
+func (s *CachedStore) List(ctx context.Context, f ListFilter) ([]User, error) {
+	opt := s.Options()
+	if s.lists == nil || opt.ListTTL <= 0 {
+		return s.inner.List(ctx, f)
+	}
+	l, err := cache.Cached(ctx, s.lists.WithSerializer(opt.Serializer), tenant.ID(ctx), f, opt.ListTTL, func(ctx context.Context) (cachedUserList, error) {
+		// dari primary: versi list sudah naik, isi replica bisa belum
+		us, err := s.inner.List(fromPrimary(ctx), f)
+		return toCachedList(us), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l.users(), nil
 }
 
-// Create: tulis DB, lalu pre-warm cache
+// InvalidateLists membatalkan semua cache List tenant di ctx. Write lewat
+// CachedStore memanggilnya sendiri; write lain lewat ListInvalidator.
+func (s *CachedStore) InvalidateLists(ctx context.Context) {
+	if s.lists != nil {
+		s.lists.Bump(ctx, tenant.ID(ctx))
+	}
+}
+
+// Search: tidak di-cache (query bebas, hit rate rendah)
+func (s *CachedStore) Search(ctx context.Context, q SearchQuery) ([]SearchResult, error) {
+	return s.inner.Search(ctx, q)
+}
+
+// Create: tulis DB, lalu pre-warm cache (menimpa negative entry bila ada)
 func (s *CachedStore) Create(ctx context.Context, u User) (User, error) {
 	created, err := s.inner.Create(ctx, u)
 	if err != nil {
 		return created, err
 	}
-	if s.rdb != nil {
-		k := keyUser(created.ID)
-		if b, err := json.Marshal(created); err == nil {
-			_ = s.rdb.Set(ctx, k, b, s.ttl).Err()
-		}
-	}
+	s.setUser(ctx, created.ID, created)
 	return created, nil
 }
 
@@ -76,22 +317,28 @@ func (s *CachedStore) Update(ctx context.Context, id string, data User) (User, e
 	if err != nil {
 		return updated, err
 	}
-	if s.rdb != nil {
-		k := keyUser(id)
-		if b, err := json.Marshal(updated); err == nil {
-			_ = s.rdb.Set(ctx, k, b, s.ttl).Err()
-		}
-	}
+	s.setUser(ctx, id, updated)
 	return updated, nil
 }
 
+// SetAvatar: tulis DB, lalu SET ulang cache
+func (s *CachedStore) SetAvatar(ctx context.Context, id, key string) (User, string, error) {
+	updated, old, err := s.inner.SetAvatar(ctx, id, key)
+	if err != nil {
+		return updated, old, err
+	}
+	s.setUser(ctx, id, updated)
+	return updated, old, nil
+}
+
 // Delete: hapus DB, lalu DEL cache
 func (s *CachedStore) Delete(ctx context.Context, id string) error {
 	if err := s.inner.Delete(ctx, id); err != nil {
 		return err
 	}
 	if s.rdb != nil {
-		_ = s.rdb.Del(ctx, keyUser(id)).Err()
+		_ = s.rdb.Del(ctx, keyUser(ctx, id)).Err()
 	}
+	s.InvalidateLists(ctx)
 	return nil
 }
@@ -0,0 +1,460 @@
+package users
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/Quineeryn/go-backend-101/internal/cache"
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
+)
+
+// newCachedFixture: Store SQLite dengan penghitung query (tiap query ditahan
+// delay supaya request konkuren benar-benar tumpang tindih) + miniredis.
+func newCachedFixture(t *testing.T, delay time.Duration) (*Store, *gorm.DB, *redis.Client, *atomic.Int64) {
+	t.Helper()
+	db := newTestDB(t)
+	var n atomic.Int64
+	if err := db.Callback().Query().Before("gorm:query").Register("test:count", func(*gorm.DB) {
+		n.Add(1)
+		time.Sleep(delay)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return NewStore(db), db, rdb, &n
+}
+
+func seedUser(t *testing.T, s *Store, name string) User {
+	t.Helper()
+	u, err := s.Create(context.Background(), User{ID: uuid.NewString(), Name: name, Email: name + "-" + uuid.NewString()[:8] + "@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func getConcurrently(stores []*CachedStore, id string, perStore int) []error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(stores)*perStore)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = stores[i%len(stores)].Get(context.Background(), id)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestCachedStore_CoalescesMisses(t *testing.T) {
+	store, _, rdb, n := newCachedFixture(t, 30*time.Millisecond)
+	u := seedUser(t, store, "hot")
+
+	// dua "replica": single-flight lokal + lock Redis
+	replicas := []*CachedStore{NewCachedStore(store, rdb, time.Minute), NewCachedStore(store, rdb, time.Minute)}
+	for _, err := range getConcurrently(replicas, u.ID, 20) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := n.Load(); got != 1 {
+		t.Fatalf("db queries = %d, want 1", got)
+	}
+}
+
+func TestCachedStore_NegativeCache(t *testing.T) {
+	store, _, rdb, n := newCachedFixture(t, 0)
+	s := NewCachedStore(store, rdb, time.Minute)
+	ctx := context.Background()
+	id := uuid.NewString()
+
+	for range 5 {
+		if _, err := s.Get(ctx, id); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("err = %v", err)
+		}
+	}
+	if got := n.Load(); got != 1 {
+		t.Fatalf("db queries = %d, want 1", got)
+	}
+
+	// Create menimpa negative entry
+	if _, err := s.Create(ctx, User{ID: id, Name: "late", Email: "late-" + id + "@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if u, err := s.Get(ctx, id); err != nil || u.Name != "late" {
+		t.Fatalf("get after create = %+v, %v", u, err)
+	}
+
+	// NegativeTTL 0 = mati
+	o := s.Options()
+	o.NegativeTTL = 0
+	s.SetOptions(o)
+	other := uuid.NewString()
+	before := n.Load()
+	_, _ = s.Get(ctx, other)
+	_, _ = s.Get(ctx, other)
+	if got := n.Load() - before; got != 2 {
+		t.Fatalf("db queries without negative cache = %d, want 2", got)
+	}
+}
+
+func TestCachedStore_StaleWhileRevalidate(t *testing.T) {
+	store, db, rdb, n := newCachedFixture(t, 0)
+	u := seedUser(t, store, "old")
+	s := NewCachedStore(store, rdb, 20*time.Millisecond)
+	o := s.Options()
+	o.EarlyRefreshBeta = 0
+	s.SetOptions(o)
+	ctx := context.Background()
+
+	if _, err := s.Get(ctx, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&User{}).Where("id = ?", u.ID).Update("name", "new").Error; err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond) // lewat TTL, masih dalam StaleTTL
+
+	got, err := s.Get(ctx, u.ID)
+	if err != nil || got.Name != "old" {
+		t.Fatalf("stale get = %q, %v; want old value served immediately", got.Name, err)
+	}
+	eventually(t, func() bool {
+		e, ok := s.lookup(ctx, keyUser(ctx, u.ID))
+		return ok && e.User.Name == "new"
+	})
+	if got := n.Load(); got != 2 {
+		t.Fatalf("db queries = %d, want 2 (load + one refresh)", got)
+	}
+}
+
+func TestCachedStore_EarlyRefresh(t *testing.T) {
+	store, _, rdb, n := newCachedFixture(t, time.Millisecond)
+	u := seedUser(t, store, "early")
+	s := NewCachedStore(store, rdb, time.Minute)
+	ctx := context.Background()
+
+	if _, err := s.Get(ctx, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	// beta default: TTL 1m jauh di atas delta 1ms → tidak refresh
+	for range 20 {
+		_, _ = s.Get(ctx, u.ID)
+	}
+	if got := n.Load(); got != 1 {
+		t.Fatalf("db queries = %d, want 1", got)
+	}
+
+	o := s.Options()
+	o.EarlyRefreshBeta = 1e9
+	s.SetOptions(o)
+	if _, err := s.Get(ctx, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, func() bool { return n.Load() == 2 })
+}
+
+func TestEarlyRefresh_Probability(t *testing.T) {
+	now := time.Now()
+	e := cacheEntry{Delta: 1000, FreshUntil: now.Add(time.Hour).UnixMilli()} // 1ms load
+	for range 1000 {
+		if earlyRefresh(e, now, 1) {
+			t.Fatal("refresh an hour early")
+		}
+	}
+	e.FreshUntil = now.UnixMilli()
+	if !earlyRefresh(e, now, 1) {
+		t.Fatal("no refresh at expiry")
+	}
+	if earlyRefresh(e, now, 0) {
+		t.Fatal("beta 0 must disable")
+	}
+}
+
+// listJSON: bandingkan lewat JSON (waktu hasil decode cache ≠ DeepEqual).
+func listJSON(t *testing.T, us []User) string {
+	t.Helper()
+	if len(us) == 0 {
+		return "[]"
+	}
+	b, err := json.Marshal(us)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// Harness: setiap write (lewat CachedStore atau ListInvalidator) harus
+// langsung terlihat di List berikutnya, bukan menunggu TTL.
+func TestCachedStore_NoStaleReadAfterWrite(t *testing.T) {
+	t.Run("single", func(t *testing.T) {
+		store, _, rdb, _ := newCachedFixture(t, 0)
+		testNoStaleReadAfterWrite(t, store, store, rdb, func() {})
+	})
+	// replica selalu satu write di belakang; setiap ctx tanpa session (request
+	// lain), jadi Reader tidak pernah di-pin ke primary
+	t.Run("lagging_replica", func(t *testing.T) {
+		c := conns{primary: openNamedDB(t, "primary"), replica: openNamedDB(t, "replica")}
+		rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+		t.Cleanup(func() { _ = rdb.Close() })
+		replicate := func() {
+			var all []User
+			if err := c.primary.Find(&all).Error; err != nil {
+				t.Fatal(err)
+			}
+			c.replica.Exec("DELETE FROM users")
+			if len(all) > 0 {
+				if err := c.replica.Create(&all).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		testNoStaleReadAfterWrite(t, NewReplicatedStore(c), NewStore(c.primary), rdb, replicate)
+	})
+}
+
+// testNoStaleReadAfterWrite: write acak lewat CachedStore; setelah tiap write
+// List & Get (miss lalu hit) harus sama dengan primary (truth). lag dipanggil
+// sebelum tiap write.
+func testNoStaleReadAfterWrite(t *testing.T, store, truth *Store, rdb *redis.Client, lag func()) {
+	s := NewCachedStore(store, rdb, time.Minute)
+	ctx := context.Background()
+	filters := []ListFilter{{}, {Attributes: map[string]any{"plan": "pro"}}}
+	var ids, gone []string
+
+	check := func(step string) {
+		t.Helper()
+		for _, f := range filters {
+			want, err := truth.List(ctx, f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range 2 { // miss lalu hit
+				got, err := s.List(ctx, f)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if g, w := listJSON(t, got), listJSON(t, want); g != w {
+					t.Fatalf("%s: stale list for %+v\n got  %s\n want %s", step, f, g, w)
+				}
+			}
+		}
+		for _, id := range ids {
+			want, _ := truth.Get(ctx, id)
+			for range 2 {
+				if got, err := s.Get(ctx, id); err != nil || listJSON(t, []User{got}) != listJSON(t, []User{want}) {
+					t.Fatalf("%s: stale get %s: %+v, %v", step, id, got, err)
+				}
+			}
+		}
+		for _, id := range gone {
+			if _, err := s.Get(ctx, id); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("%s: deleted user %s still readable (err=%v)", step, id, err)
+			}
+		}
+	}
+	plan := func(r *rand.Rand) Attributes {
+		return Attributes{"plan": []string{"pro", "free"}[r.IntN(2)]}
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	check("empty")
+	for i := range 60 {
+		var op string
+		lag()
+		switch n := rng.IntN(4); {
+		case n == 0 || len(ids) == 0:
+			op = "create"
+			u, err := s.Create(ctx, User{ID: uuid.NewString(), Name: fmt.Sprint("u", i), Email: fmt.Sprintf("u%d-%s@example.com", i, uuid.NewString()[:8]), Profile: Profile{Attributes: plan(rng)}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, u.ID)
+		case n == 1:
+			op = "update"
+			id := ids[rng.IntN(len(ids))]
+			cur, _ := truth.Get(ctx, id)
+			if _, err := s.Update(ctx, id, User{Name: fmt.Sprint("renamed", i), Email: cur.Email, Profile: Profile{Attributes: plan(rng)}}); err != nil {
+				t.Fatal(err)
+			}
+		case n == 2:
+			op = "avatar"
+			if _, _, err := s.SetAvatar(ctx, ids[rng.IntN(len(ids))], fmt.Sprint("avatars/k", i)); err != nil {
+				t.Fatal(err)
+			}
+		default:
+			op = "delete"
+			j := rng.IntN(len(ids))
+			if err := s.Delete(ctx, ids[j]); err != nil {
+				t.Fatal(err)
+			}
+			gone = append(gone, ids[j])
+			ids = append(ids[:j], ids[j+1:]...)
+		}
+		check(fmt.Sprintf("step %d (%s)", i, op))
+	}
+
+	// write di luar CachedStore (register di auth) → ListInvalidator
+	lag()
+	u, err := store.Create(ctx, User{ID: uuid.NewString(), Name: "registered", Email: "reg-" + uuid.NewString()[:8] + "@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ListInvalidator{Store: s}.Publish(ctx, EventUserCreated, map[string]string{"id": u.ID})
+	check("after register")
+}
+
+func TestCachedStore_ListCachePerTenantAndMetrics(t *testing.T) {
+	store, _, rdb, n := newCachedFixture(t, 0)
+	s := NewCachedStore(store, rdb, time.Minute)
+	a, b := tenant.WithID(context.Background(), "a"), tenant.WithID(context.Background(), "b")
+
+	hits := func(res string) float64 { return testutil.ToFloat64(httpx.CacheHit.WithLabelValues(res)) }
+	listHits, itemHits := hits("user_list"), hits("user")
+
+	for range 3 {
+		if _, err := s.List(a, ListFilter{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, _ = s.List(b, ListFilter{})
+	if got := n.Load(); got != 2 {
+		t.Fatalf("db queries = %d, want 2 (one per tenant)", got)
+	}
+	if got := hits("user_list") - listHits; got != 2 {
+		t.Fatalf("list hits = %v, want 2", got)
+	}
+	if hits("user") != itemHits {
+		t.Fatal("list hits counted as item hits")
+	}
+
+	// write di tenant b tidak membatalkan list tenant a
+	if _, err := s.Create(b, User{ID: uuid.NewString(), Name: "b", Email: "b-" + uuid.NewString()[:8] + "@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = s.List(a, ListFilter{})
+	if got := n.Load(); got != 2 {
+		t.Fatalf("db queries = %d, want 2", got)
+	}
+}
+
+// Setiap codec/kompresi: nilai hasil cache sama dengan hasil query, dan
+// PasswordHash tidak pernah sampai ke Redis.
+func TestCachedStore_CodecsMatchDBWithoutSecrets(t *testing.T) {
+	for _, codec := range []string{"json", "msgpack", "protobuf"} {
+		for _, comp := range []string{"none", "zstd", "snappy"} {
+			t.Run(codec+"/"+comp, func(t *testing.T) {
+				store, _, rdb, n := newCachedFixture(t, 0)
+				hash := "bcrypt-hash-" + uuid.NewString()
+				ctx := context.Background()
+				u, err := store.Create(ctx, User{
+					ID: uuid.NewString(), Name: "codec", Email: "codec-" + uuid.NewString()[:8] + "@example.com",
+					PasswordHash: &hash,
+					Profile:      Profile{Locale: "id-ID", Attributes: Attributes{"plan": "pro", "seats": 3.0, "tags": []any{"a", "b"}}},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				s := NewCachedStore(store, rdb, time.Minute)
+				o := s.Options()
+				o.Serializer = cache.MustSerializer(cache.SerializerOptions{Codec: codec, Compression: comp, CompressMin: 1})
+				s.SetOptions(o)
+
+				want, err := store.Get(ctx, u.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := s.Get(ctx, u.ID); err != nil { // miss → isi cache
+					t.Fatal(err)
+				}
+				before := n.Load()
+				got, err := s.Get(ctx, u.ID)
+				if err != nil || n.Load() != before {
+					t.Fatalf("second get should be a hit: err=%v", err)
+				}
+				if got.PasswordHash != nil {
+					t.Fatal("cached user carries PasswordHash")
+				}
+				want.PasswordHash = nil
+				if g, w := listJSON(t, []User{got}), listJSON(t, []User{want}); g != w {
+					t.Fatalf("cached value differs from DB\n got  %s\n want %s", g, w)
+				}
+
+				wantList, err := store.List(ctx, ListFilter{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, _ = s.List(ctx, ListFilter{})
+				before = n.Load()
+				list, err := s.List(ctx, ListFilter{})
+				if err != nil || n.Load() != before {
+					t.Fatalf("second list should be a hit: err=%v", err)
+				}
+				if g, w := listJSON(t, list), listJSON(t, wantList); g != w {
+					t.Fatalf("cached list differs from DB\n got  %s\n want %s", g, w)
+				}
+
+				keys, _ := rdb.Keys(ctx, "*").Result()
+				for _, k := range keys {
+					raw, _ := rdb.Get(ctx, k).Bytes()
+					if bytes.Contains(raw, []byte(hash)) {
+						t.Fatalf("password hash leaked into %s", k)
+					}
+				}
+			})
+		}
+	}
+}
+
+// Entry schema lain (atau format lama tanpa envelope) = miss lalu ditimpa.
+func TestCachedStore_OtherSchemaIsMiss(t *testing.T) {
+	store, _, rdb, n := newCachedFixture(t, 0)
+	u := seedUser(t, store, "schema")
+	s := NewCachedStore(store, rdb, time.Minute)
+	ctx := context.Background()
+	k := keyUser(ctx, u.ID)
+
+	old, _ := cache.DefaultSerializer.WithSchema(cacheSchema + 1).Encode(cacheEntry{User: &cachedUser{ID: u.ID, Name: "wrong"}, FreshUntil: time.Now().Add(time.Hour).UnixMilli()})
+	for _, raw := range [][]byte{old, []byte(`{"user":{"id":"x","name":"legacy"},"fresh_until":99999999999999}`)} {
+		if err := rdb.Set(ctx, k, raw, time.Minute).Err(); err != nil {
+			t.Fatal(err)
+		}
+		before := n.Load()
+		got, err := s.Get(ctx, u.ID)
+		if err != nil || got.Name != "schema" || n.Load() != before+1 {
+			t.Fatalf("want DB value via miss, got %q err=%v queries=%d", got.Name, err, n.Load()-before)
+		}
+		if e, ok := s.lookup(ctx, k); !ok || e.User.Name != "schema" {
+			t.Fatal("entry should be overwritten with the current schema")
+		}
+	}
+}
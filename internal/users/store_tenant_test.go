@@ -0,0 +1,70 @@
+package users
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Quineeryn/go-backend-101/internal/tenant"
+)
+
+func TestStore_TenantIsolation(t *testing.T) {
+	db := openNamedDB(t, "tenants")
+	// unique index sama dengan migrasi 000007
+	if err := db.Exec(`CREATE UNIQUE INDEX users_tenant_email_key ON users(tenant_id, email)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	s := NewStore(db)
+	ctxA := tenant.WithID(context.Background(), "acme")
+	ctxB := tenant.WithID(context.Background(), "globex")
+
+	// TenantID dari input diabaikan: selalu tenant ctx
+	a, err := s.Create(ctxA, User{ID: uuid.NewString(), TenantID: "globex", Name: "Ana", Email: "ana@example.com"})
+	if err != nil {
+		t.Fatalf("create A: %v", err)
+	}
+	if a.TenantID != "acme" {
+		t.Fatalf("want tenant acme, got %q", a.TenantID)
+	}
+
+	// email sama boleh di tenant lain, tidak boleh di tenant yang sama
+	b, err := s.Create(ctxB, User{ID: uuid.NewString(), Name: "Ana B", Email: "ana@example.com"})
+	if err != nil {
+		t.Fatalf("same email in other tenant: %v", err)
+	}
+	if _, err := s.Create(ctxA, User{ID: uuid.NewString(), Name: "Dup", Email: "ANA@example.com"}); !isErrDuplicate(err) {
+		t.Fatalf("want duplicate within tenant, got %v", err)
+	}
+
+	// tenant B tidak bisa melihat/mengubah/menghapus user tenant A
+	if _, err := s.Get(ctxB, a.ID); !isErrNotFound(err) {
+		t.Fatalf("Get cross-tenant: want not found, got %v", err)
+	}
+	if _, err := s.FindByID(ctxB, a.ID); err == nil {
+		t.Fatal("FindByID cross-tenant must fail")
+	}
+	if u, err := s.FindByEmail(ctxB, "ana@example.com"); err != nil || u.ID != b.ID {
+		t.Fatalf("FindByEmail must return own tenant's user, got %+v err=%v", u, err)
+	}
+	if _, err := s.Update(ctxB, a.ID, User{Name: "Hacked", Email: "x@example.com"}); !isErrNotFound(err) {
+		t.Fatalf("Update cross-tenant: want not found, got %v", err)
+	}
+	if err := s.Delete(ctxB, a.ID); !isErrNotFound(err) {
+		t.Fatalf("Delete cross-tenant: want not found, got %v", err)
+	}
+	if list, _ := s.List(ctxB, ListFilter{}); len(list) != 1 || list[0].ID != b.ID {
+		t.Fatalf("List B: want only B's user, got %+v", list)
+	}
+
+	// data A utuh
+	got, err := s.Get(ctxA, a.ID)
+	if err != nil || got.Name != "Ana" || got.TenantID != "acme" {
+		t.Fatalf("A's user changed: %+v err=%v", got, err)
+	}
+
+	// tanpa tenant di ctx → tenant default, bukan "semua tenant"
+	if list, _ := s.List(context.Background(), ListFilter{}); len(list) != 0 {
+		t.Fatalf("ctx without tenant must not see other tenants, got %d", len(list))
+	}
+}
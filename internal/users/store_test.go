@@ -131,7 +131,7 @@ func TestStore_List_AscendingByCreatedAt(t *testing.T) {
 	time.Sleep(2 * time.Millisecond)
 	u2, _ := s.Create(ctx, User{ID: uuid.NewString(), Name: "B", Email: "b@example.com"})
 
-	list, err := s.List(ctx)
+	list, err := s.List(ctx, ListFilter{})
 	if err != nil {
 		t.Fatalf("List: %v", err)
 	}
@@ -0,0 +1,369 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+	"github.com/Quineeryn/go-backend-101/internal/logger"
+)
+
+type Options struct {
+	Workers      int           // jumlah worker pengirim (default 4)
+	QueueSize    int           // buffer event in-memory (default 1024)
+	PollInterval time.Duration // interval cek retry yang jatuh tempo (default 2s)
+	Timeout      time.Duration // timeout per HTTP attempt (default 10s)
+	MaxAttempts  int           // setelah ini → dead-letter (default 8)
+	BaseBackoff  time.Duration // retry ke-1 (default 10s), lalu x2
+	MaxBackoff   time.Duration // batas atas backoff (default 1h)
+	Client       *http.Client
+}
+
+func (o *Options) defaults() {
+	if o.Workers <= 0 {
+		o.Workers = 4
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 1024
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = 2 * time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 8
+	}
+	if o.BaseBackoff <= 0 {
+		o.BaseBackoff = 10 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Hour
+	}
+	if o.Client == nil {
+		o.Client = &http.Client{Timeout: o.Timeout}
+	}
+}
+
+// Backoff: base * 2^(attempt-1), dibatasi max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= max || d <= 0 {
+			return max
+		}
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+type event struct {
+	id      string
+	typ     string
+	payload []byte
+}
+
+// Dispatcher menerima event dari request path (non-blocking), menyimpan
+// delivery ke DB, lalu worker di background yang mengirim + retry.
+type Dispatcher struct {
+	store *Store
+	opt   Options
+
+	events chan event
+	wake   chan struct{}
+	work   chan string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+func NewDispatcher(store *Store, opt Options) *Dispatcher {
+	opt.defaults()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		store:  store,
+		opt:    opt,
+		events: make(chan event, opt.QueueSize),
+		wake:   make(chan struct{}, 1),
+		work:   make(chan string),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Publish tidak pernah block: kalau antrean penuh, event di-drop (dan dicatat).
+func (d *Dispatcher) Publish(_ context.Context, typ string, data any) {
+	ev := event{id: uuid.NewString(), typ: typ}
+	b, err := json.Marshal(map[string]any{
+		"id":         ev.id,
+		"type":       typ,
+		"created_at": time.Now().UTC().Format(time.RFC3339Nano),
+		"data":       data,
+	})
+	if err != nil {
+		logger.L.Error("webhook.publish.marshal", zap.String("event", typ), zap.Error(err))
+		return
+	}
+	ev.payload = b
+
+	select {
+	case d.events <- ev:
+	default:
+		httpx.WebhookEventsDropped.Inc()
+		logger.L.Warn("webhook.publish.dropped", zap.String("event", typ), zap.String("event_id", ev.id))
+	}
+}
+
+func (d *Dispatcher) Start() {
+	d.wg.Add(2 + d.opt.Workers)
+	go d.intake()
+	go d.schedule()
+	for i := 0; i < d.opt.Workers; i++ {
+		go d.worker()
+	}
+}
+
+// Stop menghentikan worker; event yang masih di antrean tetap disimpan ke DB
+// sehingga terkirim setelah restart.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	d.once.Do(d.cancel)
+	done := make(chan struct{})
+	go func() { d.wg.Wait(); close(done) }()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Redeliver menjadwalkan ulang payload delivery lama sebagai delivery baru.
+func (d *Dispatcher) Redeliver(ctx context.Context, subID, deliveryID string) (Delivery, error) {
+	old, err := d.store.GetDelivery(ctx, subID, deliveryID)
+	if err != nil {
+		return Delivery{}, err
+	}
+	now := time.Now().UTC()
+	nd := Delivery{
+		ID:             uuid.NewString(),
+		SubscriptionID: old.SubscriptionID,
+		EventID:        old.EventID,
+		Event:          old.Event,
+		Payload:        old.Payload,
+		Status:         StatusPending,
+		NextAttemptAt:  &now,
+	}
+	if err := d.store.CreateDelivery(ctx, &nd); err != nil {
+		return Delivery{}, err
+	}
+	d.notify()
+	return nd, nil
+}
+
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) staleAfter() time.Duration {
+	return 2*d.opt.Timeout + d.opt.PollInterval
+}
+
+func (d *Dispatcher) intake() {
+	defer d.wg.Done()
+	for {
+		select {
+		case ev := <-d.events:
+			d.fanout(d.ctx, ev)
+		case <-d.ctx.Done():
+			// drain sisa antrean ke DB
+			for {
+				select {
+				case ev := <-d.events:
+					d.fanout(context.Background(), ev)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) fanout(ctx context.Context, ev event) {
+	subs, err := d.store.ActiveSubscriptions(ctx, ev.typ)
+	if err != nil {
+		logger.L.Error("webhook.fanout.subscriptions", zap.String("event", ev.typ), zap.Error(err))
+		return
+	}
+	now := time.Now().UTC()
+	for _, s := range subs {
+		del := Delivery{
+			ID:             uuid.NewString(),
+			SubscriptionID: s.ID,
+			EventID:        ev.id,
+			Event:          ev.typ,
+			Payload:        string(ev.payload),
+			Status:         StatusPending,
+			NextAttemptAt:  &now,
+		}
+		if err := d.store.CreateDelivery(ctx, &del); err != nil {
+			logger.L.Error("webhook.fanout.create", zap.String("subscription_id", s.ID), zap.Error(err))
+		}
+	}
+	if len(subs) > 0 {
+		d.notify()
+	}
+}
+
+func (d *Dispatcher) schedule() {
+	defer d.wg.Done()
+	defer close(d.work)
+
+	t := time.NewTicker(d.opt.PollInterval)
+	defer t.Stop()
+	for {
+		d.dispatchDue()
+		select {
+		case <-t.C:
+		case <-d.wake:
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) dispatchDue() {
+	now := time.Now().UTC()
+	due, err := d.store.DueDeliveries(d.ctx, now, d.staleAfter(), 100)
+	if err != nil {
+		if d.ctx.Err() == nil {
+			logger.L.Error("webhook.schedule.due", zap.Error(err))
+		}
+		return
+	}
+	for _, del := range due {
+		ok, err := d.store.Claim(d.ctx, del.ID, now, d.staleAfter())
+		if err != nil || !ok {
+			continue
+		}
+		select {
+		case d.work <- del.ID:
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+	for id := range d.work {
+		d.attempt(id)
+	}
+}
+
+func (d *Dispatcher) attempt(id string) {
+	// pakai background ctx: attempt yang sudah jalan diselesaikan walau sedang shutdown
+	ctx := context.Background()
+
+	del, err := d.store.deliveryByID(ctx, id)
+	if err != nil {
+		logger.L.Error("webhook.attempt.load", zap.String("delivery_id", id), zap.Error(err))
+		return
+	}
+	sub, err := d.store.GetSubscription(ctx, del.SubscriptionID)
+	if err != nil || !sub.Active {
+		del.Status = StatusDead
+		del.LastError = "subscription not found or inactive"
+		_ = d.store.SaveAttempt(ctx, &del)
+		httpx.WebhookDeliveries.WithLabelValues(del.Event, "dead").Inc()
+		return
+	}
+
+	code, sendErr := d.send(ctx, sub, del)
+	del.Attempts++
+	del.LastStatusCode = code
+	del.LastError = ""
+	if sendErr != nil {
+		del.LastError = sendErr.Error()
+	}
+
+	now := time.Now().UTC()
+	switch {
+	case sendErr == nil:
+		del.Status = StatusSucceeded
+		del.DeliveredAt = &now
+		del.NextAttemptAt = nil
+		httpx.WebhookDeliveries.WithLabelValues(del.Event, "success").Inc()
+	case del.Attempts >= d.opt.MaxAttempts:
+		del.Status = StatusDead
+		del.NextAttemptAt = nil
+		httpx.WebhookDeliveries.WithLabelValues(del.Event, "dead").Inc()
+		logger.L.Warn("webhook.delivery.dead",
+			zap.String("delivery_id", del.ID),
+			zap.String("subscription_id", sub.ID),
+			zap.Int("attempts", del.Attempts),
+			zap.String("error", del.LastError),
+		)
+	default:
+		next := now.Add(Backoff(del.Attempts, d.opt.BaseBackoff, d.opt.MaxBackoff))
+		del.Status = StatusPending
+		del.NextAttemptAt = &next
+		httpx.WebhookDeliveries.WithLabelValues(del.Event, "retry").Inc()
+	}
+
+	if err := d.store.SaveAttempt(ctx, &del); err != nil {
+		logger.L.Error("webhook.attempt.save", zap.String("delivery_id", del.ID), zap.Error(err))
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, sub Subscription, del Delivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.opt.Timeout)
+	defer cancel()
+
+	body := []byte(del.Payload)
+	ts := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-backend-101-webhooks/1")
+	req.Header.Set(HeaderEvent, del.Event)
+	req.Header.Set(HeaderDelivery, del.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, ts, body))
+
+	res, err := d.opt.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("receiver responded %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
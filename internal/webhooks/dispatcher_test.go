@@ -0,0 +1,230 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := AutoMigrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func fastOptions() Options {
+	return Options{
+		Workers:      2,
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+		MaxAttempts:  3,
+		BaseBackoff:  5 * time.Millisecond,
+		MaxBackoff:   20 * time.Millisecond,
+	}
+}
+
+func seedSub(t *testing.T, s *Store, url, events string) Subscription {
+	t.Helper()
+	sub, err := s.CreateSubscription(context.Background(), Subscription{
+		ID: uuid.NewString(), URL: url, Events: events, Secret: "topsecret", Active: true,
+	})
+	if err != nil {
+		t.Fatalf("create sub: %v", err)
+	}
+	return sub
+}
+
+// waitDeliveries polling sampai cond terpenuhi (atau timeout).
+func waitDeliveries(t *testing.T, s *Store, subID string, cond func([]Delivery) bool) []Delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		list, err := s.ListDeliveries(context.Background(), subID, 100)
+		if err != nil {
+			t.Fatalf("list deliveries: %v", err)
+		}
+		if cond(list) {
+			return list
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting deliveries, got %+v", list)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBackoff_DoublesAndCaps(t *testing.T) {
+	base, max := time.Second, 5*time.Second
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := Backoff(i+1, base, max); got != w {
+			t.Fatalf("attempt %d: want %s, got %s", i+1, w, got)
+		}
+	}
+}
+
+func TestDispatcher_DeliversSignedPayload(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got <- received{header: r.Header.Clone(), body: b}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	store := NewStore(newTestDB(t))
+	sub := seedSub(t, store, srv.URL, "user.created")
+	d := NewDispatcher(store, fastOptions())
+	d.Start()
+	defer d.Stop(context.Background())
+
+	d.Publish(context.Background(), "user.created", map[string]string{"id": "u1"})
+	d.Publish(context.Background(), "user.deleted", map[string]string{"id": "u1"}) // tidak di-subscribe
+
+	var r received
+	select {
+	case r = <-got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("receiver never called")
+	}
+
+	if r.header.Get(HeaderEvent) != "user.created" {
+		t.Fatalf("want event header user.created, got %q", r.header.Get(HeaderEvent))
+	}
+	if !Verify(sub.Secret, r.header.Get(HeaderSignature), r.header.Get(HeaderTimestamp), r.body, time.Minute, time.Now()) {
+		t.Fatalf("signature did not verify: %s", r.header.Get(HeaderSignature))
+	}
+	var env struct {
+		Type string            `json:"type"`
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(r.body, &env); err != nil || env.Type != "user.created" || env.Data["id"] != "u1" {
+		t.Fatalf("unexpected payload %s (err=%v)", r.body, err)
+	}
+
+	list := waitDeliveries(t, store, sub.ID, func(l []Delivery) bool {
+		return len(l) == 1 && l[0].Status == StatusSucceeded
+	})
+	if list[0].Attempts != 1 || list[0].LastStatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected delivery log: %+v", list[0])
+	}
+}
+
+func TestDispatcher_RetriesThenSucceeds(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	store := NewStore(newTestDB(t))
+	sub := seedSub(t, store, srv.URL, "*")
+	d := NewDispatcher(store, fastOptions())
+	d.Start()
+	defer d.Stop(context.Background())
+
+	d.Publish(context.Background(), "user.updated", map[string]string{"id": "u2"})
+
+	list := waitDeliveries(t, store, sub.ID, func(l []Delivery) bool {
+		return len(l) == 1 && l[0].Status == StatusSucceeded
+	})
+	if list[0].Attempts != 3 {
+		t.Fatalf("want 3 attempts, got %d", list[0].Attempts)
+	}
+}
+
+func TestDispatcher_DeadLetterAndRedeliver(t *testing.T) {
+	var healthy atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	store := NewStore(newTestDB(t))
+	sub := seedSub(t, store, srv.URL, "user.deleted")
+	d := NewDispatcher(store, fastOptions())
+	d.Start()
+	defer d.Stop(context.Background())
+
+	d.Publish(context.Background(), "user.deleted", map[string]string{"id": "u3"})
+
+	dead := waitDeliveries(t, store, sub.ID, func(l []Delivery) bool {
+		return len(l) == 1 && l[0].Status == StatusDead
+	})
+	if dead[0].Attempts != 3 || dead[0].LastStatusCode != http.StatusInternalServerError {
+		t.Fatalf("unexpected dead delivery: %+v", dead[0])
+	}
+
+	healthy.Store(true)
+	nd, err := d.Redeliver(context.Background(), sub.ID, dead[0].ID)
+	if err != nil {
+		t.Fatalf("redeliver: %v", err)
+	}
+	if nd.EventID != dead[0].EventID {
+		t.Fatalf("redelivery should keep event id")
+	}
+	waitDeliveries(t, store, sub.ID, func(l []Delivery) bool {
+		for _, x := range l {
+			if x.ID == nd.ID && x.Status == StatusSucceeded {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func TestDispatcher_PublishNeverBlocks(t *testing.T) {
+	store := NewStore(newTestDB(t))
+	opt := fastOptions()
+	opt.QueueSize = 1
+	d := NewDispatcher(store, opt) // sengaja tidak di-Start: antrean tidak pernah dikonsumsi
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			d.Publish(context.Background(), "user.created", i)
+		}
+	}()
+	go func() { wg.Wait(); close(done) }()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a full queue")
+	}
+}
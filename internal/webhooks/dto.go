@@ -0,0 +1,50 @@
+package webhooks
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+type CreateSubscriptionRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"` // opsional; kosong → digenerate server
+	Active *bool    `json:"active"`
+}
+
+type UpdateSubscriptionRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"` // kosong → secret lama dipertahankan
+	Active *bool    `json:"active"`
+}
+
+type SubscriptionResponse struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	Secret    string    `json:"secret,omitempty"` // hanya dikembalikan saat create
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func toResponse(s Subscription) SubscriptionResponse {
+	return SubscriptionResponse{
+		ID:        s.ID,
+		URL:       s.URL,
+		Events:    s.EventList(),
+		Active:    s.Active,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+func validURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
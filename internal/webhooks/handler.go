@@ -0,0 +1,193 @@
+package webhooks
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Quineeryn/go-backend-101/internal/apperr"
+	"github.com/Quineeryn/go-backend-101/internal/httpx"
+)
+
+type Handler struct {
+	store  *Store
+	disp   *Dispatcher
+	events map[string]bool // event yang boleh di-subscribe ("*" selalu boleh)
+}
+
+func NewHandler(store *Store, disp *Dispatcher, events []string) *Handler {
+	known := map[string]bool{"*": true}
+	for _, e := range events {
+		known[e] = true
+	}
+	return &Handler{store: store, disp: disp, events: known}
+}
+
+func (h *Handler) validEvents(events []string) bool {
+	if len(events) == 0 {
+		return false
+	}
+	for _, e := range splitEvents(joinEvents(events)) {
+		if !h.events[e] {
+			return false
+		}
+	}
+	return true
+}
+
+// POST /v1/webhooks
+func (h *Handler) Create(c *gin.Context) {
+	var req CreateSubscriptionRequest
+	if err := httpx.DecodeJSON(c.Request, &req); err != nil {
+		httpx.AbortError(c, "webhooks.create", apperr.E(apperr.Validation, "invalid request body", err))
+		return
+	}
+	if !validURL(req.URL) {
+		httpx.AbortError(c, "webhooks.create", apperr.E(apperr.Validation, "url must be an absolute http(s) URL", nil))
+		return
+	}
+	if !h.validEvents(req.Events) {
+		httpx.AbortError(c, "webhooks.create", apperr.E(apperr.Validation, "events must be a non-empty list of known events", nil))
+		return
+	}
+	secret := req.Secret
+	if secret == "" {
+		s, err := newSecret()
+		if err != nil {
+			httpx.AbortError(c, "webhooks.create", apperr.E(apperr.Internal, "failed to generate secret", err))
+			return
+		}
+		secret = s
+	}
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	sub, err := h.store.CreateSubscription(c.Request.Context(), Subscription{
+		ID:     uuid.NewString(),
+		URL:    req.URL,
+		Events: joinEvents(req.Events),
+		Secret: secret,
+		Active: active,
+	})
+	if err != nil {
+		httpx.AbortError(c, "webhooks.create", apperr.E(apperr.Internal, "failed to create webhook", err))
+		return
+	}
+	out := toResponse(sub)
+	out.Secret = sub.Secret
+	c.JSON(http.StatusCreated, out)
+}
+
+// GET /v1/webhooks
+func (h *Handler) List(c *gin.Context) {
+	subs, err := h.store.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		httpx.AbortError(c, "webhooks.list", apperr.E(apperr.Internal, "failed to list webhooks", err))
+		return
+	}
+	out := make([]SubscriptionResponse, 0, len(subs))
+	for _, s := range subs {
+		out = append(out, toResponse(s))
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// GET /v1/webhooks/:id
+func (h *Handler) Get(c *gin.Context) {
+	sub, err := h.store.GetSubscription(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortStoreErr(c, "webhooks.get", "webhook not found", err)
+		return
+	}
+	c.JSON(http.StatusOK, toResponse(sub))
+}
+
+// PUT /v1/webhooks/:id
+func (h *Handler) Update(c *gin.Context) {
+	var req UpdateSubscriptionRequest
+	if err := httpx.DecodeJSON(c.Request, &req); err != nil {
+		httpx.AbortError(c, "webhooks.update", apperr.E(apperr.Validation, "invalid request body", err))
+		return
+	}
+	if !validURL(req.URL) {
+		httpx.AbortError(c, "webhooks.update", apperr.E(apperr.Validation, "url must be an absolute http(s) URL", nil))
+		return
+	}
+	if !h.validEvents(req.Events) {
+		httpx.AbortError(c, "webhooks.update", apperr.E(apperr.Validation, "events must be a non-empty list of known events", nil))
+		return
+	}
+
+	cur, err := h.store.GetSubscription(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abortStoreErr(c, "webhooks.update", "webhook not found", err)
+		return
+	}
+	cur.URL = req.URL
+	cur.Events = joinEvents(req.Events)
+	if req.Secret != "" {
+		cur.Secret = req.Secret
+	}
+	if req.Active != nil {
+		cur.Active = *req.Active
+	}
+
+	updated, err := h.store.UpdateSubscription(c.Request.Context(), cur)
+	if err != nil {
+		abortStoreErr(c, "webhooks.update", "webhook not found", err)
+		return
+	}
+	c.JSON(http.StatusOK, toResponse(updated))
+}
+
+// DELETE /v1/webhooks/:id
+func (h *Handler) Delete(c *gin.Context) {
+	if err := h.store.DeleteSubscription(c.Request.Context(), c.Param("id")); err != nil {
+		abortStoreErr(c, "webhooks.delete", "webhook not found", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GET /v1/webhooks/:id/deliveries?limit=50
+func (h *Handler) Deliveries(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := h.store.GetSubscription(c.Request.Context(), id); err != nil {
+		abortStoreErr(c, "webhooks.deliveries", "webhook not found", err)
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		httpx.AbortError(c, "webhooks.deliveries", apperr.E(apperr.Validation, "limit must be between 1 and 500", err))
+		return
+	}
+	list, err := h.store.ListDeliveries(c.Request.Context(), id, limit)
+	if err != nil {
+		httpx.AbortError(c, "webhooks.deliveries", apperr.E(apperr.Internal, "failed to list deliveries", err))
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// POST /v1/webhooks/:id/deliveries/:delivery_id/redeliver
+func (h *Handler) Redeliver(c *gin.Context) {
+	d, err := h.disp.Redeliver(c.Request.Context(), c.Param("id"), c.Param("delivery_id"))
+	if err != nil {
+		abortStoreErr(c, "webhooks.redeliver", "delivery not found", err)
+		return
+	}
+	c.JSON(http.StatusAccepted, d)
+}
+
+func abortStoreErr(c *gin.Context, op, notFoundMsg string, err error) {
+	if errors.Is(err, ErrNotFound) {
+		httpx.AbortError(c, op, apperr.E(apperr.NotFound, notFoundMsg, err))
+		return
+	}
+	httpx.AbortError(c, op, apperr.E(apperr.Internal, "webhook storage error", err))
+}
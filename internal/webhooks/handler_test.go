@@ -0,0 +1,108 @@
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Quineeryn/go-backend-101/internal/middleware"
+)
+
+func newHTTP(t *testing.T) (*gin.Engine, *Store) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	store := NewStore(newTestDB(t))
+	h := NewHandler(store, NewDispatcher(store, fastOptions()), []string{"user.created", "user.updated"})
+
+	r := gin.New()
+	r.Use(middleware.ErrorEnvelope())
+	RegisterRoutes(r, h)
+	return r, store
+}
+
+func doJSON(r *gin.Engine, method, path string, body any) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_CRUD(t *testing.T) {
+	r, _ := newHTTP(t)
+
+	w := doJSON(r, http.MethodPost, "/v1/webhooks", map[string]any{
+		"url":    "https://example.com/hook",
+		"events": []string{"user.created", "USER.CREATED"},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create want 201, got %d body=%s", w.Code, w.Body.String())
+	}
+	var created SubscriptionResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Secret == "" {
+		t.Fatalf("create should return generated secret")
+	}
+	if len(created.Events) != 1 || created.Events[0] != "user.created" {
+		t.Fatalf("events should be normalized+deduped, got %v", created.Events)
+	}
+
+	// secret tidak pernah bocor setelah create
+	g := doJSON(r, http.MethodGet, "/v1/webhooks/"+created.ID, nil)
+	if g.Code != http.StatusOK || bytes.Contains(g.Body.Bytes(), []byte(created.Secret)) {
+		t.Fatalf("get: code=%d body=%s", g.Code, g.Body.String())
+	}
+
+	u := doJSON(r, http.MethodPut, "/v1/webhooks/"+created.ID, map[string]any{
+		"url":    "https://example.com/hook2",
+		"events": []string{"user.updated"},
+		"active": false,
+	})
+	if u.Code != http.StatusOK || !bytes.Contains(u.Body.Bytes(), []byte(`"active":false`)) {
+		t.Fatalf("update: code=%d body=%s", u.Code, u.Body.String())
+	}
+
+	l := doJSON(r, http.MethodGet, "/v1/webhooks/"+created.ID+"/deliveries", nil)
+	if l.Code != http.StatusOK || l.Body.String() != "[]" {
+		t.Fatalf("deliveries: code=%d body=%s", l.Code, l.Body.String())
+	}
+
+	if d := doJSON(r, http.MethodDelete, "/v1/webhooks/"+created.ID, nil); d.Code != http.StatusNoContent {
+		t.Fatalf("delete want 204, got %d", d.Code)
+	}
+	if g := doJSON(r, http.MethodGet, "/v1/webhooks/"+created.ID, nil); g.Code != http.StatusNotFound {
+		t.Fatalf("get after delete want 404, got %d", g.Code)
+	}
+}
+
+func TestHandler_Create_400_Validation(t *testing.T) {
+	r, _ := newHTTP(t)
+
+	cases := []map[string]any{
+		{"url": "ftp://example.com", "events": []string{"user.created"}},
+		{"url": "https://example.com", "events": []string{}},
+		{"url": "https://example.com", "events": []string{"order.paid"}},
+	}
+	for _, body := range cases {
+		if w := doJSON(r, http.MethodPost, "/v1/webhooks", body); w.Code != http.StatusBadRequest {
+			t.Fatalf("want 400 for %v, got %d body=%s", body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestHandler_Redeliver_404(t *testing.T) {
+	r, _ := newHTTP(t)
+	w := doJSON(r, http.MethodPost, "/v1/webhooks/nope/deliveries/nope/redeliver", nil)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("want 404, got %d body=%s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,80 @@
+package webhooks
+
+import (
+	"strings"
+	"time"
+)
+
+// Status delivery
+const (
+	StatusPending   = "pending"   // menunggu dikirim (baru / retry terjadwal)
+	StatusSending   = "sending"   // sedang di-claim worker
+	StatusSucceeded = "succeeded" // receiver balas 2xx
+	StatusDead      = "dead"      // dead-letter: attempts habis
+)
+
+type Subscription struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	URL       string    `json:"url" gorm:"not null"`
+	Events    string    `json:"-" gorm:"not null"` // comma-separated, mis. "user.created,user.deleted"
+	Secret    string    `json:"-" gorm:"not null"`
+	Active    bool      `json:"active" gorm:"not null;default:true"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (Subscription) TableName() string { return "webhook_subscriptions" }
+
+func (s Subscription) EventList() []string { return splitEvents(s.Events) }
+
+// Wants: "*" berarti semua event.
+func (s Subscription) Wants(event string) bool {
+	for _, e := range s.EventList() {
+		if e == "*" || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+type Delivery struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	SubscriptionID string     `json:"subscription_id" gorm:"index;not null"`
+	EventID        string     `json:"event_id" gorm:"not null"`
+	Event          string     `json:"event" gorm:"not null"`
+	Payload        string     `json:"-" gorm:"not null"`
+	Status         string     `json:"status" gorm:"index;not null"`
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty" gorm:"index"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (Delivery) TableName() string { return "webhook_deliveries" }
+
+func splitEvents(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func joinEvents(events []string) string {
+	seen := map[string]bool{}
+	var out []string
+	for _, e := range events {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" || seen[e] {
+			continue
+		}
+		seen[e] = true
+		out = append(out, e)
+	}
+	return strings.Join(out, ",")
+}
@@ -0,0 +1,17 @@
+package webhooks
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes: mw biasanya RequireAuth + RequireRole("admin").
+func RegisterRoutes(r *gin.Engine, h *Handler, mw ...gin.HandlerFunc) {
+	g := r.Group("/v1/webhooks", mw...)
+	{
+		g.POST("", h.Create)
+		g.GET("", h.List)
+		g.GET("/:id", h.Get)
+		g.PUT("/:id", h.Update)
+		g.DELETE("/:id", h.Delete)
+		g.GET("/:id/deliveries", h.Deliveries)
+		g.POST("/:id/deliveries/:delivery_id/redeliver", h.Redeliver)
+	}
+}
@@ -0,0 +1,179 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrNotFound = errors.New("record not found")
+
+type Store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) *Store { return &Store{db: db} }
+
+// AutoMigrate untuk SQLite/dev (Postgres pakai db/migrations).
+func AutoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Subscription{}, &Delivery{})
+}
+
+// ==== subscriptions ====
+
+func (s *Store) CreateSubscription(ctx context.Context, sub Subscription) (Subscription, error) {
+	if err := s.db.WithContext(ctx).Create(&sub).Error; err != nil {
+		return Subscription{}, err
+	}
+	return sub, nil
+}
+
+func (s *Store) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	var out []Subscription
+	if err := s.db.WithContext(ctx).Order("created_at ASC").Find(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Store) GetSubscription(ctx context.Context, id string) (Subscription, error) {
+	var sub Subscription
+	if err := s.db.WithContext(ctx).First(&sub, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Subscription{}, ErrNotFound
+		}
+		return Subscription{}, err
+	}
+	return sub, nil
+}
+
+func (s *Store) UpdateSubscription(ctx context.Context, sub Subscription) (Subscription, error) {
+	res := s.db.WithContext(ctx).Model(&Subscription{}).Where("id = ?", sub.ID).
+		Updates(map[string]any{
+			"url":        sub.URL,
+			"events":     sub.Events,
+			"secret":     sub.Secret,
+			"active":     sub.Active,
+			"updated_at": time.Now().UTC(),
+		})
+	if res.Error != nil {
+		return Subscription{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return Subscription{}, ErrNotFound
+	}
+	return s.GetSubscription(ctx, sub.ID)
+}
+
+func (s *Store) DeleteSubscription(ctx context.Context, id string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Delete(&Subscription{}, "id = ?", id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return tx.Delete(&Delivery{}, "subscription_id = ?", id).Error
+	})
+}
+
+// ActiveSubscriptions: subscription aktif yang berlangganan event tsb.
+func (s *Store) ActiveSubscriptions(ctx context.Context, event string) ([]Subscription, error) {
+	var all []Subscription
+	if err := s.db.WithContext(ctx).Where("active = ?", true).Find(&all).Error; err != nil {
+		return nil, err
+	}
+	out := all[:0]
+	for _, sub := range all {
+		if sub.Wants(event) {
+			out = append(out, sub)
+		}
+	}
+	return out, nil
+}
+
+// ==== deliveries ====
+
+func (s *Store) CreateDelivery(ctx context.Context, d *Delivery) error {
+	return s.db.WithContext(ctx).Create(d).Error
+}
+
+func (s *Store) GetDelivery(ctx context.Context, subID, id string) (Delivery, error) {
+	var d Delivery
+	if err := s.db.WithContext(ctx).First(&d, "id = ? AND subscription_id = ?", id, subID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Delivery{}, ErrNotFound
+		}
+		return Delivery{}, err
+	}
+	return d, nil
+}
+
+func (s *Store) deliveryByID(ctx context.Context, id string) (Delivery, error) {
+	var d Delivery
+	err := s.db.WithContext(ctx).First(&d, "id = ?", id).Error
+	return d, err
+}
+
+func (s *Store) ListDeliveries(ctx context.Context, subID string, limit int) ([]Delivery, error) {
+	var out []Delivery
+	if err := s.db.WithContext(ctx).
+		Where("subscription_id = ?", subID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DueDeliveries: pending yang sudah jatuh tempo, plus "sending" yang macet
+// (worker mati di tengah jalan) lebih lama dari staleAfter.
+func (s *Store) DueDeliveries(ctx context.Context, now time.Time, staleAfter time.Duration, limit int) ([]Delivery, error) {
+	var out []Delivery
+	if err := s.db.WithContext(ctx).
+		Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND updated_at <= ?)",
+			StatusPending, now, StatusSending, now.Add(-staleAfter)).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Claim menandai delivery sebagai "sending". False bila sudah diambil worker lain.
+func (s *Store) Claim(ctx context.Context, id string, now time.Time, staleAfter time.Duration) (bool, error) {
+	res := s.db.WithContext(ctx).Model(&Delivery{}).
+		Where("id = ? AND (status = ? OR (status = ? AND updated_at <= ?))",
+			id, StatusPending, StatusSending, now.Add(-staleAfter)).
+		Updates(map[string]any{"status": StatusSending, "updated_at": now})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (s *Store) SaveAttempt(ctx context.Context, d *Delivery) error {
+	return s.db.WithContext(ctx).Model(&Delivery{}).Where("id = ?", d.ID).
+		Updates(map[string]any{
+			"status":           d.Status,
+			"attempts":         d.Attempts,
+			"next_attempt_at":  d.NextAttemptAt,
+			"last_status_code": d.LastStatusCode,
+			"last_error":       d.LastError,
+			"delivered_at":     d.DeliveredAt,
+			"updated_at":       time.Now().UTC(),
+		}).Error
+}
+
+// PurgeBefore menghapus log delivery final (succeeded/dead) yang lebih tua dari cutoff.
+func (s *Store) PurgeBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).
+		Where("status IN ? AND updated_at < ?", []string{StatusSucceeded, StatusDead}, before).
+		Delete(&Delivery{})
+	return res.RowsAffected, res.Error
+}